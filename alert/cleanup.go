@@ -0,0 +1,147 @@
+package alert
+
+import (
+	"dragon-alert-bot/bot"
+	"dragon-alert-bot/db"
+	"dragon-alert-bot/dragon"
+	"log"
+	"sort"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// 提醒消息记录的类型（alert_messages.kind）
+const (
+	messageAlert = "alert" // 提醒文字消息
+	messageRoad  = "road"  // 随提醒附带的路单图片
+)
+
+// recordAlertMessage 记录已发送的提醒消息（每条长龙一行）
+func recordAlertMessage(chatID int64, messageID int, kind string, results []*dragon.PatternResult) {
+	for _, r := range results {
+		_, err := db.WriteDB.Exec(`
+			INSERT INTO alert_messages (chat_id, message_id, pattern_type, attribute_type, start_qihao, kind)
+			VALUES (?, ?, ?, ?, ?, ?)
+		`, chatID, messageID, r.PatternType, r.AttributeType, r.StartQihao, kind)
+		if err != nil {
+			log.Printf("[提醒记录] 群组:%d 消息:%d 记录失败: %v", chatID, messageID, err)
+			return
+		}
+	}
+}
+
+// threadAnchor 查找本次提醒要回复的消息：优先最长的长龙在本群组的第一条提醒，没有则返回0
+func threadAnchor(chatID int64, results []*dragon.PatternResult) int {
+	var conds []string
+	var args []interface{}
+	args = append(args, chatID)
+	for _, r := range results {
+		conds = append(conds, "(pattern_type = ? AND attribute_type = ? AND start_qihao = ?)")
+		args = append(args, r.PatternType, r.AttributeType, r.StartQihao)
+	}
+
+	rows, err := db.WriteDB.Query(`
+		SELECT pattern_type, attribute_type, start_qihao, MIN(message_id)
+		FROM alert_messages
+		WHERE chat_id = ? AND kind = 'alert' AND deleted_at IS NULL AND (`+strings.Join(conds, " OR ")+`)
+		GROUP BY pattern_type, attribute_type, start_qihao
+	`, args...)
+	if err != nil {
+		return 0
+	}
+	defer rows.Close()
+
+	first := make(map[string]int)
+	for rows.Next() {
+		var pattern, attr, start string
+		var messageID int
+		if err := rows.Scan(&pattern, &attr, &start, &messageID); err != nil {
+			continue
+		}
+		first[pattern+"/"+attr+"/"+start] = messageID
+	}
+
+	sorted := append([]*dragon.PatternResult(nil), results...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Count > sorted[j].Count })
+	for _, r := range sorted {
+		if id, ok := first[r.PatternType+"/"+r.AttributeType+"/"+r.StartQihao]; ok {
+			return id
+		}
+	}
+	return 0
+}
+
+// deletePreviousAlerts 删除群组中除 keepID 以外尚未删除的提醒
+func deletePreviousAlerts(chatID int64, keepID int) {
+	rows, err := db.WriteDB.Query(`
+		SELECT DISTINCT message_id FROM alert_messages
+		WHERE chat_id = ? AND deleted_at IS NULL AND message_id <> ?
+	`, chatID, keepID)
+	if err != nil {
+		return
+	}
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if rows.Scan(&id) == nil {
+			ids = append(ids, id)
+		}
+	}
+	rows.Close()
+
+	deleteAlerts(chatID, ids)
+}
+
+// deleteAlerts 删除提醒消息，删除成功后标记为已删除（超过48小时等无法删除的也标记，不再重试）
+// 其他原因删除失败的不标记，下次清理时重试
+func deleteAlerts(chatID int64, messageIDs []int) {
+	for _, id := range messageIDs {
+		id := id
+		bot.Enqueue(chatID, tgbotapi.NewDeleteMessage(chatID, id), bot.PriorityNormal, func(_ tgbotapi.Message, err error) {
+			if err != nil && !undeletable(err) {
+				return
+			}
+			db.WriteDB.Exec("UPDATE alert_messages SET deleted_at = NOW() WHERE chat_id = ? AND message_id = ?", chatID, id)
+		})
+	}
+}
+
+// undeletable 消息已不存在或已超过可删除时间，重试也无法删除
+func undeletable(err error) bool {
+	desc := err.Error()
+	return strings.Contains(desc, "message to delete not found") || strings.Contains(desc, "message can't be deleted")
+}
+
+// deleteExpiredAlerts 删除超时模式下超过保留时间的提醒
+func (d *Dispatcher) deleteExpiredAlerts() {
+	rows, err := db.WriteDB.Query(`
+		SELECT DISTINCT a.chat_id, a.message_id
+		FROM alert_messages a
+		JOIN chat_configs c ON c.chat_id = a.chat_id
+		WHERE c.cleanup_mode = ? AND a.deleted_at IS NULL
+		AND a.sent_at < NOW() - INTERVAL c.cleanup_minutes MINUTE
+	`, bot.CleanupExpire)
+	if err != nil {
+		log.Printf("[提醒清理] 查询失败: %v", err)
+		return
+	}
+
+	expired := make(map[int64][]int)
+	for rows.Next() {
+		var chatID int64
+		var messageID int
+		if rows.Scan(&chatID, &messageID) == nil {
+			expired[chatID] = append(expired[chatID], messageID)
+		}
+	}
+	rows.Close()
+
+	for chatID, ids := range expired {
+		deleteAlerts(chatID, ids)
+	}
+
+	// 提醒记录保留一周
+	db.WriteDB.Exec("DELETE FROM alert_messages WHERE sent_at < NOW() - INTERVAL 7 DAY")
+}
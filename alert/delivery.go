@@ -0,0 +1,91 @@
+package alert
+
+import (
+	"dragon-alert-bot/db"
+	"dragon-alert-bot/dragon"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// 发送日志的类型
+const (
+	DeliveryAlert  = "alert"  // 长龙提醒
+	DeliveryRecord = "record" // 平/破纪录提醒
+	DeliveryDigest = "digest" // 汇总消息
+	DeliveryBoard  = "board"  // 实时榜单（代替逐条提醒）
+)
+
+// deliveryRetention 发送日志的保留天数（每日统计永久保留）
+const deliveryRetention = 30
+
+// logDelivery 记录一次提醒发送的结果，并累加到群组当天的统计
+// currentData 为空或没有开奖时间时不计算延迟
+func logDelivery(chatID int64, kind string, results []*dragon.PatternResult, currentData *dragon.CurrentLotteryInfo, messageID int, sendErr error) {
+	var dragons []string
+	for _, r := range results {
+		dragons = append(dragons, fmt.Sprintf("%s/%s/%s:%d", r.AttributeType, r.PatternType, r.StartQihao, r.Count))
+	}
+
+	var qihao string
+	var latency interface{}
+	latencyMs := 0
+	if currentData != nil {
+		qihao = currentData.Qihao
+		if !currentData.OpenTime.IsZero() {
+			latencyMs = int(time.Since(currentData.OpenTime).Milliseconds())
+			latency = latencyMs
+		}
+	}
+
+	status, errText := "sent", ""
+	if sendErr != nil {
+		status, errText = "failed", truncate(sendErr.Error(), 255)
+	}
+
+	_, err := db.WriteDB.Exec(`
+		INSERT INTO alert_deliveries (chat_id, kind, qihao, dragons, dragon_count, message_id, latency_ms, status, error)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, chatID, kind, qihao, truncate(strings.Join(dragons, " "), 1000), len(results), messageID, latency, status, errText)
+	if err != nil {
+		log.Printf("[发送日志] 群组:%d 记录失败: %v", chatID, err)
+	}
+
+	// 延迟只统计发送成功的消息
+	sent, failed, latencyCount := 1, 0, 0
+	if sendErr != nil {
+		sent, failed, latencyMs = 0, 1, 0
+	} else if latency != nil {
+		latencyCount = 1
+	}
+
+	_, err = db.WriteDB.Exec(`
+		INSERT INTO alert_daily_stats (chat_id, stat_date, sent, failed, dragons, latency_total, latency_count, latency_max)
+		VALUES (?, CURDATE(), ?, ?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE
+			sent = sent + VALUES(sent),
+			failed = failed + VALUES(failed),
+			dragons = dragons + VALUES(dragons),
+			latency_total = latency_total + VALUES(latency_total),
+			latency_count = latency_count + VALUES(latency_count),
+			latency_max = GREATEST(latency_max, VALUES(latency_max))
+	`, chatID, sent, failed, sent*len(results), latencyMs, latencyCount, latencyMs)
+	if err != nil {
+		log.Printf("[发送日志] 群组:%d 统计失败: %v", chatID, err)
+	}
+}
+
+// purgeDeliveries 删除超过保留天数的发送日志
+func purgeDeliveries() {
+	db.WriteDB.Exec("DELETE FROM alert_deliveries WHERE created_at < NOW() - INTERVAL ? DAY", deliveryRetention)
+}
+
+// truncate 按字符截断，避免超过字段长度
+func truncate(s string, max int) string {
+	runes := []rune(s)
+	if len(runes) <= max {
+		return s
+	}
+	return string(runes[:max])
+}
@@ -0,0 +1,234 @@
+package alert
+
+import (
+	"dragon-alert-bot/bot"
+	"dragon-alert-bot/db"
+	"dragon-alert-bot/dragon"
+	"dragon-alert-bot/schedule"
+	"log"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// queueDigest 暂存达到提醒条件的长龙，等待定时汇总，同一条长龙只保留最新长度
+func queueDigest(chatID int64, results []*dragon.PatternResult) {
+	for _, r := range results {
+		_, err := db.WriteDB.Exec(`
+			INSERT INTO digest_entries (chat_id, pattern_type, attribute_type, start_qihao, current_qihao, count, rarity)
+			VALUES (?, ?, ?, ?, ?, ?, ?)
+			ON DUPLICATE KEY UPDATE current_qihao = VALUES(current_qihao), count = VALUES(count), rarity = VALUES(rarity), status = 'active'
+		`, chatID, r.PatternType, r.AttributeType, r.StartQihao, r.CurrentQihao, r.Count, r.Rarity)
+		if err != nil {
+			log.Printf("[定时汇总] 群组:%d 暂存失败: %v", chatID, err)
+			return
+		}
+	}
+}
+
+// AdvanceDigests 每期执行一次：标记本期结束的长龙、累加汇总期数并发送到期的汇总
+// ended 为 Tracker.Track 返回的本期结束的长龙
+func (d *Dispatcher) AdvanceDigests(ended []dragon.DragonState) {
+	for _, s := range ended {
+		markDigestEnded(s)
+	}
+
+	_, err := db.WriteDB.Exec(`
+		UPDATE chat_configs SET digest_draw_count = digest_draw_count + 1
+		WHERE delivery_mode <> ? AND digest_draws > 0
+	`, bot.DeliveryImmediate)
+	if err != nil {
+		log.Printf("[定时汇总] 更新期数失败: %v", err)
+	}
+
+	d.flushDigests()
+}
+
+// markDigestEnded 将结束的长龙记入汇总：仍在暂存的直接标记结束；
+// 已随上一次汇总发出（暂存记录已删除）的，按提醒记录为汇总模式的群组重新暂存一条结束记录
+func markDigestEnded(s dragon.DragonState) {
+	_, err := db.WriteDB.Exec(`
+		UPDATE digest_entries SET status = 'ended', current_qihao = ?, count = ?
+		WHERE pattern_type = ? AND attribute_type = ? AND start_qihao = ?
+	`, s.CurrentQihao, s.Count, s.PatternType, s.AttributeType, s.StartQihao)
+	if err != nil {
+		log.Printf("[定时汇总] 标记结束失败: %v", err)
+		return
+	}
+
+	_, err = db.WriteDB.Exec(`
+		INSERT INTO digest_entries (chat_id, pattern_type, attribute_type, start_qihao, current_qihao, count, status)
+		SELECT a.chat_id, a.pattern_type, a.attribute_type, a.start_qihao, ?, ?, 'ended'
+		FROM dragon_alerts a JOIN chat_configs c ON c.chat_id = a.chat_id
+		WHERE a.pattern_type = ? AND a.attribute_type = ? AND a.start_qihao = ? AND c.enabled = 1 AND c.delivery_mode <> ?
+		ON DUPLICATE KEY UPDATE status = 'ended', current_qihao = VALUES(current_qihao), count = VALUES(count)
+	`, s.CurrentQihao, s.Count, s.PatternType, s.AttributeType, s.StartQihao, bot.DeliveryImmediate)
+	if err != nil {
+		log.Printf("[定时汇总] 暂存结束记录失败: %v", err)
+	}
+}
+
+// digestDue 已到汇总间隔（期数或分钟）的条件
+const digestDue = `((digest_draws > 0 AND digest_draw_count >= digest_draws)
+	OR (digest_draws = 0 AND (last_digest_at IS NULL OR last_digest_at <= NOW() - INTERVAL digest_minutes MINUTE)))`
+
+// flushDigests 发送已到汇总间隔（期数或分钟）的群组汇总
+func (d *Dispatcher) flushDigests() {
+	rows, err := db.WriteDB.Query(`
+		SELECT chat_id FROM chat_configs
+		WHERE enabled = 1 AND delivery_mode <> ? AND `+digestDue, bot.DeliveryImmediate)
+	if err != nil {
+		log.Printf("[定时汇总] 查询失败: %v", err)
+		return
+	}
+
+	var chatIDs []int64
+	for rows.Next() {
+		var chatID int64
+		if rows.Scan(&chatID) == nil {
+			chatIDs = append(chatIDs, chatID)
+		}
+	}
+	rows.Close()
+
+	now := time.Now()
+	for _, chatID := range chatIDs {
+		chatConfig, err := d.analyzer.GetChatConfig(chatID)
+		if err != nil {
+			continue
+		}
+		// 静默时段不发送汇总，到提醒时段后再发送
+		if !schedule.FromConfig(chatConfig).Active(now) {
+			continue
+		}
+
+		d.sendDigest(chatConfig)
+	}
+}
+
+// sendDigest 发送群组的汇总
+// 开奖流程和定时任务可能同时检查到同一个群组到期，先以条件更新认领本周期，只有认领成功的一方发送
+func (d *Dispatcher) sendDigest(chatConfig *db.ChatConfig) {
+	chatID := chatConfig.ChatID
+
+	res, err := db.WriteDB.Exec(`
+		UPDATE chat_configs SET digest_draw_count = 0, last_digest_at = NOW()
+		WHERE chat_id = ? AND `+digestDue, chatID)
+	if err != nil {
+		log.Printf("[定时汇总] 群组:%d 重置周期失败: %v", chatID, err)
+		return
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return
+	}
+
+	rows, err := db.WriteDB.Query(`
+		SELECT chat_id, pattern_type, attribute_type, start_qihao, current_qihao, count, rarity, status, queued_at
+		FROM digest_entries
+		WHERE chat_id = ?
+		ORDER BY queued_at
+	`, chatID)
+	if err != nil {
+		return
+	}
+
+	var entries []db.DigestEntry
+	for rows.Next() {
+		var e db.DigestEntry
+		err := rows.Scan(&e.ChatID, &e.PatternType, &e.AttributeType, &e.StartQihao, &e.CurrentQihao, &e.Count, &e.Rarity, &e.Status, &e.QueuedAt)
+		if err != nil {
+			continue
+		}
+		entries = append(entries, e)
+	}
+	rows.Close()
+
+	// 本周期的开始时间：上次汇总时间，没有时取最早暂存的长龙
+	since := time.Now().Add(-time.Duration(chatConfig.DigestMinutes) * time.Minute)
+	if chatConfig.LastDigestAt != nil {
+		since = *chatConfig.LastDigestAt
+	} else if len(entries) > 0 {
+		since = entries[0].QueuedAt
+	}
+
+	if len(entries) == 0 {
+		return
+	}
+
+	// 只删除读到的长龙：读取之后新暂存或更新了长度的长龙留到下一次汇总
+	keys := make([]string, 0, len(entries))
+	args := []interface{}{chatID}
+	for _, e := range entries {
+		keys = append(keys, "(?, ?, ?, ?, ?)")
+		args = append(args, e.PatternType, e.AttributeType, e.StartQihao, e.CurrentQihao, e.Status)
+	}
+	_, err = db.WriteDB.Exec(`
+		DELETE FROM digest_entries
+		WHERE chat_id = ? AND (pattern_type, attribute_type, start_qihao, current_qihao, status) IN (`+strings.Join(keys, ", ")+`)
+	`, args...)
+	if err != nil {
+		log.Printf("[定时汇总] 群组:%d 删除已汇总的长龙失败: %v", chatID, err)
+	}
+
+	msg := tgbotapi.NewMessage(chatID, bot.FormatDigest(chatConfig.Language, entries, longestDragons(since),
+		chatConfig.DigestDraws, chatConfig.DigestMinutes))
+	msg.ParseMode = "HTML"
+	msg.DisableWebPagePreview = true
+	bot.Enqueue(chatID, msg, bot.PriorityAlert, func(sent tgbotapi.Message, err error) {
+		logDelivery(chatID, DeliveryDigest, digestEntryResults(entries), nil, sent.MessageID, err)
+	})
+
+	log.Printf("[定时汇总] 群组:%d 发送%d条长龙", chatID, len(entries))
+}
+
+// longestDragons 查询 since 之后有更新的长龙中各属性最长的一条
+func longestDragons(since time.Time) []db.Dragon {
+	rows, err := db.WriteDB.Query(`
+		SELECT pattern_type, attribute_type, start_qihao, current_qihao, count
+		FROM dragons
+		WHERE updated_at >= ?
+		ORDER BY count DESC
+	`, since)
+	if err != nil {
+		log.Printf("[定时汇总] 查询最长长龙失败: %v", err)
+		return nil
+	}
+	defer rows.Close()
+
+	seen := make(map[string]bool)
+	var longest []db.Dragon
+	for rows.Next() {
+		var dr db.Dragon
+		if err := rows.Scan(&dr.PatternType, &dr.AttributeType, &dr.StartQihao, &dr.CurrentQihao, &dr.Count); err != nil {
+			continue
+		}
+		if seen[dr.AttributeType] {
+			continue
+		}
+		seen[dr.AttributeType] = true
+		longest = append(longest, dr)
+	}
+	return longest
+}
+
+// digestEntryResults 将汇总中的长龙转换为 PatternResult（用于发送日志）
+func digestEntryResults(entries []db.DigestEntry) []*dragon.PatternResult {
+	results := make([]*dragon.PatternResult, 0, len(entries))
+	for _, e := range entries {
+		results = append(results, queuedResult(e.PatternType, e.AttributeType, e.StartQihao, e.CurrentQihao, e.Count, e.Rarity))
+	}
+	return results
+}
+
+// queuedResult 将暂存（定时汇总、静默汇总）的长龙转换为 PatternResult
+func queuedResult(patternType, attributeType, startQihao, currentQihao string, count, rarity int) *dragon.PatternResult {
+	return &dragon.PatternResult{
+		PatternType:   patternType,
+		AttributeType: attributeType,
+		StartQihao:    startQihao,
+		CurrentQihao:  currentQihao,
+		Count:         count,
+		Rarity:        rarity,
+	}
+}
@@ -2,8 +2,10 @@ package alert
 
 import (
 	"dragon-alert-bot/bot"
+	"dragon-alert-bot/db"
 	"dragon-alert-bot/dragon"
-	"log"
+	"dragon-alert-bot/schedule"
+	"time"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 )
@@ -20,46 +22,104 @@ func NewDispatcher(analyzer *dragon.Analyzer, tracker *dragon.Tracker) *Dispatch
 	}
 }
 
-// ProcessNewData 处理新开奖数据
-func (d *Dispatcher) ProcessNewData(chatID int64, results []*dragon.PatternResult, currentData *dragon.CurrentLotteryInfo) {
-	if len(results) == 0 {
-		return
-	}
+// ProcessNewData 处理新开奖数据，每期对每个群组调用一次（没有达到阈值的长龙时 results 为空）
+// results 需已经过 Tracker.Track 登记（带有全局长龙ID）并按群组规则过滤；
+// runs 为所有正在进行的模式，rules 为群组规则，用于实时榜单
+func (d *Dispatcher) ProcessNewData(chatConfig *db.ChatConfig, results, runs []*dragon.PatternResult, rules []db.DragonRule, currentData *dragon.CurrentLotteryInfo) {
+	// 提醒时段检查（静默时段丢弃、暂存汇总或只保留紧急长龙）
+	active := schedule.FromConfig(chatConfig).Active(time.Now())
+	results = d.applySchedule(chatConfig, results)
 
-	// 跟踪所有长龙并收集需要提醒的
-	var alertResults []*dragon.PatternResult
+	if len(results) > 0 {
+		// 记录本群组提醒的长龙（新长龙和延续的长龙每期都提醒）
+		d.tracker.RecordDelivery(chatConfig.ChatID, results)
 
-	for _, result := range results {
-		shouldAlert, _ := d.tracker.TrackDragon(chatID, result)
-		if shouldAlert {
-			alertResults = append(alertResults, result)
+		// 汇总模式：暂存到定时汇总
+		if chatConfig.DeliveryMode == bot.DeliveryDigest || chatConfig.DeliveryMode == bot.DeliveryBoth {
+			queueDigest(chatConfig.ChatID, results)
 		}
 	}
 
-	// 结束不活跃的长龙
-	d.tracker.EndInactiveDragons(chatID, results)
+	// 实时榜单模式：编辑置顶榜单代替逐条提醒，提醒时段内每期都更新，静默时段只在有紧急长龙时更新
+	if chatConfig.LiveBoard {
+		if active || len(results) > 0 {
+			d.updateLiveBoard(chatConfig, results, runs, rules, currentData)
+		}
+		return
+	}
 
-	// 如果有需要提醒的，发送消息
-	if len(alertResults) > 0 {
-		d.sendAlert(chatID, alertResults, currentData)
+	// 只汇总的群组不逐条提醒
+	if len(results) == 0 || chatConfig.DeliveryMode == bot.DeliveryDigest {
+		return
 	}
+
+	d.sendAlert(chatConfig, results, currentData)
 }
 
-func (d *Dispatcher) sendAlert(chatID int64, results []*dragon.PatternResult, currentData *dragon.CurrentLotteryInfo) {
-	message := bot.FormatAlertMessage(results, currentData)
+func (d *Dispatcher) sendAlert(chatConfig *db.ChatConfig, results []*dragon.PatternResult, currentData *dragon.CurrentLotteryInfo) {
+	message := bot.RenderChatAlert(chatConfig, results, currentData)
 	if message == "" {
 		return
 	}
 
-	// 异步发送消息，避免阻塞
-	go func(cid int64, msg string) {
-		msgConfig := tgbotapi.NewMessage(cid, msg)
-		msgConfig.ParseMode = "HTML"
-		msgConfig.DisableWebPagePreview = true
+	chatID := chatConfig.ChatID
+	msgConfig := tgbotapi.NewMessage(chatID, message)
+	msgConfig.ParseMode = "HTML"
+	msgConfig.DisableWebPagePreview = true
+
+	// 回复串联：延续的长龙回复到它的第一条提醒
+	if chatConfig.CleanupMode == bot.CleanupThread {
+		if anchor := threadAnchor(chatID, results); anchor > 0 {
+			msgConfig.ReplyToMessageID = anchor
+			msgConfig.AllowSendingWithoutReply = true
+		}
+	}
 
-		_, err := bot.BotAPI.Send(msgConfig)
+	// 加入发送队列，提醒优先于菜单等普通消息发送；发送成功后记录消息ID用于清理
+	bot.Enqueue(chatID, msgConfig, bot.PriorityAlert, func(sent tgbotapi.Message, err error) {
+		logDelivery(chatID, DeliveryAlert, results, currentData, sent.MessageID, err)
 		if err != nil {
-			log.Printf("[发送失败] 群组:%d 错误:%v", cid, err)
+			return
+		}
+
+		recordAlertMessage(chatID, sent.MessageID, messageAlert, results)
+		if chatConfig.CleanupMode == bot.CleanupPrevious {
+			deletePreviousAlerts(chatID, sent.MessageID)
 		}
-	}(chatID, message)
+		if chatConfig.RoadAttach {
+			sendRoad(chatConfig, results, sent.MessageID)
+		}
+	})
+}
+
+// updateLiveBoard 更新实时榜单模式群组的置顶榜单，有达到提醒条件的长龙时记录发送日志
+func (d *Dispatcher) updateLiveBoard(chatConfig *db.ChatConfig, results, runs []*dragon.PatternResult, rules []db.DragonRule, currentData *dragon.CurrentLotteryInfo) {
+	chatID := chatConfig.ChatID
+	text := bot.FormatLiveBoard(chatConfig.Language, runs, rules, currentData)
+
+	var onResult func(tgbotapi.Message, error)
+	if len(results) > 0 {
+		onResult = func(sent tgbotapi.Message, err error) {
+			logDelivery(chatID, DeliveryBoard, results, currentData, sent.MessageID, err)
+		}
+	}
+	bot.UpdateLiveBoard(chatID, chatConfig.LiveBoardMessageID, text, onResult)
+}
+
+// SendRecordAlert 发送平/破纪录提醒
+func (d *Dispatcher) SendRecordAlert(chatConfig *db.ChatConfig, event *dragon.RecordEvent, currentData *dragon.CurrentLotteryInfo) {
+	// 静默时段与普通提醒相同处理
+	if len(d.applySchedule(chatConfig, []*dragon.PatternResult{event.Result})) == 0 {
+		return
+	}
+
+	chatID := chatConfig.ChatID
+	message := bot.FormatRecordMessage(chatConfig.Language, event, currentData)
+
+	msgConfig := tgbotapi.NewMessage(chatID, message)
+	msgConfig.ParseMode = "HTML"
+	msgConfig.DisableWebPagePreview = true
+	bot.Enqueue(chatID, msgConfig, bot.PriorityAlert, func(sent tgbotapi.Message, err error) {
+		logDelivery(chatID, DeliveryRecord, []*dragon.PatternResult{event.Result}, currentData, sent.MessageID, err)
+	})
 }
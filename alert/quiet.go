@@ -0,0 +1,152 @@
+package alert
+
+import (
+	"dragon-alert-bot/bot"
+	"dragon-alert-bot/db"
+	"dragon-alert-bot/dragon"
+	"dragon-alert-bot/schedule"
+	"log"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// applySchedule 按群组的提醒时段过滤长龙
+// 提醒时段内原样返回；静默时段按处理方式丢弃、暂存到汇总或只保留达到紧急长度的长龙
+func (d *Dispatcher) applySchedule(chatConfig *db.ChatConfig, results []*dragon.PatternResult) []*dragon.PatternResult {
+	sched := schedule.FromConfig(chatConfig)
+	if sched.Active(time.Now()) {
+		return results
+	}
+
+	switch sched.Policy {
+	case schedule.PolicyDigest:
+		queueQuietDigest(chatConfig.ChatID, results)
+		return nil
+
+	case schedule.PolicyCritical:
+		var critical []*dragon.PatternResult
+		for _, r := range results {
+			if dragon.GroupCount(r.Count, r.PatternType) >= sched.Critical {
+				critical = append(critical, r)
+			}
+		}
+		return critical
+	}
+
+	return nil
+}
+
+// queueQuietDigest 暂存静默时段内的长龙，同一条长龙只保留最新长度
+func queueQuietDigest(chatID int64, results []*dragon.PatternResult) {
+	for _, r := range results {
+		_, err := db.WriteDB.Exec(`
+			INSERT INTO quiet_digests (chat_id, pattern_type, attribute_type, start_qihao, current_qihao, count, rarity)
+			VALUES (?, ?, ?, ?, ?, ?, ?)
+			ON DUPLICATE KEY UPDATE current_qihao = VALUES(current_qihao), count = VALUES(count), rarity = VALUES(rarity)
+		`, chatID, r.PatternType, r.AttributeType, r.StartQihao, r.CurrentQihao, r.Count, r.Rarity)
+		if err != nil {
+			log.Printf("[静默汇总] 群组:%d 暂存失败: %v", chatID, err)
+			return
+		}
+	}
+}
+
+// flushQuietDigests 提醒时段开始后发送各群组在静默时段暂存的长龙汇总
+func (d *Dispatcher) flushQuietDigests() {
+	rows, err := db.WriteDB.Query("SELECT DISTINCT chat_id FROM quiet_digests")
+	if err != nil {
+		log.Printf("[静默汇总] 查询失败: %v", err)
+		return
+	}
+
+	var chatIDs []int64
+	for rows.Next() {
+		var chatID int64
+		if rows.Scan(&chatID) == nil {
+			chatIDs = append(chatIDs, chatID)
+		}
+	}
+	rows.Close()
+
+	now := time.Now()
+	for _, chatID := range chatIDs {
+		chatConfig, err := d.analyzer.GetChatConfig(chatID)
+		if err != nil {
+			continue
+		}
+		if !schedule.FromConfig(chatConfig).Active(now) {
+			continue
+		}
+
+		d.sendQuietDigest(chatConfig)
+	}
+}
+
+func (d *Dispatcher) sendQuietDigest(chatConfig *db.ChatConfig) {
+	chatID := chatConfig.ChatID
+
+	rows, err := db.WriteDB.Query(`
+		SELECT chat_id, pattern_type, attribute_type, start_qihao, current_qihao, count, rarity, queued_at
+		FROM quiet_digests
+		WHERE chat_id = ?
+		ORDER BY queued_at
+	`, chatID)
+	if err != nil {
+		return
+	}
+
+	var entries []db.QuietDigest
+	for rows.Next() {
+		var e db.QuietDigest
+		err := rows.Scan(&e.ChatID, &e.PatternType, &e.AttributeType, &e.StartQihao, &e.CurrentQihao, &e.Count, &e.Rarity, &e.QueuedAt)
+		if err != nil {
+			continue
+		}
+		entries = append(entries, e)
+	}
+	rows.Close()
+
+	if len(entries) == 0 {
+		return
+	}
+
+	// 先删除再发送，避免下一次检查时重复汇总；只删除读到的长龙，读取之后暂存的留到下一次
+	keys := make([]string, 0, len(entries))
+	args := []interface{}{chatID}
+	for _, e := range entries {
+		keys = append(keys, "(?, ?, ?, ?)")
+		args = append(args, e.PatternType, e.AttributeType, e.StartQihao, e.CurrentQihao)
+	}
+	_, err = db.WriteDB.Exec(`
+		DELETE FROM quiet_digests
+		WHERE chat_id = ? AND (pattern_type, attribute_type, start_qihao, current_qihao) IN (`+strings.Join(keys, ", ")+`)
+	`, args...)
+	if err != nil {
+		log.Printf("[静默汇总] 群组:%d 删除已汇总的长龙失败: %v", chatID, err)
+	}
+
+	active := make(map[string]bool)
+	for _, s := range d.tracker.ActiveDragons() {
+		active[s.Key()] = true
+	}
+
+	msg := tgbotapi.NewMessage(chatID, bot.FormatQuietDigest(chatConfig.Language, entries, active))
+	msg.ParseMode = "HTML"
+	msg.DisableWebPagePreview = true
+	bot.Enqueue(chatID, msg, bot.PriorityAlert, func(sent tgbotapi.Message, err error) {
+		logDelivery(chatID, DeliveryDigest, digestResults(entries), nil, sent.MessageID, err)
+	})
+
+	log.Printf("[静默汇总] 群组:%d 发送%d条长龙", chatID, len(entries))
+}
+
+// digestResults 将暂存的长龙转换为 PatternResult（用于发送日志）
+func digestResults(entries []db.QuietDigest) []*dragon.PatternResult {
+	results := make([]*dragon.PatternResult, 0, len(entries))
+	for _, e := range entries {
+		results = append(results, queuedResult(e.PatternType, e.AttributeType, e.StartQihao, e.CurrentQihao, e.Count, e.Rarity))
+	}
+	return results
+}
@@ -0,0 +1,34 @@
+package alert
+
+import (
+	"dragon-alert-bot/bot"
+	"dragon-alert-bot/db"
+	"dragon-alert-bot/dragon"
+	"dragon-alert-bot/road"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// sendRoad 回复提醒消息发送路单图片，图片与提醒一起按群组的清理方式删除
+func sendRoad(chatConfig *db.ChatConfig, results []*dragon.PatternResult, replyTo int) {
+	attr, ok := bot.RoadAttribute(results)
+	if !ok {
+		return
+	}
+
+	chatID := chatConfig.ChatID
+	photo, remember, ok := bot.RoadPhoto(chatID, chatConfig.Language, attr, road.DefaultDraws)
+	if !ok {
+		return
+	}
+	photo.ReplyToMessageID = replyTo
+	photo.AllowSendingWithoutReply = true
+	photo.DisableNotification = true
+
+	bot.Enqueue(chatID, photo, bot.PriorityAlert, func(sent tgbotapi.Message, err error) {
+		if err == nil {
+			remember(sent)
+			recordAlertMessage(chatID, sent.MessageID, messageRoad, results)
+		}
+	})
+}
@@ -0,0 +1,28 @@
+package alert
+
+import (
+	"dragon-alert-bot/bot"
+	"dragon-alert-bot/leader"
+	"time"
+)
+
+// schedulerInterval 定时任务的检查间隔
+const schedulerInterval = time.Minute
+
+// RunScheduler 定时任务：删除超时的提醒、发送静默时段汇总、发送按分钟的定时汇总、清理发送日志和过期的阈值输入提示（仅主节点执行）
+func (d *Dispatcher) RunScheduler() {
+	ticker := time.NewTicker(schedulerInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if !leader.IsLeader() {
+			continue
+		}
+
+		d.deleteExpiredAlerts()
+		d.flushQuietDigests()
+		d.flushDigests()
+		purgeDeliveries()
+		bot.DeleteExpiredPrompts()
+	}
+}
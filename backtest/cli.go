@@ -0,0 +1,341 @@
+package backtest
+
+import (
+	"dragon-alert-bot/config"
+	"dragon-alert-bot/db"
+	"dragon-alert-bot/dragon"
+	"dragon-alert-bot/lottery"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// RunCLI 执行 backtest 子命令，返回进程退出码
+//
+// 用法：
+//
+//	dragon-alert-bot backtest -from 2026-09-01 -to 2026-10-01 -rules size:a:6,parity:ab:3
+//	dragon-alert-bot backtest -csv draws.csv -chat -1001234567890 -format json
+func RunCLI(args []string) int {
+	fs := flag.NewFlagSet("backtest", flag.ContinueOnError)
+	fromStr := fs.String("from", "", "开始时间（2006-01-02 或 2006-01-02 15:04:05），默认30天前")
+	toStr := fs.String("to", "", "结束时间（不含），默认当前时间")
+	csvPath := fs.String("csv", "", "从CSV读取开奖数据（qihao,opentime,opennum[,sum_value]，按时间升序），不指定则读取只读数据库")
+	rulesStr := fs.String("rules", "", "规则列表，如 size:a:6,parity:ab:3,combo:abab:r80（r 前缀表示稀有度阈值）")
+	chatID := fs.Int64("chat", 0, "使用该群组当前的规则配置")
+	format := fs.String("format", "table", "输出格式：table 或 json")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	now := time.Now()
+	from := now.AddDate(0, 0, -30)
+	to := now
+
+	var err error
+	if *fromStr != "" {
+		if from, err = parseTime(*fromStr); err != nil {
+			fmt.Fprintf(os.Stderr, "开始时间格式错误: %v\n", err)
+			return 2
+		}
+	}
+	if *toStr != "" {
+		if to, err = parseTime(*toStr); err != nil {
+			fmt.Fprintf(os.Stderr, "结束时间格式错误: %v\n", err)
+			return 2
+		}
+	}
+	if !from.Before(to) {
+		fmt.Fprintln(os.Stderr, "开始时间必须早于结束时间")
+		return 2
+	}
+
+	if *format != "table" && *format != "json" {
+		fmt.Fprintf(os.Stderr, "不支持的输出格式: %s\n", *format)
+		return 2
+	}
+
+	cfg := config.Load()
+
+	// 加载规则
+	rules := DefaultRules()
+	switch {
+	case *rulesStr != "":
+		if rules, err = ParseRules(*rulesStr); err != nil {
+			fmt.Fprintf(os.Stderr, "规则格式错误: %v\n", err)
+			return 2
+		}
+	case *chatID != 0:
+		if err := db.InitWriteDB(cfg); err != nil {
+			fmt.Fprintf(os.Stderr, "数据库连接失败: %v\n", err)
+			return 1
+		}
+		if rules, err = dragon.NewAnalyzer(nil).GetChatRules(*chatID); err != nil {
+			fmt.Fprintf(os.Stderr, "读取群组规则失败: %v\n", err)
+			return 1
+		}
+	}
+
+	if len(rules) == 0 {
+		fmt.Fprintln(os.Stderr, "没有可用的规则")
+		return 2
+	}
+
+	// 逐期回放
+	engine := NewEngine(rules)
+	if *csvPath != "" {
+		err = feedCSV(engine, *csvPath, from, to)
+	} else {
+		err = feedDB(engine, cfg, from, to)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "读取开奖数据失败: %v\n", err)
+		return 1
+	}
+
+	report := engine.Finish()
+	if report.Draws == 0 {
+		fmt.Fprintln(os.Stderr, "回测区间内没有开奖数据")
+		return 1
+	}
+
+	if *format == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(report); err != nil {
+			return 1
+		}
+		return 0
+	}
+
+	WriteTable(os.Stdout, report)
+	return 0
+}
+
+// feedDB 从只读数据库读取数据：先用区间前500期预热，再流式回放区间内数据
+func feedDB(engine *Engine, cfg *config.Config, from, to time.Time) error {
+	if err := db.InitReadDB(cfg); err != nil {
+		return err
+	}
+
+	monitor := lottery.NewMonitor()
+	warmup, err := monitor.GetHistoryBefore(from, windowSize)
+	if err != nil {
+		return err
+	}
+	for i := len(warmup) - 1; i >= 0; i-- {
+		engine.Feed(&warmup[i], false)
+	}
+
+	return monitor.StreamRange(from, to, func(data *lottery.LotteryData) error {
+		engine.Feed(data, true)
+		return nil
+	})
+}
+
+// feedCSV 从CSV文件读取数据，区间之前的数据用于预热
+func feedCSV(engine *Engine, path string, from, to time.Time) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	reader := csv.NewReader(f)
+	reader.FieldsPerRecord = -1
+
+	line := 0
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		line++
+
+		// 跳过表头
+		if line == 1 && strings.EqualFold(strings.TrimSpace(record[0]), "qihao") {
+			continue
+		}
+
+		data, err := parseCSVRecord(record)
+		if err != nil {
+			return fmt.Errorf("第%d行: %v", line, err)
+		}
+
+		if !data.OpenTime.Before(to) {
+			return nil
+		}
+		engine.Feed(data, !data.OpenTime.Before(from))
+	}
+}
+
+// parseCSVRecord 解析一行CSV：qihao,opentime,opennum[,sum_value]
+func parseCSVRecord(record []string) (*lottery.LotteryData, error) {
+	if len(record) < 3 {
+		return nil, fmt.Errorf("字段不足")
+	}
+
+	data := &lottery.LotteryData{
+		Qihao:   strings.TrimSpace(record[0]),
+		OpenNum: strings.TrimSpace(record[2]),
+		Source:  "csv",
+	}
+
+	var err error
+	if data.OpenTime, err = time.ParseInLocation("2006-01-02 15:04:05", strings.TrimSpace(record[1]), time.Local); err != nil {
+		return nil, err
+	}
+
+	if len(record) >= 4 && strings.TrimSpace(record[3]) != "" {
+		if data.SumValue, err = strconv.Atoi(strings.TrimSpace(record[3])); err != nil {
+			return nil, err
+		}
+		return data, nil
+	}
+
+	// 没有和值列时由开奖号码计算（如 3+5+8）
+	for _, n := range strings.FieldsFunc(data.OpenNum, func(r rune) bool { return r < '0' || r > '9' }) {
+		v, _ := strconv.Atoi(n)
+		data.SumValue += v
+	}
+
+	return data, nil
+}
+
+// 规则中可用的属性名
+var attributeAliases = map[string]string{
+	"size":        "size",
+	"parity":      "parity",
+	"sum":         "sum",
+	"combo":       "size_parity",
+	"size_parity": "size_parity",
+}
+
+// ParseRules 解析规则列表，格式为 属性:格式:阈值，多条用逗号分隔
+// 阈值以 r 开头时表示稀有度阈值（如 size:a:r80）
+func ParseRules(spec string) ([]db.DragonRule, error) {
+	var rules []db.DragonRule
+
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		parts := strings.Split(item, ":")
+		if len(parts) != 3 {
+			return nil, fmt.Errorf("%q 应为 属性:格式:阈值", item)
+		}
+
+		attr, ok := attributeAliases[strings.ToLower(parts[0])]
+		if !ok {
+			return nil, fmt.Errorf("未知属性 %q", parts[0])
+		}
+
+		pattern := strings.ToLower(parts[1])
+		if !validPattern(attr, pattern) {
+			return nil, fmt.Errorf("属性 %s 不支持格式 %q", parts[0], parts[1])
+		}
+
+		rule := db.DragonRule{
+			PatternType:   pattern,
+			AttributeType: attr,
+			Enabled:       true,
+		}
+
+		value := strings.ToLower(parts[2])
+		if strings.HasPrefix(value, "r") {
+			n, err := strconv.Atoi(value[1:])
+			if err != nil || n < 1 || n > 100 {
+				return nil, fmt.Errorf("%q 稀有度阈值应为 1-100", item)
+			}
+			rule.RarityThreshold = n
+		} else {
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("%q 阈值应为正整数", item)
+			}
+			rule.Threshold = n
+		}
+
+		rules = append(rules, rule)
+	}
+
+	return rules, nil
+}
+
+func validPattern(attr, pattern string) bool {
+	if attr == "size_parity" {
+		return pattern == "ab_ac" || pattern == "ab_cd" || pattern == "abab"
+	}
+	return pattern == "a" || pattern == "ab" || pattern == "abb"
+}
+
+func parseTime(s string) (time.Time, error) {
+	if t, err := time.ParseInLocation("2006-01-02 15:04:05", s, time.Local); err == nil {
+		return t, nil
+	}
+	return time.ParseInLocation("2006-01-02", s, time.Local)
+}
+
+// WriteTable 以表格形式输出回测报告
+func WriteTable(out io.Writer, report *Report) {
+	fmt.Fprintf(out, "回测区间: %s ~ %s  共 %d 期\n",
+		report.From.Format("2006-01-02 15:04"), report.To.Format("2006-01-02 15:04"), report.Draws)
+	fmt.Fprintf(out, "提醒消息: %d 条  触发长龙: %d 条\n\n", report.AlertMessages, report.DragonsAlerted)
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "属性\t格式\t阈值\t提醒次数\t长龙数\t最长(期)")
+	for _, r := range report.Rules {
+		threshold := strconv.Itoa(r.Threshold)
+		if r.RarityThreshold > 0 {
+			threshold = fmt.Sprintf("稀有度%d", r.RarityThreshold)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%d\t%d\n", r.AttributeType, r.PatternType, threshold, r.Alerts, r.Dragons, r.Longest)
+	}
+	w.Flush()
+
+	fmt.Fprintln(out, "\n长龙长度分布（期数:条数）")
+	w = tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "属性\t格式\t条数\t最长\t分布")
+	for _, l := range report.Lengths {
+		var lengths []int
+		for n := range l.Distribution {
+			lengths = append(lengths, n)
+		}
+		sort.Ints(lengths)
+
+		var parts []string
+		for _, n := range lengths {
+			parts = append(parts, fmt.Sprintf("%d:%d", n, l.Distribution[n]))
+		}
+		fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%s\n", l.AttributeType, l.PatternType, l.Dragons, l.Longest, strings.Join(parts, " "))
+	}
+	w.Flush()
+
+	fmt.Fprintln(out, "\n每小时提醒消息数")
+	max := 0
+	for _, n := range report.Hourly {
+		if n > max {
+			max = n
+		}
+	}
+	for hour, n := range report.Hourly {
+		bar := ""
+		if max > 0 {
+			bar = strings.Repeat("█", n*40/max)
+		}
+		fmt.Fprintf(out, "%02d时 %6d %s\n", hour, n, bar)
+	}
+}
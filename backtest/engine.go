@@ -0,0 +1,241 @@
+package backtest
+
+import (
+	"dragon-alert-bot/db"
+	"dragon-alert-bot/dragon"
+	"dragon-alert-bot/lottery"
+	"sort"
+	"time"
+)
+
+// windowSize 每次检测使用的历史期数（与 Analyzer 保持一致）
+const windowSize = 500
+
+// Report 回测报告
+type Report struct {
+	From           time.Time    `json:"from"`
+	To             time.Time    `json:"to"`
+	Draws          int          `json:"draws"`           // 回测期数
+	AlertMessages  int          `json:"alert_messages"`  // 会发送的提醒消息数（每期有匹配即一条）
+	DragonsAlerted int          `json:"dragons_alerted"` // 触发过提醒的不同长龙数
+	Rules          []RuleStat   `json:"rules"`
+	Lengths        []LengthStat `json:"lengths"`
+	Hourly         [24]int      `json:"hourly"` // 每小时的提醒消息数
+}
+
+// RuleStat 单条规则的触发统计
+type RuleStat struct {
+	PatternType     string `json:"pattern_type"`
+	AttributeType   string `json:"attribute_type"`
+	Threshold       int    `json:"threshold"`
+	RarityThreshold int    `json:"rarity_threshold,omitempty"`
+	Alerts          int    `json:"alerts"`  // 命中次数（每期每条长龙计一次）
+	Dragons         int    `json:"dragons"` // 命中的不同长龙数
+	Longest         int    `json:"longest"` // 命中的最长长龙（期数）
+}
+
+// LengthStat 长龙长度分布（期数）
+type LengthStat struct {
+	PatternType   string      `json:"pattern_type"`
+	AttributeType string      `json:"attribute_type"`
+	Dragons       int         `json:"dragons"`
+	Longest       int         `json:"longest"`
+	Distribution  map[int]int `json:"distribution"` // 长度 → 条数
+}
+
+// Engine 回测引擎：按开奖顺序逐期输入，复用线上的检测与规则过滤逻辑
+type Engine struct {
+	rules      []db.DragonRule
+	needRarity bool
+
+	window  []lottery.Attributes
+	active  map[string]*dragon.PatternResult // 正在进行的长龙（仅回测区间内出现的）
+	alerted map[string]map[int]bool          // 长龙 → 命中过的规则序号
+	lengths map[string]*LengthStat
+
+	report *Report
+}
+
+// NewEngine 创建回测引擎
+func NewEngine(rules []db.DragonRule) *Engine {
+	e := &Engine{
+		rules:   rules,
+		active:  make(map[string]*dragon.PatternResult),
+		alerted: make(map[string]map[int]bool),
+		lengths: make(map[string]*LengthStat),
+		report:  &Report{},
+	}
+
+	for _, rule := range rules {
+		if rule.RarityThreshold > 0 {
+			e.needRarity = true
+		}
+		e.report.Rules = append(e.report.Rules, RuleStat{
+			PatternType:     rule.PatternType,
+			AttributeType:   rule.AttributeType,
+			Threshold:       rule.Threshold,
+			RarityThreshold: rule.RarityThreshold,
+		})
+	}
+
+	return e
+}
+
+// Feed 输入一期开奖数据；counted 为 false 时仅用于预热检测窗口，不计入报告
+func (e *Engine) Feed(data *lottery.LotteryData, counted bool) {
+	e.window = append(e.window, data.CalculateAttributes())
+	if len(e.window) > windowSize {
+		e.window = append(e.window[:0:0], e.window[len(e.window)-windowSize:]...)
+	}
+
+	if !counted {
+		return
+	}
+
+	if e.report.Draws == 0 {
+		e.report.From = data.OpenTime
+	}
+	e.report.To = data.OpenTime
+	e.report.Draws++
+
+	results := dragon.DetectPatterns(e.window)
+	if e.needRarity {
+		dragon.ScoreRarity(results, dragon.BuildRunHistory(e.window))
+	}
+
+	e.trackLengths(results)
+
+	filtered := dragon.FilterResultsByRules(results, e.rules)
+	if len(filtered) == 0 {
+		return
+	}
+
+	e.report.AlertMessages++
+	e.report.Hourly[data.OpenTime.Hour()]++
+
+	for _, r := range filtered {
+		key := dragonKey(r)
+		for i, rule := range e.rules {
+			if rule.PatternType != r.PatternType || rule.AttributeType != r.AttributeType {
+				continue
+			}
+
+			stat := &e.report.Rules[i]
+			stat.Alerts++
+			if r.Count > stat.Longest {
+				stat.Longest = r.Count
+			}
+
+			if e.alerted[key] == nil {
+				e.alerted[key] = make(map[int]bool)
+				e.report.DragonsAlerted++
+			}
+			if !e.alerted[key][i] {
+				e.alerted[key][i] = true
+				stat.Dragons++
+			}
+			break
+		}
+	}
+}
+
+// trackLengths 跟踪长龙，结束时计入长度分布
+func (e *Engine) trackLengths(results []*dragon.PatternResult) {
+	current := make(map[string]*dragon.PatternResult)
+	for _, r := range results {
+		current[dragonKey(r)] = r
+	}
+
+	for key, r := range e.active {
+		if _, ok := current[key]; !ok {
+			e.addLength(r)
+			delete(e.active, key)
+			delete(e.alerted, key)
+		}
+	}
+
+	for key, r := range current {
+		e.active[key] = r
+	}
+}
+
+func (e *Engine) addLength(r *dragon.PatternResult) {
+	key := r.AttributeType + "/" + r.PatternType
+	stat, ok := e.lengths[key]
+	if !ok {
+		stat = &LengthStat{
+			PatternType:   r.PatternType,
+			AttributeType: r.AttributeType,
+			Distribution:  make(map[int]int),
+		}
+		e.lengths[key] = stat
+	}
+
+	stat.Dragons++
+	stat.Distribution[r.Count]++
+	if r.Count > stat.Longest {
+		stat.Longest = r.Count
+	}
+}
+
+// Finish 结束回测并返回报告（仍在进行的长龙按当前长度计入分布）
+func (e *Engine) Finish() *Report {
+	for key, r := range e.active {
+		e.addLength(r)
+		delete(e.active, key)
+	}
+
+	e.report.Lengths = e.report.Lengths[:0]
+	for _, stat := range e.lengths {
+		e.report.Lengths = append(e.report.Lengths, *stat)
+	}
+	sort.Slice(e.report.Lengths, func(i, j int) bool {
+		a, b := e.report.Lengths[i], e.report.Lengths[j]
+		if a.AttributeType != b.AttributeType {
+			return attributeOrder(a.AttributeType) < attributeOrder(b.AttributeType)
+		}
+		return a.PatternType < b.PatternType
+	})
+
+	return e.report
+}
+
+// Run 对一组按时间从旧到新排列的开奖数据执行回测，前 warmup 期只用于预热
+func Run(draws []lottery.LotteryData, warmup int, rules []db.DragonRule) *Report {
+	engine := NewEngine(rules)
+	for i := range draws {
+		engine.Feed(&draws[i], i >= warmup)
+	}
+	return engine.Finish()
+}
+
+// DefaultRules 将默认规则转换为规则配置
+func DefaultRules() []db.DragonRule {
+	var rules []db.DragonRule
+	for _, r := range dragon.DefaultRules {
+		rules = append(rules, db.DragonRule{
+			PatternType:   r.PatternType,
+			AttributeType: r.AttributeType,
+			Threshold:     r.Threshold,
+			Enabled:       true,
+		})
+	}
+	return rules
+}
+
+func dragonKey(r *dragon.PatternResult) string {
+	return r.AttributeType + "/" + r.PatternType + "/" + r.StartQihao
+}
+
+func attributeOrder(attr string) int {
+	switch attr {
+	case "size":
+		return 0
+	case "parity":
+		return 1
+	case "sum":
+		return 2
+	default:
+		return 3
+	}
+}
@@ -2,15 +2,38 @@ package bot
 
 import (
 	"dragon-alert-bot/config"
+	"dragon-alert-bot/dragon"
+	"dragon-alert-bot/i18n"
+	"dragon-alert-bot/leader"
+	"dragon-alert-bot/lottery"
 	"log"
+	"net/http"
+	"time"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 )
 
 var (
 	BotAPI *tgbotapi.BotAPI
+
+	modules Modules
+
+	webhookURL    string
+	webhookListen string
 )
 
+// Modules Bot 处理命令时依赖的业务模块
+type Modules struct {
+	Monitor  *lottery.Monitor
+	Analyzer *dragon.Analyzer
+	Records  *dragon.RecordKeeper
+}
+
+// SetModules 注入业务模块（在 Start 之前调用）
+func SetModules(m Modules) {
+	modules = m
+}
+
 func InitBot(cfg *config.Config) error {
 	var err error
 	BotAPI, err = tgbotapi.NewBotAPI(cfg.BotToken)
@@ -21,38 +44,58 @@ func InitBot(cfg *config.Config) error {
 	BotAPI.Debug = false
 	log.Printf("Bot 已授权: @%s (ID:%d)", BotAPI.Self.UserName, BotAPI.Self.ID)
 
-	// 注册Bot命令菜单
-	commands := []tgbotapi.BotCommand{
-		{
-			Command:     "start",
-			Description: "查看欢迎信息和使用说明",
-		},
-		{
-			Command:     "long",
-			Description: "配置长龙提醒（仅群组管理员）",
-		},
-		{
-			Command:     "data",
-			Description: "查看机器人数据统计",
-		},
+	// Webhook 模式：所有实例都可以接收更新（由负载均衡分发）
+	webhookURL = cfg.WebhookURL
+	webhookListen = cfg.WebhookListen
+	if webhookURL != "" {
+		wh, err := tgbotapi.NewWebhook(webhookURL + "/" + BotAPI.Token)
+		if err != nil {
+			return err
+		}
+		if _, err := BotAPI.Request(wh); err != nil {
+			return err
+		}
+		log.Printf("Webhook 已设置: %s", webhookURL)
 	}
 
-	cmdConfig := tgbotapi.NewSetMyCommands(commands...)
-	_, err = BotAPI.Request(cmdConfig)
-	if err != nil {
-		log.Printf("注册命令菜单失败: %v", err)
-	} else {
-		log.Println("Bot命令菜单注册成功")
-	}
+	// 注册Bot命令菜单：默认使用默认语言，英文客户端显示英文
+	registerCommands(tgbotapi.NewSetMyCommands(botCommands(i18n.Default)...))
+	registerCommands(tgbotapi.NewSetMyCommandsWithScopeAndLanguage(tgbotapi.NewBotCommandScopeDefault(), i18n.En, botCommands(i18n.En)...))
+
+	// 所有消息统一经发送队列限流发送
+	startOutbox()
 
 	return nil
 }
 
-func Start() {
-	u := tgbotapi.NewUpdate(0)
-	u.Timeout = 60
+// commandNames 命令菜单中的命令，说明在消息目录 command.<命令> 中
+var commandNames = []string{
+	"start", "long", "data", "records", "template", "stats", "road", "trend",
+	"current", "history", "rules", "set", "enable", "disable",
+}
 
-	updates := BotAPI.GetUpdatesChan(u)
+// botCommands 按语言生成命令菜单
+func botCommands(lang string) []tgbotapi.BotCommand {
+	commands := make([]tgbotapi.BotCommand, 0, len(commandNames))
+	for _, name := range commandNames {
+		commands = append(commands, tgbotapi.BotCommand{
+			Command:     name,
+			Description: i18n.T(lang, "command."+name),
+		})
+	}
+	return commands
+}
+
+func registerCommands(cmdConfig tgbotapi.SetMyCommandsConfig) {
+	if _, err := BotAPI.Request(cmdConfig); err != nil {
+		log.Printf("注册命令菜单失败 (语言:%s): %v", cmdConfig.LanguageCode, err)
+	} else {
+		log.Printf("Bot命令菜单注册成功 (语言:%s)", cmdConfig.LanguageCode)
+	}
+}
+
+func Start() {
+	updates := receiveUpdates()
 
 	// 使用工作池处理更新，提高并发能力
 	workerCount := 50
@@ -68,9 +111,76 @@ func Start() {
 	select {}
 }
 
+// receiveUpdates 根据部署模式选择接收更新的方式
+func receiveUpdates() tgbotapi.UpdatesChannel {
+	if webhookURL != "" {
+		updates := BotAPI.ListenForWebhook("/" + BotAPI.Token)
+		go func() {
+			if err := http.ListenAndServe(webhookListen, nil); err != nil {
+				log.Printf("Webhook 监听失败: %v", err)
+			}
+		}()
+		return updates
+	}
+
+	if !leader.Enabled() {
+		u := tgbotapi.NewUpdate(0)
+		u.Timeout = 60
+		return BotAPI.GetUpdatesChan(u)
+	}
+
+	// 高可用 + 长轮询：Telegram 同一时间只允许一个 getUpdates，只由主节点轮询
+	updates := make(chan tgbotapi.Update, 100)
+	go pollAsLeader(updates)
+	return updates
+}
+
+// pollAsLeader 仅在本实例为主节点时轮询更新，失去主节点身份后暂停
+func pollAsLeader(updates chan<- tgbotapi.Update) {
+	u := tgbotapi.NewUpdate(0)
+	u.Timeout = 30
+
+	for {
+		if !leader.IsLeader() {
+			time.Sleep(time.Second)
+			continue
+		}
+
+		list, err := BotAPI.GetUpdates(u)
+		if err != nil {
+			log.Printf("获取更新失败: %v", err)
+			time.Sleep(3 * time.Second)
+			continue
+		}
+
+		for _, update := range list {
+			if update.UpdateID >= u.Offset {
+				u.Offset = update.UpdateID + 1
+				updates <- update
+			}
+		}
+	}
+}
+
 func handleUpdate(update tgbotapi.Update) {
-	// 处理命令
+	// 机器人在群组中的成员状态变化（加入、移出、权限变化）
+	if update.MyChatMember != nil {
+		handleMyChatMember(update.MyChatMember)
+		return
+	}
+
+	// 群组升级为超级群组
+	if update.Message != nil && (update.Message.MigrateToChatID != 0 || update.Message.MigrateFromChatID != 0) {
+		handleMigration(update.Message)
+		return
+	}
+
+	// 处理命令和对阈值输入提示的回复
 	if update.Message != nil {
+		if update.Message.ReplyToMessage != nil && !update.Message.IsCommand() {
+			handleThresholdReply(update.Message)
+			return
+		}
 		handleCommand(update.Message)
 		return
 	}
@@ -0,0 +1,264 @@
+package bot
+
+import (
+	"dragon-alert-bot/db"
+	"log"
+	"strings"
+	"sync"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// 群组被自动停用的原因（chat_configs.disabled_reason）
+const (
+	disabledKicked     = "kicked"     // 机器人被移出群组
+	disabledNotFound   = "not_found"  // 群组不存在（已解散）
+	disabledPermission = "permission" // 多次因权限不足发送失败
+	disabledRemoved    = "removed"    // 机器人被移出群组（my_chat_member 通知，群组已归档）
+)
+
+// permissionFailureLimit 连续多少次权限不足后停用群组
+const permissionFailureLimit = 10
+
+// 发送错误分类
+const (
+	sendErrOther      = iota
+	sendErrMigrated   // 群组已升级为超级群组
+	sendErrGone       // 机器人已不在群组中或群组不存在
+	sendErrPermission // 没有发送权限
+)
+
+// failingChats 有过权限失败记录的群组，发送成功后清零计数
+var failingChats sync.Map
+
+// classifySendError 根据 Telegram 返回的错误判断会话状态
+func classifySendError(apiErr *tgbotapi.Error) (int, string) {
+	if apiErr.MigrateToChatID != 0 {
+		return sendErrMigrated, ""
+	}
+
+	desc := strings.ToLower(apiErr.Message)
+	switch {
+	case strings.Contains(desc, "bot was kicked"),
+		strings.Contains(desc, "bot is not a member"),
+		strings.Contains(desc, "bot was blocked by the user"),
+		strings.Contains(desc, "user is deactivated"):
+		return sendErrGone, disabledKicked
+	case strings.Contains(desc, "chat not found"),
+		strings.Contains(desc, "group chat was deactivated"):
+		return sendErrGone, disabledNotFound
+	case strings.Contains(desc, "not enough rights"),
+		strings.Contains(desc, "have no rights"),
+		strings.Contains(desc, "chat_write_forbidden"),
+		strings.Contains(desc, "chat_send_plain_forbidden"),
+		strings.Contains(desc, "need administrator rights"):
+		return sendErrPermission, ""
+	}
+
+	return sendErrOther, ""
+}
+
+// handleChatError 处理与会话状态相关的发送错误
+// 返回 true 表示消息已改投新的会话，调用方不再按失败处理
+func (o *Outbox) handleChatError(item *outgoing, apiErr *tgbotapi.Error) bool {
+	kind, reason := classifySendError(apiErr)
+
+	switch kind {
+	case sendErrMigrated:
+		newID := apiErr.MigrateToChatID
+		if err := migrateChat(item.chatID, newID); err != nil {
+			log.Printf("[群组迁移] %d → %d 失败: %v", item.chatID, newID, err)
+			return false
+		}
+		o.retargetChat(item.chatID, newID)
+
+		message, ok := retarget(item.message, newID)
+		if !ok {
+			return false
+		}
+		item.chatID = newID
+		item.message = message
+		item.attempts--
+		o.requeue(item)
+		return true
+
+	case sendErrGone:
+		disableChat(item.chatID, reason, apiErr.Message)
+		o.dropChat(item.chatID, apiErr)
+
+	case sendErrPermission:
+		recordPermissionFailure(item.chatID, apiErr.Message)
+	}
+
+	return false
+}
+
+// sendSucceeded 发送成功后清除该群组的权限失败计数
+func sendSucceeded(chatID int64) {
+	if _, ok := failingChats.LoadAndDelete(chatID); ok {
+		db.WriteDB.Exec("UPDATE chat_configs SET permission_failures = 0 WHERE chat_id = ?", chatID)
+	}
+}
+
+// retargetChat 将队列中发往旧会话的消息改投新会话（编辑等依赖旧消息ID的请求无法改投）
+func (o *Outbox) retargetChat(oldID, newID int64) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	for p := range o.lanes {
+		for _, item := range o.lanes[p] {
+			if item.chatID != oldID {
+				continue
+			}
+			if message, ok := retarget(item.message, newID); ok {
+				item.chatID = newID
+				item.message = message
+			}
+		}
+	}
+}
+
+// dropChat 丢弃队列中发往该会话的所有消息
+func (o *Outbox) dropChat(chatID int64, err error) {
+	o.mu.Lock()
+	var dropped []*outgoing
+	for p := range o.lanes {
+		kept := o.lanes[p][:0]
+		for _, item := range o.lanes[p] {
+			if item.chatID == chatID {
+				dropped = append(dropped, item)
+				continue
+			}
+			kept = append(kept, item)
+		}
+		o.lanes[p] = kept
+	}
+	o.mu.Unlock()
+
+	for _, item := range dropped {
+		if item.onResult != nil {
+			item.onResult(tgbotapi.Message{}, err)
+		}
+	}
+	if len(dropped) > 0 {
+		log.Printf("[发送队列] 会话:%d 已不可用，丢弃 %d 条待发送消息", chatID, len(dropped))
+	}
+}
+
+// retarget 修改消息的目标会话
+func retarget(c tgbotapi.Chattable, chatID int64) (tgbotapi.Chattable, bool) {
+	switch m := c.(type) {
+	case tgbotapi.MessageConfig:
+		m.ChatID = chatID
+		return m, true
+	case tgbotapi.PhotoConfig:
+		m.ChatID = chatID
+		return m, true
+	}
+	return c, false
+}
+
+// migrateChat 群组升级为超级群组后，将配置、规则和提醒记录迁移到新的群组ID
+func migrateChat(oldID, newID int64) error {
+	tx, err := db.WriteDB.Begin()
+	if err != nil {
+		return err
+	}
+
+	statements := []struct {
+		query string
+		args  []interface{}
+	}{
+		// 新群组ID可能已经通过 /start 创建了默认配置，以旧群组的配置为准
+		{"DELETE FROM chat_configs WHERE chat_id = ? AND EXISTS (SELECT 1 FROM (SELECT chat_id FROM chat_configs WHERE chat_id = ?) old)", []interface{}{newID, oldID}},
+		{"UPDATE chat_configs SET chat_id = ? WHERE chat_id = ?", []interface{}{newID, oldID}},
+		{"DELETE FROM dragon_rules WHERE chat_id = ? AND EXISTS (SELECT 1 FROM (SELECT chat_id FROM dragon_rules WHERE chat_id = ?) old)", []interface{}{newID, oldID}},
+		{"UPDATE dragon_rules SET chat_id = ? WHERE chat_id = ?", []interface{}{newID, oldID}},
+		{"UPDATE IGNORE dragon_alerts SET chat_id = ? WHERE chat_id = ?", []interface{}{newID, oldID}},
+		{"DELETE FROM dragon_alerts WHERE chat_id = ?", []interface{}{oldID}},
+		// 旧群组的消息ID在新群组中无效
+		{"DELETE FROM alert_messages WHERE chat_id = ?", []interface{}{oldID}},
+		{"UPDATE IGNORE quiet_digests SET chat_id = ? WHERE chat_id = ?", []interface{}{newID, oldID}},
+		{"DELETE FROM quiet_digests WHERE chat_id = ?", []interface{}{oldID}},
+		{"UPDATE IGNORE digest_entries SET chat_id = ? WHERE chat_id = ?", []interface{}{newID, oldID}},
+		{"DELETE FROM digest_entries WHERE chat_id = ?", []interface{}{oldID}},
+		{"UPDATE alert_deliveries SET chat_id = ? WHERE chat_id = ?", []interface{}{newID, oldID}},
+		// 迁移当天新群组可能已有统计，与旧群组的统计合并
+		{`INSERT INTO alert_daily_stats (chat_id, stat_date, sent, failed, dragons, latency_total, latency_count, latency_max)
+			SELECT ?, stat_date, sent, failed, dragons, latency_total, latency_count, latency_max
+			FROM alert_daily_stats WHERE chat_id = ?
+			ON DUPLICATE KEY UPDATE
+				sent = sent + VALUES(sent),
+				failed = failed + VALUES(failed),
+				dragons = dragons + VALUES(dragons),
+				latency_total = latency_total + VALUES(latency_total),
+				latency_count = latency_count + VALUES(latency_count),
+				latency_max = GREATEST(latency_max, VALUES(latency_max))`, []interface{}{newID, oldID}},
+		{"DELETE FROM alert_daily_stats WHERE chat_id = ?", []interface{}{oldID}},
+		// 阈值输入提示绑定旧群组的消息ID，迁移后无法回复
+		{"DELETE FROM threshold_prompts WHERE chat_id = ?", []interface{}{oldID}},
+	}
+
+	for _, s := range statements {
+		if _, err := tx.Exec(s.query, s.args...); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	if _, ok := failingChats.LoadAndDelete(oldID); ok {
+		failingChats.Store(newID, true)
+	}
+
+	log.Printf("[群组迁移] %d → %d 配置、规则和提醒记录已迁移", oldID, newID)
+	return nil
+}
+
+// disableChat 机器人无法再向群组发送消息时自动停用提醒
+func disableChat(chatID int64, reason, description string) {
+	_, err := db.WriteDB.Exec(`
+		UPDATE chat_configs
+		SET enabled = FALSE, disabled_reason = ?, last_send_error = ?, last_send_error_at = NOW()
+		WHERE chat_id = ? AND enabled = TRUE
+	`, reason, truncateError(description), chatID)
+	if err != nil {
+		log.Printf("[自动停用] 群组:%d 失败: %v", chatID, err)
+		return
+	}
+
+	failingChats.Delete(chatID)
+	log.Printf("[自动停用] 群组:%d 原因:%s (%s)", chatID, reason, description)
+}
+
+// recordPermissionFailure 记录权限不足导致的发送失败，连续多次后停用群组
+func recordPermissionFailure(chatID int64, description string) {
+	failingChats.Store(chatID, true)
+
+	_, err := db.WriteDB.Exec(`
+		UPDATE chat_configs
+		SET permission_failures = permission_failures + 1, last_send_error = ?, last_send_error_at = NOW()
+		WHERE chat_id = ?
+	`, truncateError(description), chatID)
+	if err != nil {
+		return
+	}
+
+	var failures int
+	db.WriteDB.QueryRow("SELECT permission_failures FROM chat_configs WHERE chat_id = ?", chatID).Scan(&failures)
+	log.Printf("[权限不足] 群组:%d 连续失败:%d次 (%s)", chatID, failures, description)
+
+	if failures >= permissionFailureLimit {
+		disableChat(chatID, disabledPermission, description)
+	}
+}
+
+func truncateError(s string) string {
+	if len(s) > 255 {
+		return s[:255]
+	}
+	return s
+}
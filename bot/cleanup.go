@@ -0,0 +1,82 @@
+package bot
+
+import (
+	"dragon-alert-bot/db"
+	"dragon-alert-bot/i18n"
+	"log"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// 群组的旧提醒清理方式（chat_configs.cleanup_mode）
+const (
+	CleanupNone     = "none"     // 不清理
+	CleanupPrevious = "previous" // 发送新提醒后删除上一条
+	CleanupExpire   = "expire"   // 删除超过 N 分钟的提醒
+	CleanupThread   = "thread"   // 延续的长龙回复到该长龙的第一条提醒
+)
+
+// 超时删除的分钟数范围
+const (
+	minCleanupMinutes  = 5
+	maxCleanupMinutes  = 1440
+	cleanupMinutesStep = 5
+)
+
+// showCleanupMenu 显示旧提醒清理方式菜单
+func showCleanupMenu(chatID int64, messageID int) {
+	mode := CleanupNone
+	minutes := 30
+	db.WriteDB.QueryRow("SELECT cleanup_mode, cleanup_minutes FROM chat_configs WHERE chat_id = ?", chatID).Scan(&mode, &minutes)
+	lang := chatLanguage(chatID)
+
+	text := i18n.T(lang, "cleanup.title", i18n.T(lang, "cleanup.mode."+mode), minutes)
+
+	button := func(m string) tgbotapi.InlineKeyboardButton {
+		label := i18n.T(lang, "cleanup.mode."+m)
+		if m == mode {
+			label = "✅ " + label
+		}
+		return tgbotapi.NewInlineKeyboardButtonData(label, "dragon_cleanup_"+m)
+	}
+
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(button(CleanupNone), button(CleanupPrevious)),
+		tgbotapi.NewInlineKeyboardRow(button(CleanupExpire), button(CleanupThread)),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("➖", "dragon_cleanup_dec"),
+			tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "cleanup.minutes", minutes), "dragon_noop"),
+			tgbotapi.NewInlineKeyboardButtonData("➕", "dragon_cleanup_inc"),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "menu.back"), "dragon_main"),
+		),
+	)
+
+	msg := tgbotapi.NewEditMessageText(chatID, messageID, text)
+	msg.ReplyMarkup = &keyboard
+	send(chatID, msg)
+}
+
+// handleCleanup 处理清理方式菜单的操作：切换方式或调整超时分钟数
+func handleCleanup(chatID int64, messageID int, action string) {
+	var err error
+	switch action {
+	case CleanupNone, CleanupPrevious, CleanupExpire, CleanupThread:
+		_, err = db.WriteDB.Exec("UPDATE chat_configs SET cleanup_mode = ? WHERE chat_id = ?", action, chatID)
+	case "inc":
+		_, err = db.WriteDB.Exec("UPDATE chat_configs SET cleanup_minutes = LEAST(cleanup_minutes + ?, ?) WHERE chat_id = ?",
+			cleanupMinutesStep, maxCleanupMinutes, chatID)
+	case "dec":
+		_, err = db.WriteDB.Exec("UPDATE chat_configs SET cleanup_minutes = GREATEST(cleanup_minutes - ?, ?) WHERE chat_id = ?",
+			cleanupMinutesStep, minCleanupMinutes, chatID)
+	default:
+		return
+	}
+
+	if err != nil {
+		log.Printf("更新清理方式失败: %v", err)
+	}
+
+	showCleanupMenu(chatID, messageID)
+}
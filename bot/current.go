@@ -0,0 +1,143 @@
+package bot
+
+import (
+	"dragon-alert-bot/db"
+	"dragon-alert-bot/dragon"
+	"dragon-alert-bot/i18n"
+	"dragon-alert-bot/lottery"
+	"fmt"
+	"log"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// historyDepth 本实例还没有分析过开奖时读取的期数（与 Analyzer.Analyze 相同）
+const historyDepth = 500
+
+// drawHistory 返回分析器最近一次分析的开奖历史（从旧到新）
+// 非主节点不执行分析，此时从数据库读取
+func drawHistory() []lottery.Attributes {
+	if attrs := modules.Analyzer.History(); len(attrs) > 0 {
+		return attrs
+	}
+
+	attrs, err := modules.Analyzer.LoadAttrs(historyDepth)
+	if err != nil {
+		log.Printf("[开奖历史] 查询开奖数据失败: %v", err)
+		return nil
+	}
+	return attrs
+}
+
+// runHistory 返回开奖历史（从旧到新）和其中已结束的长龙长度
+// 使用分析器缓存的统计，本实例还没有分析过时从数据库读取后统计
+func runHistory() ([]lottery.Attributes, dragon.RunHistory) {
+	if attrs, history := modules.Analyzer.Snapshot(); len(attrs) > 0 {
+		return attrs, history
+	}
+
+	attrs := drawHistory()
+	return attrs, dragon.BuildRunHistory(attrs)
+}
+
+// currentRuns 返回开奖历史（从旧到新）和其中所有正在进行的模式（已计算稀有度）
+func currentRuns() ([]lottery.Attributes, []*dragon.PatternResult) {
+	attrs, history := runHistory()
+	runs := dragon.DetectAllRuns(attrs)
+	dragon.ScoreRarity(runs, history)
+	return attrs, runs
+}
+
+// handleCurrent 列出当前所有正在进行的模式（不受群组阈值限制），群组中显示距离提醒条件还差多少
+func handleCurrent(message *tgbotapi.Message) {
+	chatID := message.Chat.ID
+	lang := chatLanguage(chatID)
+	if chatID > 0 {
+		lang = i18n.FromLanguageCode(message.From.LanguageCode)
+	}
+
+	attrs, runs := currentRuns()
+
+	// 私聊没有群组规则，只列出模式
+	var rules []db.DragonRule
+	if chatID < 0 {
+		var err error
+		rules, err = modules.Analyzer.GetChatRules(chatID)
+		if err != nil {
+			log.Printf("[当前长龙] 群组:%d 查询规则失败: %v", chatID, err)
+		}
+	}
+
+	qihao := ""
+	if len(attrs) > 0 {
+		qihao = attrs[len(attrs)-1].Qihao
+	}
+
+	msg := tgbotapi.NewMessage(chatID, FormatCurrentRuns(lang, runs, rules, chatID < 0, qihao))
+	msg.ParseMode = "HTML"
+	send(chatID, msg)
+}
+
+// FormatCurrentRuns 格式化所有正在进行的模式：长度、组数、起始期号和距离群组提醒条件的差距
+// withRules 为 false 时（私聊）不显示差距
+func FormatCurrentRuns(lang string, runs []*dragon.PatternResult, rules []db.DragonRule, withRules bool, qihao string) string {
+	var text strings.Builder
+	text.WriteString(i18n.T(lang, "current.title") + "\n")
+	if qihao != "" {
+		text.WriteString(i18n.T(lang, "alert.qihao", qihao) + "\n")
+	}
+
+	for _, attr := range []string{"size", "parity", "sum", "size_parity"} {
+		var lines []string
+		for _, pattern := range []string{"a", "ab", "abb", "ab_ac", "ab_cd", "abab"} {
+			for _, r := range runs {
+				if r.AttributeType != attr || r.PatternType != pattern {
+					continue
+				}
+
+				line := "  • " + i18n.T(lang, "board.line",
+					recordPatternName(lang, pattern), formatLength(lang, r.Count, r.PatternType), r.StartQihao)
+				if withRules {
+					line += " · " + ruleDistance(lang, r, rules)
+				}
+				lines = append(lines, line)
+			}
+		}
+
+		if len(lines) == 0 {
+			lines = append(lines, "  "+i18n.T(lang, "board.none"))
+		}
+		text.WriteString(fmt.Sprintf("\n<b>%s</b>\n%s\n", attributeTitle(lang, attr), strings.Join(lines, "\n")))
+	}
+
+	return strings.TrimRight(text.String(), "\n")
+}
+
+// ruleDistance 距离群组提醒条件的差距：已达到、还差几期/组、稀有度差距或未启用
+func ruleDistance(lang string, r *dragon.PatternResult, rules []db.DragonRule) string {
+	for _, rule := range rules {
+		if rule.PatternType != r.PatternType || rule.AttributeType != r.AttributeType {
+			continue
+		}
+
+		if rule.RarityThreshold > 0 {
+			if r.Rarity >= rule.RarityThreshold {
+				return i18n.T(lang, "current.reached")
+			}
+			return i18n.T(lang, "current.rarity", r.Rarity, rule.RarityThreshold)
+		}
+
+		need := rule.Threshold - dragon.GroupCount(r.Count, r.PatternType)
+		if need <= 0 {
+			return i18n.T(lang, "current.reached")
+		}
+		unit := i18n.UnitGroup
+		if r.PatternType == "a" {
+			unit = i18n.UnitDraw
+		}
+		return i18n.T(lang, "current.need", i18n.Count(lang, unit, need))
+	}
+
+	return i18n.T(lang, "current.off")
+}
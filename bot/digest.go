@@ -0,0 +1,144 @@
+package bot
+
+import (
+	"dragon-alert-bot/db"
+	"dragon-alert-bot/i18n"
+	"log"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// 群组的提醒方式（chat_configs.delivery_mode）
+const (
+	DeliveryImmediate = "immediate" // 每期逐条提醒
+	DeliveryDigest    = "digest"    // 只发送定时汇总
+	DeliveryBoth      = "both"      // 逐条提醒并发送定时汇总
+)
+
+// digestIntervals 汇总间隔选项：d 开头为期数，m 开头为分钟
+var digestIntervals = []string{"d10", "d20", "d50", "m30", "m60", "m120"}
+
+// digestIntervalText 汇总间隔的显示文字（如"每10期"、"每60分钟"）
+func digestIntervalText(lang string, draws, minutes int) string {
+	if draws > 0 {
+		return i18n.T(lang, "digest.every", i18n.Count(lang, i18n.UnitDraw, draws))
+	}
+	return i18n.T(lang, "digest.every", i18n.Plural(lang, "digest.minutes", minutes, minutes))
+}
+
+// deliveryModeText 主菜单中显示的提醒方式
+func deliveryModeText(lang, mode string, draws, minutes int) string {
+	switch mode {
+	case DeliveryDigest, DeliveryBoth:
+		return i18n.T(lang, "digest.mode."+mode) + " (" + digestIntervalText(lang, draws, minutes) + ")"
+	}
+	return i18n.T(lang, "menu.mode.alerts")
+}
+
+// showDigestMenu 显示提醒方式（逐条/汇总）菜单
+func showDigestMenu(chatID int64, messageID int) {
+	mode := DeliveryImmediate
+	var draws int
+	minutes := 60
+	var lang string
+	db.WriteDB.QueryRow("SELECT delivery_mode, digest_draws, digest_minutes, language FROM chat_configs WHERE chat_id = ?", chatID).
+		Scan(&mode, &draws, &minutes, &lang)
+	lang = i18n.Normalize(lang)
+
+	text := i18n.T(lang, "digest.title", i18n.T(lang, "digest.mode."+mode), digestIntervalText(lang, draws, minutes))
+
+	modeButton := func(m string) tgbotapi.InlineKeyboardButton {
+		label := i18n.T(lang, "digest.mode."+m)
+		if m == mode {
+			label = "✅ " + label
+		}
+		return tgbotapi.NewInlineKeyboardButtonData(label, "dragon_digest_mode_"+m)
+	}
+
+	var intervalRow []tgbotapi.InlineKeyboardButton
+	var rows [][]tgbotapi.InlineKeyboardButton
+	rows = append(rows,
+		tgbotapi.NewInlineKeyboardRow(modeButton(DeliveryImmediate)),
+		tgbotapi.NewInlineKeyboardRow(modeButton(DeliveryDigest), modeButton(DeliveryBoth)),
+	)
+	for _, interval := range digestIntervals {
+		d, m := parseDigestInterval(interval)
+		label := digestIntervalText(lang, d, m)
+		if (d > 0 && d == draws) || (d == 0 && draws == 0 && m == minutes) {
+			label = "✅ " + label
+		}
+		intervalRow = append(intervalRow, tgbotapi.NewInlineKeyboardButtonData(label, "dragon_digest_every_"+interval))
+		if len(intervalRow) == 3 {
+			rows = append(rows, intervalRow)
+			intervalRow = nil
+		}
+	}
+	rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "menu.back"), "dragon_main"),
+	))
+
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(rows...)
+
+	msg := tgbotapi.NewEditMessageText(chatID, messageID, text)
+	msg.ReplyMarkup = &keyboard
+	send(chatID, msg)
+}
+
+// parseDigestInterval 解析汇总间隔选项，返回期数和分钟数（其中一个为0）
+func parseDigestInterval(interval string) (int, int) {
+	if len(interval) < 2 {
+		return 0, 0
+	}
+	n, err := strconv.Atoi(interval[1:])
+	if err != nil || n <= 0 {
+		return 0, 0
+	}
+	if strings.HasPrefix(interval, "d") {
+		return n, 0
+	}
+	return 0, n
+}
+
+// handleDigest 处理提醒方式菜单的操作：切换方式（mode_X）或汇总间隔（every_X）
+func handleDigest(chatID int64, messageID int, args []string) {
+	if len(args) < 2 {
+		showDigestMenu(chatID, messageID)
+		return
+	}
+
+	var err error
+	switch args[0] {
+	case "mode":
+		switch args[1] {
+		case DeliveryImmediate, DeliveryDigest, DeliveryBoth:
+			// 切换方式时重新开始计算汇总周期
+			_, err = db.WriteDB.Exec(`
+				UPDATE chat_configs SET delivery_mode = ?, digest_draw_count = 0, last_digest_at = NOW() WHERE chat_id = ?
+			`, args[1], chatID)
+		default:
+			return
+		}
+
+	case "every":
+		draws, minutes := parseDigestInterval(args[1])
+		if draws == 0 && minutes == 0 {
+			return
+		}
+		if draws > 0 {
+			_, err = db.WriteDB.Exec("UPDATE chat_configs SET digest_draws = ? WHERE chat_id = ?", draws, chatID)
+		} else {
+			_, err = db.WriteDB.Exec("UPDATE chat_configs SET digest_draws = 0, digest_minutes = ? WHERE chat_id = ?", minutes, chatID)
+		}
+
+	default:
+		return
+	}
+
+	if err != nil {
+		log.Printf("更新提醒方式失败: %v", err)
+	}
+
+	showDigestMenu(chatID, messageID)
+}
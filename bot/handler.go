@@ -2,6 +2,8 @@ package bot
 
 import (
 	"dragon-alert-bot/db"
+	"dragon-alert-bot/dragon"
+	"dragon-alert-bot/i18n"
 	"fmt"
 	"log"
 
@@ -42,6 +44,28 @@ func handleCommand(message *tgbotapi.Message) {
 		handleDragon(message)
 	case "data":
 		handleData(message)
+	case "records":
+		handleRecords(message)
+	case "template":
+		handleTemplate(message)
+	case "stats":
+		handleStats(message)
+	case "road":
+		handleRoad(message)
+	case "trend":
+		handleTrend(message)
+	case "current":
+		handleCurrent(message)
+	case "history":
+		handleHistory(message)
+	case "set":
+		handleSetCommand(message)
+	case "enable":
+		handleEnableCommand(message, true)
+	case "disable":
+		handleEnableCommand(message, false)
+	case "rules":
+		handleRulesCommand(message)
 	}
 }
 
@@ -50,63 +74,53 @@ func handleStart(message *tgbotapi.Message) {
 
 	// 判断是群组还是私聊
 	if message.Chat.Type == "group" || message.Chat.Type == "supergroup" {
-		text := `欢迎使用长龙提醒机器人！🎲
+		msg := tgbotapi.NewMessage(chatID, i18n.T(chatLanguage(chatID), "welcome.group"))
+		send(chatID, msg)
 
-功能：
-• 自动监测开奖数据
-• 识别各种长龙模式
-• 自定义提醒规则
-
-命令：
-/long - 配置长龙提醒（仅管理员）`
-
-		msg := tgbotapi.NewMessage(chatID, text)
-		BotAPI.Send(msg)
-
-		// 异步初始化群组配置
-		go ensureChatConfig(chatID)
+		// 异步初始化群组配置（语言只按管理员设置，这里不指定）
+		go ensureChatConfig(chatID, "")
 	} else {
-		text := `欢迎使用长龙提醒机器人！🎲
-
-⚠️ 本机器人仅支持群组使用
-
-功能特点：
-• 自动监测开奖数据
-• 识别多种长龙模式
-• 灵活的自定义规则
-
-使用步骤：
-1. 点击下方按钮添加到群组
-2. 在群组中发送 /long 命令
-3. 管理员可配置提醒规则`
+		lang := i18n.FromLanguageCode(message.From.LanguageCode)
 
 		// 创建内联按钮
 		keyboard := tgbotapi.NewInlineKeyboardMarkup(
 			tgbotapi.NewInlineKeyboardRow(
 				tgbotapi.NewInlineKeyboardButtonURL(
-					"➕ 添加机器人到群组",
+					i18n.T(lang, "welcome.add_button"),
 					fmt.Sprintf("https://t.me/%s?startgroup=1", BotAPI.Self.UserName),
 				),
 			),
 		)
 
-		msg := tgbotapi.NewMessage(chatID, text)
+		msg := tgbotapi.NewMessage(chatID, i18n.T(lang, "welcome.private"))
 		msg.ReplyMarkup = keyboard
-		BotAPI.Send(msg)
+		send(chatID, msg)
 	}
 }
 
 func handleDragon(message *tgbotapi.Message) {
+	if !checkGroupAdmin(message) {
+		return
+	}
+
+	// 显示主菜单
+	showMainMenu(message.Chat.ID, 0)
+}
+
+// checkGroupAdmin 检查命令是否由群组管理员在群组中发送，不是时回复提示
+// 通过检查后确保群组配置存在
+func checkGroupAdmin(message *tgbotapi.Message) bool {
 	chatID := message.Chat.ID
 
 	// 只允许在群组中使用
 	if message.Chat.Type != "group" && message.Chat.Type != "supergroup" {
-		text := "⚠️ 长龙提醒仅支持群组使用\n\n请点击下方按钮将机器人添加到群组"
+		lang := i18n.FromLanguageCode(message.From.LanguageCode)
+		text := i18n.T(lang, "dragon.group_only")
 
 		keyboard := tgbotapi.NewInlineKeyboardMarkup(
 			tgbotapi.NewInlineKeyboardRow(
 				tgbotapi.NewInlineKeyboardButtonURL(
-					"➕ 添加到群组",
+					i18n.T(lang, "dragon.add_button"),
 					fmt.Sprintf("https://t.me/%s?startgroup=1", BotAPI.Self.UserName),
 				),
 			),
@@ -114,8 +128,8 @@ func handleDragon(message *tgbotapi.Message) {
 
 		msg := tgbotapi.NewMessage(chatID, text)
 		msg.ReplyMarkup = keyboard
-		BotAPI.Send(msg)
-		return
+		send(chatID, msg)
+		return false
 	}
 
 	// 检查权限（只有群组管理员可以配置）
@@ -127,16 +141,14 @@ func handleDragon(message *tgbotapi.Message) {
 	})
 
 	if err != nil || (member.Status != "creator" && member.Status != "administrator") {
-		msg := tgbotapi.NewMessage(chatID, "⚠️ 仅限群组管理员操作")
-		BotAPI.Send(msg)
-		return
+		msg := tgbotapi.NewMessage(chatID, i18n.T(chatLanguage(chatID), "admin_only"))
+		send(chatID, msg)
+		return false
 	}
 
-	// 异步确保配置存在，不阻塞响应
-	go ensureChatConfig(chatID)
-
-	// 显示主菜单
-	showMainMenu(chatID, 0)
+	// 确保配置存在，新群组按管理员的 language_code 设置语言
+	ensureChatConfig(chatID, message.From.LanguageCode)
+	return true
 }
 
 func handleData(message *tgbotapi.Message) {
@@ -144,11 +156,11 @@ func handleData(message *tgbotapi.Message) {
 
 	// 获取群组数量
 	var totalGroups int
-	db.WriteDB.QueryRow("SELECT COUNT(*) FROM chat_configs WHERE chat_id < 0").Scan(&totalGroups)
+	db.WriteDB.QueryRow("SELECT COUNT(*) FROM chat_configs WHERE chat_id < 0 AND archived_at IS NULL").Scan(&totalGroups)
 
 	// 获取启用的群组数量
 	var enabledGroups int
-	db.WriteDB.QueryRow("SELECT COUNT(*) FROM chat_configs WHERE chat_id < 0 AND enabled = TRUE").Scan(&enabledGroups)
+	db.WriteDB.QueryRow("SELECT COUNT(*) FROM chat_configs WHERE chat_id < 0 AND archived_at IS NULL AND enabled = TRUE").Scan(&enabledGroups)
 
 	// 获取总规则数
 	var totalRules int
@@ -156,118 +168,104 @@ func handleData(message *tgbotapi.Message) {
 
 	// 获取活跃长龙数量
 	var activeDragons int
-	db.WriteDB.QueryRow("SELECT COUNT(*) FROM dragon_alerts WHERE status = 'active'").Scan(&activeDragons)
-
-	// 获取今日提醒次数（需要添加统计表，暂时显示活跃长龙）
-	text := fmt.Sprintf(`📊 <b>机器人数据统计</b>
-
-👥 <b>群组数据</b>
-• 总群组数: <code>%d</code>
-• 启用提醒: <code>%d</code>
-• 禁用提醒: <code>%d</code>
-
-⚙️ <b>配置数据</b>
-• 启用规则: <code>%d</code> 条
-
-🔥 <b>长龙数据</b>
-• 活跃长龙: <code>%d</code> 个
-
-💡 使用 /long 配置长龙提醒`,
+	db.WriteDB.QueryRow("SELECT COUNT(*) FROM dragons WHERE status = 'active'").Scan(&activeDragons)
+
+	// 获取今日提醒统计（所有群组）
+	var today dailyStats
+	db.WriteDB.QueryRow(`
+		SELECT COALESCE(SUM(sent), 0), COALESCE(SUM(failed), 0), COALESCE(SUM(dragons), 0),
+			COALESCE(SUM(latency_total), 0), COALESCE(SUM(latency_count), 0), COALESCE(MAX(latency_max), 0)
+		FROM alert_daily_stats WHERE stat_date = CURDATE()
+	`).Scan(&today.sent, &today.failed, &today.dragons, &today.latencyTotal, &today.latencyCount, &today.latencyMax)
+
+	lang := chatLanguage(chatID)
+	text := i18n.T(lang, "data.text",
 		totalGroups,
 		enabledGroups,
 		totalGroups-enabledGroups,
 		totalRules,
 		activeDragons,
+		today.sent,
+		today.failed,
+		today.averageLatency(lang),
 	)
 
 	msg := tgbotapi.NewMessage(chatID, text)
 	msg.ParseMode = "HTML"
-	BotAPI.Send(msg)
+	send(chatID, msg)
 }
 
-func ensureChatConfig(chatID int64) {
+func handleRecords(message *tgbotapi.Message) {
+	chatID := message.Chat.ID
+
+	entries, err := modules.Records.GetLeaderboard()
+	if err != nil {
+		log.Printf("[纪录榜] 查询失败: %v", err)
+		return
+	}
+
+	msg := tgbotapi.NewMessage(chatID, FormatLeaderboard(chatLanguage(chatID), entries))
+	msg.ParseMode = "HTML"
+	send(chatID, msg)
+}
+
+// ensureChatConfig 确保群组配置存在
+// languageCode 为管理员的 language_code，群组还没有设置语言时按它设置，为空时不设置
+func ensureChatConfig(chatID int64, languageCode string) {
 	// 只为群组创建配置（chatID < 0 为群组）
 	if chatID > 0 {
 		return
 	}
 
+	lang := ""
+	if languageCode != "" {
+		lang = i18n.FromLanguageCode(languageCode)
+	}
+
 	// 检查配置是否存在
 	var exists bool
 	err := db.WriteDB.QueryRow("SELECT EXISTS(SELECT 1 FROM chat_configs WHERE chat_id = ?)", chatID).Scan(&exists)
 	if err != nil || !exists {
 		// 创建默认配置
-		db.WriteDB.Exec("INSERT INTO chat_configs (chat_id, enabled) VALUES (?, TRUE)", chatID)
+		db.WriteDB.Exec("INSERT INTO chat_configs (chat_id, enabled, language) VALUES (?, TRUE, ?)", chatID, lang)
 
 		// 创建默认规则
 		createDefaultRules(chatID)
 
-		log.Printf("[配置初始化] 群组:%d", chatID)
+		log.Printf("[配置初始化] 群组:%d 语言:%s", chatID, lang)
+		return
+	}
+
+	if lang != "" {
+		db.WriteDB.Exec("UPDATE chat_configs SET language = ? WHERE chat_id = ? AND language = ''", lang, chatID)
 	}
 }
 
 // createDefaultRules 创建默认规则
 func createDefaultRules(chatID int64) {
-	defaultRules := []struct {
-		pattern   string
-		attribute string
-		threshold int
-	}{
-		{"a", "size", 5},
-		{"a", "parity", 5},
-		{"a", "sum", 5},
-		{"ab", "size", 2},
-		{"ab", "parity", 2},
-		{"ab", "sum", 2},
-		{"abb", "size", 2},
-		{"abb", "parity", 2},
-		{"abb", "sum", 2},
-		{"ab_ac", "size_parity", 2},
-		{"ab_cd", "size_parity", 2},
-		{"abab", "size_parity", 2},
-	}
-
-	for _, rule := range defaultRules {
+	for _, rule := range dragon.DefaultRules {
 		db.WriteDB.Exec(`
 			INSERT INTO dragon_rules (chat_id, pattern_type, attribute_type, threshold, enabled)
 			VALUES (?, ?, ?, ?, TRUE)
 			ON DUPLICATE KEY UPDATE threshold = ?, enabled = TRUE
-		`, chatID, rule.pattern, rule.attribute, rule.threshold, rule.threshold)
+		`, chatID, rule.PatternType, rule.AttributeType, rule.Threshold, rule.Threshold)
 	}
 }
 
 // ensureDefaultRules 确保规则存在
 func ensureDefaultRules(chatID int64) {
-	defaultRules := []struct {
-		pattern   string
-		attribute string
-		threshold int
-	}{
-		{"a", "size", 5},
-		{"a", "parity", 5},
-		{"a", "sum", 5},
-		{"ab", "size", 2},
-		{"ab", "parity", 2},
-		{"ab", "sum", 2},
-		{"abb", "size", 2},
-		{"abb", "parity", 2},
-		{"abb", "sum", 2},
-		{"ab_ac", "size_parity", 2},
-		{"ab_cd", "size_parity", 2},
-		{"abab", "size_parity", 2},
-	}
-
-	for _, rule := range defaultRules {
+	for _, rule := range dragon.DefaultRules {
 		var exists bool
 		err := db.WriteDB.QueryRow(`
 			SELECT EXISTS(SELECT 1 FROM dragon_rules 
 			WHERE chat_id = ? AND pattern_type = ? AND attribute_type = ?)
-		`, chatID, rule.pattern, rule.attribute).Scan(&exists)
+		`, chatID, rule.PatternType, rule.AttributeType).Scan(&exists)
 
 		if err != nil || !exists {
 			db.WriteDB.Exec(`
 				INSERT INTO dragon_rules (chat_id, pattern_type, attribute_type, threshold, enabled)
 				VALUES (?, ?, ?, ?, TRUE)
-			`, chatID, rule.pattern, rule.attribute, rule.threshold)
+			`, chatID, rule.PatternType, rule.AttributeType, rule.Threshold)
 		}
 	}
 }
@@ -0,0 +1,403 @@
+package bot
+
+import (
+	"dragon-alert-bot/db"
+	"dragon-alert-bot/i18n"
+	"fmt"
+	"html"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// historyPageSize /history 每页显示的长龙数
+const historyPageSize = 8
+
+// historyDate 回调数据中的日期格式
+const historyDate = "060102"
+
+// historyAttributes 属性在回调数据中的简写（回调数据最长64字节）
+var historyAttributes = map[string]string{
+	"size":        "s",
+	"parity":      "p",
+	"sum":         "m",
+	"size_parity": "c",
+}
+
+// historyFilter 历史长龙的筛选条件，零值表示不筛选
+type historyFilter struct {
+	Attribute string
+	Pattern   string
+	MinCount  int
+	From      time.Time // 包含当天
+	To        time.Time // 包含当天
+}
+
+// parseHistoryArgs 解析 /history 的参数：属性、格式、最小期数、日期（第一个为开始，第二个为结束）或最近N天（如 7d）
+func parseHistoryArgs(args string) (historyFilter, bool) {
+	var f historyFilter
+	for _, arg := range strings.Fields(strings.ToLower(args)) {
+		if attr, ok := attributeAliases[arg]; ok {
+			f.Attribute = attr
+			continue
+		}
+		if pattern, ok := patternAliases[arg]; ok {
+			f.Pattern = pattern
+			continue
+		}
+		if n, err := strconv.Atoi(arg); err == nil && n > 0 {
+			f.MinCount = n
+			continue
+		}
+		if days, err := strconv.Atoi(strings.TrimSuffix(arg, "d")); err == nil && strings.HasSuffix(arg, "d") && days > 0 {
+			now := time.Now()
+			f.From = time.Date(now.Year(), now.Month(), now.Day()+1-days, 0, 0, 0, 0, time.Local)
+			continue
+		}
+		if date, ok := parseHistoryDate(arg); ok {
+			if f.From.IsZero() {
+				f.From = date
+			} else {
+				f.To = date
+			}
+			continue
+		}
+		return f, false
+	}
+
+	if !f.To.IsZero() && f.To.Before(f.From) {
+		f.From, f.To = f.To, f.From
+	}
+	return f, true
+}
+
+// parseHistoryDate 解析 2006-01-02 或 01-02（今年）格式的日期
+func parseHistoryDate(s string) (time.Time, bool) {
+	if t, err := time.ParseInLocation("2006-01-02", s, time.Local); err == nil {
+		return t, true
+	}
+	if t, err := time.ParseInLocation("01-02", s, time.Local); err == nil {
+		return t.AddDate(time.Now().Year(), 0, 0), true
+	}
+	return time.Time{}, false
+}
+
+// encode 编码为回调数据：属性:格式:最小期数:开始:结束，空值为"-"
+func (f historyFilter) encode() string {
+	part := func(s string) string {
+		if s == "" {
+			return "-"
+		}
+		return s
+	}
+	date := func(t time.Time) string {
+		if t.IsZero() {
+			return "-"
+		}
+		return t.Format(historyDate)
+	}
+	return strings.Join([]string{part(historyAttributes[f.Attribute]), part(f.Pattern),
+		strconv.Itoa(f.MinCount), date(f.From), date(f.To)}, ":")
+}
+
+func decodeHistoryFilter(parts []string) (historyFilter, bool) {
+	var f historyFilter
+	if len(parts) < 5 {
+		return f, false
+	}
+
+	for attr, code := range historyAttributes {
+		if parts[0] == code {
+			f.Attribute = attr
+		}
+	}
+	if pattern, ok := patternAliases[parts[1]]; ok {
+		f.Pattern = pattern
+	}
+	f.MinCount, _ = strconv.Atoi(parts[2])
+	f.From, _ = time.ParseInLocation(historyDate, parts[3], time.Local)
+	f.To, _ = time.ParseInLocation(historyDate, parts[4], time.Local)
+	return f, true
+}
+
+// where 生成 dragon_alerts a LEFT JOIN dragons d 的筛选条件
+func (f historyFilter) where(chatID int64) (string, []interface{}) {
+	conds := []string{"a.chat_id = ?"}
+	args := []interface{}{chatID}
+	if f.Attribute != "" {
+		conds = append(conds, "a.attribute_type = ?")
+		args = append(args, f.Attribute)
+	}
+	if f.Pattern != "" {
+		conds = append(conds, "a.pattern_type = ?")
+		args = append(args, f.Pattern)
+	}
+	if f.MinCount > 0 {
+		conds = append(conds, "COALESCE(d.count, a.count) >= ?")
+		args = append(args, f.MinCount)
+	}
+	if !f.From.IsZero() {
+		conds = append(conds, "a.created_at >= ?")
+		args = append(args, f.From)
+	}
+	if !f.To.IsZero() {
+		conds = append(conds, "a.created_at < ?")
+		args = append(args, f.To.AddDate(0, 0, 1))
+	}
+	return strings.Join(conds, " AND "), args
+}
+
+// describe 当前筛选条件的说明，没有筛选时为空
+func (f historyFilter) describe(lang string) string {
+	var parts []string
+	if f.Attribute != "" {
+		parts = append(parts, attributeName(lang, f.Attribute))
+	}
+	if f.Pattern != "" {
+		parts = append(parts, recordPatternName(lang, f.Pattern))
+	}
+	if f.MinCount > 0 {
+		parts = append(parts, "≥"+i18n.Count(lang, i18n.UnitDraw, f.MinCount))
+	}
+	if !f.From.IsZero() || !f.To.IsZero() {
+		from, to := "…", "…"
+		if !f.From.IsZero() {
+			from = f.From.Format("01-02")
+		}
+		if !f.To.IsZero() {
+			to = f.To.Format("01-02")
+		}
+		parts = append(parts, from+"~"+to)
+	}
+	return strings.Join(parts, " · ")
+}
+
+// historyEntry 一条历史长龙（本群组提醒过的长龙及其最终状态）
+type historyEntry struct {
+	ID            int64
+	PatternType   string
+	AttributeType string
+	StartQihao    string
+	EndQihao      string
+	Count         int
+	PatternDetail string
+	Status        string
+	StartedAt     time.Time
+	EndedAt       time.Time
+	AlertedAt     time.Time
+	AlertCount    int
+}
+
+// historyColumns 查询历史长龙的列，长龙已从 dragons 删除时使用群组记录中的数据
+const historyColumns = `
+	a.id, a.pattern_type, a.attribute_type, a.start_qihao,
+	COALESCE(NULLIF(d.end_qihao, ''), d.current_qihao, a.current_qihao),
+	COALESCE(d.count, a.count), COALESCE(d.pattern_detail, a.pattern_detail, ''), COALESCE(d.status, a.status),
+	COALESCE(d.created_at, a.created_at), COALESCE(d.ended_at, d.updated_at, a.updated_at),
+	a.created_at, a.last_alert_count`
+
+func scanHistoryEntry(scan func(dest ...interface{}) error) (historyEntry, error) {
+	var e historyEntry
+	err := scan(&e.ID, &e.PatternType, &e.AttributeType, &e.StartQihao, &e.EndQihao,
+		&e.Count, &e.PatternDetail, &e.Status, &e.StartedAt, &e.EndedAt, &e.AlertedAt, &e.AlertCount)
+	return e, err
+}
+
+// handleHistory 浏览本群组提醒过的历史长龙：/history [属性] [格式] [最小期数] [开始日期] [结束日期]
+func handleHistory(message *tgbotapi.Message) {
+	chatID := message.Chat.ID
+	lang := chatLanguage(chatID)
+
+	if chatID > 0 {
+		lang = i18n.FromLanguageCode(message.From.LanguageCode)
+		send(chatID, tgbotapi.NewMessage(chatID, i18n.T(lang, "stats.group_only")))
+		return
+	}
+
+	filter, ok := parseHistoryArgs(message.CommandArguments())
+	if !ok {
+		send(chatID, tgbotapi.NewMessage(chatID, i18n.T(lang, "history.usage")))
+		return
+	}
+
+	text, keyboard := historyPage(chatID, lang, filter, 0)
+	msg := tgbotapi.NewMessage(chatID, text)
+	msg.ParseMode = "HTML"
+	if len(keyboard.InlineKeyboard) > 0 {
+		msg.ReplyMarkup = keyboard
+	}
+	send(chatID, msg)
+}
+
+// handleHistoryCallback 处理历史长龙的翻页（hist:页码:筛选）和详情（histd:ID:页码:筛选），所有成员都可以使用
+func handleHistoryCallback(chatID int64, messageID int, lang, data string) {
+	parts := strings.Split(data, ":")
+	if len(parts) < 2 {
+		return
+	}
+
+	var text string
+	var keyboard tgbotapi.InlineKeyboardMarkup
+	switch parts[0] {
+	case "hist":
+		page, err := strconv.Atoi(parts[1])
+		filter, ok := decodeHistoryFilter(parts[2:])
+		if err != nil || !ok || page < 0 {
+			return
+		}
+		text, keyboard = historyPage(chatID, lang, filter, page)
+
+	case "histd":
+		if len(parts) < 3 {
+			return
+		}
+		id, err := strconv.ParseInt(parts[1], 10, 64)
+		if err != nil {
+			return
+		}
+		text, keyboard = historyDetail(chatID, lang, id, "hist:"+strings.Join(parts[2:], ":"))
+
+	default:
+		return
+	}
+
+	msg := tgbotapi.NewEditMessageText(chatID, messageID, text)
+	msg.ParseMode = "HTML"
+	msg.ReplyMarkup = &keyboard
+	send(chatID, msg)
+}
+
+// historyPage 生成第 page 页（0 为最新）的历史长龙列表和按钮
+func historyPage(chatID int64, lang string, filter historyFilter, page int) (string, tgbotapi.InlineKeyboardMarkup) {
+	where, args := filter.where(chatID)
+
+	var total int
+	err := db.WriteDB.QueryRow("SELECT COUNT(*) FROM dragon_alerts a LEFT JOIN dragons d ON d.id = a.dragon_id WHERE "+where, args...).Scan(&total)
+	if err != nil {
+		log.Printf("[历史长龙] 群组:%d 查询失败: %v", chatID, err)
+	}
+
+	pages := (total + historyPageSize - 1) / historyPageSize
+	if page >= pages && pages > 0 {
+		page = pages - 1
+	}
+
+	var entries []historyEntry
+	rows, err := db.WriteDB.Query("SELECT "+historyColumns+`
+		FROM dragon_alerts a LEFT JOIN dragons d ON d.id = a.dragon_id
+		WHERE `+where+`
+		ORDER BY a.id DESC
+		LIMIT ? OFFSET ?
+	`, append(args, historyPageSize, page*historyPageSize)...)
+	if err == nil {
+		for rows.Next() {
+			if e, err := scanHistoryEntry(rows.Scan); err == nil {
+				entries = append(entries, e)
+			}
+		}
+		rows.Close()
+	}
+
+	var text strings.Builder
+	text.WriteString(i18n.T(lang, "history.title", total) + "\n")
+	if desc := filter.describe(lang); desc != "" {
+		text.WriteString(i18n.T(lang, "history.filter", html.EscapeString(desc)) + "\n")
+	}
+
+	state := filter.encode()
+	rowsKeyboard := [][]tgbotapi.InlineKeyboardButton{}
+	if len(entries) == 0 {
+		text.WriteString("\n" + i18n.T(lang, "history.empty"))
+	}
+	for i, e := range entries {
+		n := page*historyPageSize + i + 1
+		text.WriteString(fmt.Sprintf("\n%d. %s", n, formatHistoryLine(lang, e)))
+		rowsKeyboard = append(rowsKeyboard, tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData(
+			fmt.Sprintf("%d. %s %s", n, attributeName(lang, e.AttributeType), recordPatternName(lang, e.PatternType)),
+			fmt.Sprintf("histd:%d:%d:%s", e.ID, page, state))))
+	}
+
+	var nav []tgbotapi.InlineKeyboardButton
+	if page > 0 {
+		nav = append(nav, tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "history.newer"), fmt.Sprintf("hist:%d:%s", page-1, state)))
+	}
+	if pages > 1 {
+		nav = append(nav, tgbotapi.NewInlineKeyboardButtonData(fmt.Sprintf("%d/%d", page+1, pages), fmt.Sprintf("hist:%d:%s", page, state)))
+	}
+	if page+1 < pages {
+		nav = append(nav, tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "history.older"), fmt.Sprintf("hist:%d:%s", page+1, state)))
+	}
+	if len(nav) > 0 {
+		rowsKeyboard = append(rowsKeyboard, nav)
+	}
+
+	return text.String(), tgbotapi.InlineKeyboardMarkup{InlineKeyboard: rowsKeyboard}
+}
+
+// formatHistoryLine 列表中的一行：属性格式、最终长度、起止期号和持续时间
+func formatHistoryLine(lang string, e historyEntry) string {
+	status := ""
+	if e.Status != "ended" {
+		status = " " + i18n.T(lang, "quiet.active")
+	}
+	return i18n.T(lang, "history.line",
+		attributeTitle(lang, e.AttributeType), recordPatternName(lang, e.PatternType),
+		formatLength(lang, e.Count, e.PatternType), e.StartQihao, e.EndQihao,
+		formatDuration(lang, e.EndedAt.Sub(e.StartedAt))) + status
+}
+
+// historyDetail 一条历史长龙的详情，back 为返回列表的回调数据
+func historyDetail(chatID int64, lang string, id int64, back string) (string, tgbotapi.InlineKeyboardMarkup) {
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "menu.back"), back),
+	))
+
+	row := db.WriteDB.QueryRow("SELECT "+historyColumns+`
+		FROM dragon_alerts a LEFT JOIN dragons d ON d.id = a.dragon_id
+		WHERE a.id = ? AND a.chat_id = ?
+	`, id, chatID)
+	e, err := scanHistoryEntry(row.Scan)
+	if err != nil {
+		return i18n.T(lang, "history.empty"), keyboard
+	}
+
+	// 本群组为这条长龙发送过的提醒消息数（不含附带的路单图片）
+	var messages int
+	db.WriteDB.QueryRow(`
+		SELECT COUNT(DISTINCT message_id) FROM alert_messages
+		WHERE chat_id = ? AND pattern_type = ? AND attribute_type = ? AND start_qihao = ? AND kind = 'alert'
+	`, chatID, e.PatternType, e.AttributeType, e.StartQihao).Scan(&messages)
+
+	status := i18n.T(lang, "quiet.ended")
+	if e.Status != "ended" {
+		status = i18n.T(lang, "quiet.active")
+	}
+
+	text := i18n.T(lang, "history.detail",
+		attributeTitle(lang, e.AttributeType), recordPatternName(lang, e.PatternType), status,
+		e.StartQihao, e.EndQihao,
+		formatLength(lang, e.Count, e.PatternType),
+		e.StartedAt.Format("01-02 15:04"), e.EndedAt.Format("01-02 15:04"), formatDuration(lang, e.EndedAt.Sub(e.StartedAt)),
+		e.AlertedAt.Format("01-02 15:04"), formatLength(lang, e.AlertCount, e.PatternType), messages)
+
+	if e.PatternDetail != "" {
+		text += "\n" + i18n.T(lang, "history.pattern", html.EscapeString(i18n.Values(lang, e.PatternDetail)))
+	}
+
+	return text, keyboard
+}
+
+// formatDuration 持续时间（如"2小时5分钟"）
+func formatDuration(lang string, d time.Duration) string {
+	if d < 0 {
+		d = 0
+	}
+	minutes := int(d.Minutes())
+	if minutes < 60 {
+		return i18n.T(lang, "history.duration.m", minutes)
+	}
+	return i18n.T(lang, "history.duration.hm", minutes/60, minutes%60)
+}
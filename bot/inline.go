@@ -2,6 +2,8 @@ package bot
 
 import (
 	"dragon-alert-bot/db"
+	"dragon-alert-bot/dragon"
+	"dragon-alert-bot/i18n"
 	"fmt"
 	"log"
 	"strings"
@@ -18,6 +20,22 @@ func handleCallback(callback *tgbotapi.CallbackQuery) {
 	callbackConfig := tgbotapi.NewCallback(callback.ID, "")
 	BotAPI.Request(callbackConfig)
 
+	// 走势、历史长龙的翻页所有成员都可以使用，不检查管理员权限
+	if strings.HasPrefix(data, "trend_") || strings.HasPrefix(data, "hist") {
+		go func() {
+			lang := chatLanguage(chatID)
+			if chatID > 0 {
+				lang = i18n.FromLanguageCode(callback.From.LanguageCode)
+			}
+			if strings.HasPrefix(data, "trend_") {
+				handleTrendPage(chatID, messageID, lang, strings.Split(data, "_")[1:])
+			} else {
+				handleHistoryCallback(chatID, messageID, lang, data)
+			}
+		}()
+		return
+	}
+
 	// 全异步处理（包括权限检查）
 	go func() {
 		// 异步检查管理员权限，非管理员直接忽略
@@ -38,25 +56,56 @@ func handleCallback(callback *tgbotapi.CallbackQuery) {
 			showMainMenu(chatID, messageID)
 		case "toggle":
 			toggleDragonAlert(chatID, messageID)
-		case "size":
-			showAttributeMenu(chatID, messageID, "size", "大小")
-		case "parity":
-			showAttributeMenu(chatID, messageID, "parity", "单双")
-		case "sum":
-			showAttributeMenu(chatID, messageID, "sum", "和值")
+		case "lang":
+			cycleLanguage(chatID, messageID)
+		case "board":
+			toggleLiveBoard(chatID, messageID)
+		case "road":
+			toggleRoadAttach(chatID, messageID)
+		case "sched":
+			if len(parts) >= 3 {
+				handleSchedule(chatID, messageID, parts[2:])
+			} else {
+				showScheduleMenu(chatID, messageID)
+			}
+		case "digest":
+			if len(parts) >= 3 {
+				handleDigest(chatID, messageID, parts[2:])
+			} else {
+				showDigestMenu(chatID, messageID)
+			}
+		case "cleanup":
+			if len(parts) >= 3 {
+				handleCleanup(chatID, messageID, parts[2])
+			} else {
+				showCleanupMenu(chatID, messageID)
+			}
+		case "size", "parity", "sum":
+			showAttributeMenu(chatID, messageID, action)
 		case "combo":
 			showComboMenu(chatID, messageID)
 		case "status":
 			showStatusMenu(chatID, messageID)
 		case "refresh":
 			showStatusMenu(chatID, messageID)
+		case "simulate":
+			showSimulation(chatID, messageID)
+		case "preview":
+			sendPreview(chatID)
 		case "set":
 			if len(parts) >= 5 {
 				handleSetRule(chatID, messageID, parts[2], parts[3], parts[4])
 			}
 		case "combo2":
+			// 组合模式名本身带下划线（如 ab_ac），动作总在最后一段
 			if len(parts) >= 4 {
-				handleComboRule(chatID, messageID, parts[2], parts[3])
+				pattern := strings.Join(parts[2:len(parts)-1], "_")
+				handleComboRule(chatID, messageID, pattern, parts[len(parts)-1])
+			}
+		case "input":
+			// 点击触发值：dragon_input_<属性>_<格式>，组合的格式名带下划线
+			if len(parts) >= 4 {
+				promptThreshold(chatID, messageID, callback.From, attributeAliases[parts[2]], strings.Join(parts[3:], "_"))
 			}
 		}
 	}()
@@ -64,54 +113,90 @@ func handleCallback(callback *tgbotapi.CallbackQuery) {
 
 func showMainMenu(chatID int64, messageID int) {
 	// 获取当前启用状态
-	var enabled bool
-	db.WriteDB.QueryRow("SELECT enabled FROM chat_configs WHERE chat_id = ?", chatID).Scan(&enabled)
-
-	status := "❌ 已禁用"
-	toggleText := "✅ 启用提醒"
+	var enabled, liveBoard, roadAttach bool
+	var lang string
+	deliveryMode := DeliveryImmediate
+	var digestDraws, digestMinutes int
+	db.WriteDB.QueryRow(`
+		SELECT enabled, live_board, language, delivery_mode, digest_draws, digest_minutes, road_attach
+		FROM chat_configs WHERE chat_id = ?
+	`, chatID).Scan(&enabled, &liveBoard, &lang, &deliveryMode, &digestDraws, &digestMinutes, &roadAttach)
+	lang = i18n.Normalize(lang)
+
+	status := i18n.T(lang, "menu.disabled")
+	toggleText := i18n.T(lang, "menu.enable")
 	if enabled {
-		status = "✅ 已启用"
-		toggleText = "❌ 禁用提醒"
+		status = i18n.T(lang, "menu.enabled")
+		toggleText = i18n.T(lang, "menu.disable")
 	}
 
-	text := fmt.Sprintf(`🎲 长龙提醒配置
-当前状态: %s`, status)
+	mode := deliveryModeText(lang, deliveryMode, digestDraws, digestMinutes)
+	boardText := i18n.T(lang, "menu.to_board")
+	if liveBoard {
+		mode = i18n.T(lang, "menu.mode.board")
+		boardText = i18n.T(lang, "menu.to_alerts")
+	}
+
+	text := i18n.T(lang, "menu.title", status, mode)
+
+	roadText := i18n.T(lang, "menu.road.on")
+	if roadAttach {
+		roadText = i18n.T(lang, "menu.road.off")
+	}
 
 	keyboard := tgbotapi.NewInlineKeyboardMarkup(
 		tgbotapi.NewInlineKeyboardRow(
 			tgbotapi.NewInlineKeyboardButtonData(toggleText, "dragon_toggle"),
 		),
 		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("📊 配置大小长龙", "dragon_size"),
+			tgbotapi.NewInlineKeyboardButtonData(boardText, "dragon_board"),
+			tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "menu.digest"), "dragon_digest"),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "menu.schedule"), "dragon_sched"),
+			tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "menu.cleanup"), "dragon_cleanup"),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(roadText, "dragon_road"),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "menu.size"), "dragon_size"),
 		),
 		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("🎯 配置单双长龙", "dragon_parity"),
+			tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "menu.parity"), "dragon_parity"),
 		),
 		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("🔢 配置和值长龙", "dragon_sum"),
+			tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "menu.sum"), "dragon_sum"),
 		),
 		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("🔄 配置组合长龙", "dragon_combo"),
+			tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "menu.combo"), "dragon_combo"),
 		),
 		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("📋 查看配置状态", "dragon_status"),
+			tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "menu.status"), "dragon_status"),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "menu.simulate"), "dragon_simulate"),
+			tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "menu.preview"), "dragon_preview"),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("🌐 "+i18n.Name(lang), "dragon_lang"),
 		),
 	)
 
 	if messageID > 0 {
 		msg := tgbotapi.NewEditMessageText(chatID, messageID, text)
 		msg.ReplyMarkup = &keyboard
-		BotAPI.Send(msg)
+		send(chatID, msg)
 	} else {
 		msg := tgbotapi.NewMessage(chatID, text)
 		msg.ReplyMarkup = keyboard
-		BotAPI.Send(msg)
+		send(chatID, msg)
 	}
 }
 
 func toggleDragonAlert(chatID int64, messageID int) {
 	// 切换启用状态
-	_, err := db.WriteDB.Exec("UPDATE chat_configs SET enabled = NOT enabled WHERE chat_id = ?", chatID)
+	_, err := db.WriteDB.Exec("UPDATE chat_configs SET enabled = NOT enabled, disabled_reason = '' WHERE chat_id = ?", chatID)
 	if err != nil {
 		log.Printf("切换状态失败: %v", err)
 	}
@@ -119,12 +204,13 @@ func toggleDragonAlert(chatID int64, messageID int) {
 	showMainMenu(chatID, messageID)
 }
 
-func showAttributeMenu(chatID int64, messageID int, attrType, attrName string) {
+func showAttributeMenu(chatID int64, messageID int, attrType string) {
 	ensureDefaultRules(chatID)
+	lang := chatLanguage(chatID)
 
 	// 获取规则配置
 	rows, err := db.WriteDB.Query(`
-		SELECT pattern_type, threshold, enabled 
+		SELECT pattern_type, threshold, rarity_threshold, enabled 
 		FROM dragon_rules 
 		WHERE chat_id = ? AND attribute_type = ?
 		ORDER BY 
@@ -140,40 +226,24 @@ func showAttributeMenu(chatID int64, messageID int, attrType, attrName string) {
 	}
 	defer rows.Close()
 
-	rules := make(map[string]struct {
-		threshold int
-		enabled   bool
-	})
+	rules := make(map[string]menuRule)
 
 	for rows.Next() {
 		var pattern string
-		var threshold int
-		var enabled bool
-		rows.Scan(&pattern, &threshold, &enabled)
-		rules[pattern] = struct {
-			threshold int
-			enabled   bool
-		}{threshold, enabled}
+		var rule menuRule
+		rows.Scan(&pattern, &rule.threshold, &rule.rarity, &rule.enabled)
+		rules[pattern] = rule
 	}
 
-	text := fmt.Sprintf("🎲 %s长龙配置\n[+][-]调整触发值 | 点击名称切换启用", attrName)
+	text := i18n.T(lang, "rules.attr.title", attributeName(lang, attrType))
 
 	var buttons [][]tgbotapi.InlineKeyboardButton
 
-	patterns := []struct {
-		key  string
-		name string
-	}{
-		{"a", "a格式(连续)"},
-		{"ab", "ab格式(交替)"},
-		{"abb", "abb格式(A-B-B组)"},
-	}
-
-	for _, p := range patterns {
-		rule, exists := rules[p.key]
+	for _, p := range []string{"a", "ab", "abb"} {
+		rule, exists := rules[p]
 		if !exists {
 			rule.threshold = 5
-			if p.key == "ab" || p.key == "abb" {
+			if p == "ab" || p == "abb" {
 				rule.threshold = 2
 			}
 			rule.enabled = true
@@ -186,41 +256,37 @@ func showAttributeMenu(chatID int64, messageID int, attrType, attrName string) {
 
 		buttons = append(buttons, tgbotapi.NewInlineKeyboardRow(
 			tgbotapi.NewInlineKeyboardButtonData(
-				fmt.Sprintf("%s %s", statusIcon, p.name),
-				fmt.Sprintf("dragon_set_%s_%s_toggle", attrType, p.key),
+				fmt.Sprintf("%s %s", statusIcon, i18n.T(lang, "rules.pattern."+p)),
+				fmt.Sprintf("dragon_set_%s_%s_toggle", attrType, p),
 			),
+			tgbotapi.NewInlineKeyboardButtonData(rule.modeText(lang), fmt.Sprintf("dragon_set_%s_%s_mode", attrType, p)),
 		))
 
-		// a格式用"次"，其他格式用"组"
-		unit := "次"
-		if p.key != "a" {
-			unit = "组"
-		}
-
 		buttons = append(buttons, tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("➖", fmt.Sprintf("dragon_set_%s_%s_dec", attrType, p.key)),
-			tgbotapi.NewInlineKeyboardButtonData(fmt.Sprintf("触发: %d%s", rule.threshold, unit), "dragon_noop"),
-			tgbotapi.NewInlineKeyboardButtonData("➕", fmt.Sprintf("dragon_set_%s_%s_inc", attrType, p.key)),
+			tgbotapi.NewInlineKeyboardButtonData("➖", fmt.Sprintf("dragon_set_%s_%s_dec", attrType, p)),
+			tgbotapi.NewInlineKeyboardButtonData(rule.triggerText(lang, p), fmt.Sprintf("dragon_input_%s_%s", attrType, p)),
+			tgbotapi.NewInlineKeyboardButtonData("➕", fmt.Sprintf("dragon_set_%s_%s_inc", attrType, p)),
 		))
 	}
 
 	buttons = append(buttons, tgbotapi.NewInlineKeyboardRow(
-		tgbotapi.NewInlineKeyboardButtonData("◀️ 返回主菜单", "dragon_main"),
+		tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "menu.back_main"), "dragon_main"),
 	))
 
 	keyboard := tgbotapi.NewInlineKeyboardMarkup(buttons...)
 
 	msg := tgbotapi.NewEditMessageText(chatID, messageID, text)
 	msg.ReplyMarkup = &keyboard
-	BotAPI.Send(msg)
+	send(chatID, msg)
 }
 
 func showComboMenu(chatID int64, messageID int) {
 	ensureDefaultRules(chatID)
+	lang := chatLanguage(chatID)
 
 	// 获取组合规则配置
 	rows, err := db.WriteDB.Query(`
-		SELECT pattern_type, threshold, enabled 
+		SELECT pattern_type, threshold, rarity_threshold, enabled 
 		FROM dragon_rules 
 		WHERE chat_id = ? AND attribute_type = 'size_parity'
 	`, chatID)
@@ -230,37 +296,21 @@ func showComboMenu(chatID int64, messageID int) {
 	}
 	defer rows.Close()
 
-	rules := make(map[string]struct {
-		threshold int
-		enabled   bool
-	})
+	rules := make(map[string]menuRule)
 
 	for rows.Next() {
 		var pattern string
-		var threshold int
-		var enabled bool
-		rows.Scan(&pattern, &threshold, &enabled)
-		rules[pattern] = struct {
-			threshold int
-			enabled   bool
-		}{threshold, enabled}
+		var rule menuRule
+		rows.Scan(&pattern, &rule.threshold, &rule.rarity, &rule.enabled)
+		rules[pattern] = rule
 	}
 
-	text := "🔄 组合长龙配置\n大小+单双组合 | [+][-]调整触发值"
+	text := i18n.T(lang, "rules.combo.title")
 
 	var buttons [][]tgbotapi.InlineKeyboardButton
 
-	patterns := []struct {
-		key  string
-		name string
-	}{
-		{"ab_ac", "ab,ac格式(固定+交替)"},
-		{"ab_cd", "ab,cd格式(同时交替)"},
-		{"abab", "abab格式(组合重复)"},
-	}
-
-	for _, p := range patterns {
-		rule, exists := rules[p.key]
+	for _, p := range []string{"ab_ac", "ab_cd", "abab"} {
+		rule, exists := rules[p]
 		if !exists {
 			rule.threshold = 2
 			rule.enabled = true
@@ -273,33 +323,34 @@ func showComboMenu(chatID int64, messageID int) {
 
 		buttons = append(buttons, tgbotapi.NewInlineKeyboardRow(
 			tgbotapi.NewInlineKeyboardButtonData(
-				fmt.Sprintf("%s %s", statusIcon, p.name),
-				fmt.Sprintf("dragon_combo2_%s_toggle", p.key),
+				fmt.Sprintf("%s %s", statusIcon, i18n.T(lang, "rules.pattern."+p)),
+				fmt.Sprintf("dragon_combo2_%s_toggle", p),
 			),
+			tgbotapi.NewInlineKeyboardButtonData(rule.modeText(lang), fmt.Sprintf("dragon_combo2_%s_mode", p)),
 		))
 
 		buttons = append(buttons, tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("➖", fmt.Sprintf("dragon_combo2_%s_dec", p.key)),
-			tgbotapi.NewInlineKeyboardButtonData(fmt.Sprintf("触发: %d组", rule.threshold), "dragon_noop"),
-			tgbotapi.NewInlineKeyboardButtonData("➕", fmt.Sprintf("dragon_combo2_%s_inc", p.key)),
+			tgbotapi.NewInlineKeyboardButtonData("➖", fmt.Sprintf("dragon_combo2_%s_dec", p)),
+			tgbotapi.NewInlineKeyboardButtonData(rule.triggerText(lang, p), fmt.Sprintf("dragon_input_combo_%s", p)),
+			tgbotapi.NewInlineKeyboardButtonData("➕", fmt.Sprintf("dragon_combo2_%s_inc", p)),
 		))
 	}
 
 	buttons = append(buttons, tgbotapi.NewInlineKeyboardRow(
-		tgbotapi.NewInlineKeyboardButtonData("◀️ 返回主菜单", "dragon_main"),
+		tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "menu.back_main"), "dragon_main"),
 	))
 
 	keyboard := tgbotapi.NewInlineKeyboardMarkup(buttons...)
 
 	msg := tgbotapi.NewEditMessageText(chatID, messageID, text)
 	msg.ReplyMarkup = &keyboard
-	BotAPI.Send(msg)
+	send(chatID, msg)
 }
 
 func showStatusMenu(chatID int64, messageID int) {
 	// 获取所有规则
 	rows, err := db.WriteDB.Query(`
-		SELECT pattern_type, attribute_type, threshold, enabled 
+		SELECT pattern_type, attribute_type, threshold, rarity_threshold, enabled 
 		FROM dragon_rules 
 		WHERE chat_id = ?
 		ORDER BY attribute_type, pattern_type
@@ -309,16 +360,11 @@ func showStatusMenu(chatID int64, messageID int) {
 	}
 	defer rows.Close()
 
+	lang := chatLanguage(chatID)
+
 	var enabledCount int
 	var text strings.Builder
-	text.WriteString("📋 配置状态\n")
-
-	attrNames := map[string]string{
-		"size":        "📊大小",
-		"parity":      "🎯单双",
-		"sum":         "🔢和值",
-		"size_parity": "🔄组合",
-	}
+	text.WriteString(i18n.T(lang, "status.title") + "\n")
 
 	patternNames := map[string]string{
 		"a":     "a",
@@ -332,15 +378,15 @@ func showStatusMenu(chatID int64, messageID int) {
 	currentAttr := ""
 	for rows.Next() {
 		var pattern, attr string
-		var threshold int
+		var threshold, rarity int
 		var enabled bool
-		rows.Scan(&pattern, &attr, &threshold, &enabled)
+		rows.Scan(&pattern, &attr, &threshold, &rarity, &enabled)
 
 		if attr != currentAttr {
 			if currentAttr != "" {
 				text.WriteString("\n")
 			}
-			text.WriteString(fmt.Sprintf("%s: ", attrNames[attr]))
+			text.WriteString(fmt.Sprintf("%s: ", attributeTitle(lang, attr)))
 			currentAttr = attr
 		}
 
@@ -351,88 +397,110 @@ func showStatusMenu(chatID int64, messageID int) {
 			enabledCount++
 		}
 
-		// a格式显示次数，其他格式显示组数
-		unit := "次"
-		if pattern != "a" {
-			unit = "组"
+		if rarity > 0 {
+			text.WriteString(fmt.Sprintf("%s%s:💎%d ", status, patternNames[pattern], rarity))
+		} else {
+			text.WriteString(fmt.Sprintf("%s%s:%s ", status, patternNames[pattern], thresholdText(lang, pattern, threshold)))
 		}
-
-		text.WriteString(fmt.Sprintf("%s%s:%d%s ", status, patternNames[pattern], threshold, unit))
 	}
 
-	text.WriteString(fmt.Sprintf("\n\n已启用 %d 条规则", enabledCount))
+	text.WriteString("\n\n" + i18n.Plural(lang, "status.enabled_rules", enabledCount, enabledCount))
 
 	keyboard := tgbotapi.NewInlineKeyboardMarkup(
 		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("🔄 刷新", "dragon_refresh"),
-			tgbotapi.NewInlineKeyboardButtonData("◀️ 返回", "dragon_main"),
+			tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "status.refresh"), "dragon_refresh"),
+			tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "status.preview"), "dragon_preview"),
+			tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "menu.back"), "dragon_main"),
 		),
 	)
 
 	msg := tgbotapi.NewEditMessageText(chatID, messageID, text.String())
 	msg.ReplyMarkup = &keyboard
-	BotAPI.Send(msg)
+	send(chatID, msg)
 }
 
 func handleSetRule(chatID int64, messageID int, attrType, pattern, action string) {
 	// 统一步长为1（所有类型都按组调整）
-	switch action {
-	case "inc":
-		db.WriteDB.Exec(`
-			UPDATE dragon_rules 
-			SET threshold = LEAST(threshold + 1, 20) 
-			WHERE chat_id = ? AND pattern_type = ? AND attribute_type = ?
-		`, chatID, pattern, attrType)
+	updateRule(chatID, pattern, attrType, action)
 
-	case "dec":
-		db.WriteDB.Exec(`
-			UPDATE dragon_rules 
-			SET threshold = GREATEST(threshold - 1, 1) 
-			WHERE chat_id = ? AND pattern_type = ? AND attribute_type = ?
-		`, chatID, pattern, attrType)
+	// 快速响应：异步刷新
+	go showAttributeMenu(chatID, messageID, attrType)
+}
 
-	case "toggle":
-		db.WriteDB.Exec(`
-			UPDATE dragon_rules 
-			SET enabled = NOT enabled 
-			WHERE chat_id = ? AND pattern_type = ? AND attribute_type = ?
-		`, chatID, pattern, attrType)
-	}
+func handleComboRule(chatID int64, messageID int, pattern, action string) {
+	updateRule(chatID, pattern, "size_parity", action)
 
 	// 快速响应：异步刷新
-	attrNames := map[string]string{
-		"size":   "大小",
-		"parity": "单双",
-		"sum":    "和值",
+	go showComboMenu(chatID, messageID)
+}
+
+// menuRule 菜单中展示的规则配置
+type menuRule struct {
+	threshold int
+	rarity    int
+	enabled   bool
+}
+
+// modeText 触发方式切换按钮文字
+func (r menuRule) modeText(lang string) string {
+	if r.rarity > 0 {
+		return i18n.T(lang, "rules.mode.rarity")
 	}
+	return i18n.T(lang, "rules.mode.length")
+}
 
-	go showAttributeMenu(chatID, messageID, attrType, attrNames[attrType])
+// triggerText 触发值按钮文字
+func (r menuRule) triggerText(lang, pattern string) string {
+	if r.rarity > 0 {
+		return i18n.T(lang, "rules.trigger.rarity", r.rarity)
+	}
+	return i18n.T(lang, "rules.trigger.length", thresholdText(lang, pattern, r.threshold))
 }
 
-func handleComboRule(chatID int64, messageID int, pattern, action string) {
+// thresholdText 带单位的长度阈值：a格式用"次"，其他格式用"组"
+func thresholdText(lang, pattern string, threshold int) string {
+	if pattern == "a" {
+		return i18n.Count(lang, i18n.UnitTime, threshold)
+	}
+	return i18n.Count(lang, i18n.UnitGroup, threshold)
+}
+
+// updateRule 执行规则调整动作
+// 按长度触发时步长为1（范围见 dragon.ThresholdRange），按稀有度触发时步长为5（5-100）
+// 只在调整的方向上限制范围：已超出范围的值（如 /set 设置的）按 [+] 不会被拉低，按 [-] 不会被抬高
+func updateRule(chatID int64, pattern, attrType, action string) {
+	min, max := dragon.ThresholdRange(pattern)
+
 	switch action {
 	case "inc":
 		db.WriteDB.Exec(`
 			UPDATE dragon_rules 
-			SET threshold = LEAST(threshold + 1, 20) 
-			WHERE chat_id = ? AND pattern_type = ? AND attribute_type = 'size_parity'
-		`, chatID, pattern)
+			SET threshold = IF(rarity_threshold > 0, threshold, GREATEST(threshold, LEAST(threshold + 1, ?))),
+			    rarity_threshold = IF(rarity_threshold > 0, GREATEST(rarity_threshold, LEAST(rarity_threshold + 5, ?)), 0)
+			WHERE chat_id = ? AND pattern_type = ? AND attribute_type = ?
+		`, max, dragon.RarityMax, chatID, pattern, attrType)
 
 	case "dec":
 		db.WriteDB.Exec(`
 			UPDATE dragon_rules 
-			SET threshold = GREATEST(threshold - 1, 1) 
-			WHERE chat_id = ? AND pattern_type = ? AND attribute_type = 'size_parity'
-		`, chatID, pattern)
+			SET threshold = IF(rarity_threshold > 0, threshold, LEAST(threshold, GREATEST(threshold - 1, ?))),
+			    rarity_threshold = IF(rarity_threshold > 0, LEAST(rarity_threshold, GREATEST(rarity_threshold - 5, ?)), 0)
+			WHERE chat_id = ? AND pattern_type = ? AND attribute_type = ?
+		`, min, dragon.RarityMin, chatID, pattern, attrType)
 
 	case "toggle":
 		db.WriteDB.Exec(`
 			UPDATE dragon_rules 
 			SET enabled = NOT enabled 
-			WHERE chat_id = ? AND pattern_type = ? AND attribute_type = 'size_parity'
-		`, chatID, pattern)
-	}
+			WHERE chat_id = ? AND pattern_type = ? AND attribute_type = ?
+		`, chatID, pattern, attrType)
 
-	// 快速响应：异步刷新
-	go showComboMenu(chatID, messageID)
+	case "mode":
+		// 在长度触发和稀有度触发之间切换（稀有度默认60）
+		db.WriteDB.Exec(`
+			UPDATE dragon_rules 
+			SET rarity_threshold = IF(rarity_threshold > 0, 0, 60)
+			WHERE chat_id = ? AND pattern_type = ? AND attribute_type = ?
+		`, chatID, pattern, attrType)
+	}
 }
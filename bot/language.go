@@ -0,0 +1,26 @@
+package bot
+
+import (
+	"dragon-alert-bot/db"
+	"dragon-alert-bot/i18n"
+	"log"
+)
+
+// chatLanguage 读取群组语言，未设置时使用默认语言
+func chatLanguage(chatID int64) string {
+	var lang string
+	db.WriteDB.QueryRow("SELECT language FROM chat_configs WHERE chat_id = ?", chatID).Scan(&lang)
+	return i18n.Normalize(lang)
+}
+
+// cycleLanguage 切换到下一个语言并刷新主菜单
+func cycleLanguage(chatID int64, messageID int) {
+	lang := i18n.Next(chatLanguage(chatID))
+
+	_, err := db.WriteDB.Exec("UPDATE chat_configs SET language = ? WHERE chat_id = ?", lang, chatID)
+	if err != nil {
+		log.Printf("切换语言失败: %v", err)
+	}
+
+	showMainMenu(chatID, messageID)
+}
@@ -0,0 +1,92 @@
+package bot
+
+import (
+	"dragon-alert-bot/db"
+	"log"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// UpdateLiveBoard 编辑群组的实时榜单消息；还没有榜单或编辑失败（消息被删除等）时发送新消息并置顶
+// onResult 可为空，在榜单编辑或重新发送完成后调用
+func UpdateLiveBoard(chatID int64, messageID int, text string, onResult func(tgbotapi.Message, error)) {
+	if messageID == 0 {
+		postLiveBoard(chatID, text, onResult)
+		return
+	}
+
+	edit := tgbotapi.NewEditMessageText(chatID, messageID, text)
+	edit.ParseMode = "HTML"
+	edit.DisableWebPagePreview = true
+
+	Enqueue(chatID, edit, PriorityAlert, func(sent tgbotapi.Message, err error) {
+		if err == nil || strings.Contains(err.Error(), "message is not modified") {
+			if onResult != nil {
+				sent.MessageID = messageID
+				onResult(sent, nil)
+			}
+			return
+		}
+
+		log.Printf("[实时榜单] 群组:%d 编辑消息%d失败，重新发送: %v", chatID, messageID, err)
+		postLiveBoard(chatID, text, onResult)
+	})
+}
+
+// postLiveBoard 发送新的榜单消息，记录消息ID并置顶
+func postLiveBoard(chatID int64, text string, onResult func(tgbotapi.Message, error)) {
+	msg := tgbotapi.NewMessage(chatID, text)
+	msg.ParseMode = "HTML"
+	msg.DisableWebPagePreview = true
+	msg.DisableNotification = true
+
+	Enqueue(chatID, msg, PriorityAlert, func(sent tgbotapi.Message, err error) {
+		if onResult != nil {
+			onResult(sent, err)
+		}
+		if err != nil {
+			return
+		}
+
+		db.WriteDB.Exec("UPDATE chat_configs SET live_board_message_id = ? WHERE chat_id = ?", sent.MessageID, chatID)
+
+		pin := tgbotapi.PinChatMessageConfig{
+			ChatID:              chatID,
+			MessageID:           sent.MessageID,
+			DisableNotification: true,
+		}
+		Enqueue(chatID, pin, PriorityNormal, func(_ tgbotapi.Message, err error) {
+			if err != nil {
+				log.Printf("[实时榜单] 群组:%d 置顶失败（机器人需要置顶消息权限）: %v", chatID, err)
+			}
+		})
+	})
+}
+
+// toggleLiveBoard 开启或关闭实时榜单模式
+// 开启时立即发送一次榜单，关闭时取消置顶并清除记录的消息ID
+func toggleLiveBoard(chatID int64, messageID int) {
+	var enabled bool
+	var boardID int
+	db.WriteDB.QueryRow("SELECT live_board, live_board_message_id FROM chat_configs WHERE chat_id = ?", chatID).Scan(&enabled, &boardID)
+
+	_, err := db.WriteDB.Exec("UPDATE chat_configs SET live_board = ?, live_board_message_id = 0 WHERE chat_id = ?", !enabled, chatID)
+	if err != nil {
+		log.Printf("切换实时榜单失败: %v", err)
+		showMainMenu(chatID, messageID)
+		return
+	}
+
+	if enabled {
+		if boardID > 0 {
+			send(chatID, tgbotapi.UnpinChatMessageConfig{ChatID: chatID, MessageID: boardID})
+		}
+	} else {
+		rules, _ := modules.Analyzer.GetChatRules(chatID)
+		_, runs := currentRuns()
+		postLiveBoard(chatID, FormatLiveBoard(chatLanguage(chatID), runs, rules, nil), nil)
+	}
+
+	showMainMenu(chatID, messageID)
+}
@@ -0,0 +1,140 @@
+package bot
+
+import (
+	"dragon-alert-bot/db"
+	"dragon-alert-bot/i18n"
+	"fmt"
+	"log"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// handleMyChatMember 处理机器人自身成员状态的变化
+func handleMyChatMember(update *tgbotapi.ChatMemberUpdated) {
+	chat := update.Chat
+	if chat.Type != "group" && chat.Type != "supergroup" {
+		return
+	}
+
+	wasMember := isPresent(update.OldChatMember)
+	isMember := isPresent(update.NewChatMember)
+
+	switch {
+	case !wasMember && isMember:
+		handleBotAdded(chat.ID, update.From.LanguageCode)
+
+	case wasMember && !isMember:
+		archiveChat(chat.ID, update.NewChatMember.Status)
+
+	case update.NewChatMember.Status == "restricted" && !update.NewChatMember.CanSendMessages:
+		log.Printf("[成员状态] 群组:%d 机器人被禁言", chat.ID)
+
+	case update.OldChatMember.Status != "administrator" && update.NewChatMember.Status == "administrator":
+		// 升为管理员后通常恢复了发送权限，清除权限失败计数
+		failingChats.Delete(chat.ID)
+		db.WriteDB.Exec("UPDATE chat_configs SET permission_failures = 0 WHERE chat_id = ?", chat.ID)
+	}
+}
+
+// isPresent 判断成员状态是否在群组中
+func isPresent(member tgbotapi.ChatMember) bool {
+	switch member.Status {
+	case "creator", "administrator", "member":
+		return true
+	case "restricted":
+		return member.IsMember
+	}
+	return false
+}
+
+// handleMigration 处理群组升级为超级群组的服务消息（旧群组和新群组各会收到一条）
+func handleMigration(message *tgbotapi.Message) {
+	oldID, newID := message.Chat.ID, message.MigrateToChatID
+	if message.MigrateFromChatID != 0 {
+		oldID, newID = message.MigrateFromChatID, message.Chat.ID
+	}
+
+	// 重复执行时旧群组已没有数据，不会有影响
+	if err := migrateChat(oldID, newID); err != nil {
+		log.Printf("[群组迁移] %d → %d 失败: %v", oldID, newID, err)
+		return
+	}
+	outbox.retargetChat(oldID, newID)
+}
+
+// handleBotAdded 机器人加入群组：新群组创建默认配置并发送欢迎语，
+// 归档或被自动停用的群组恢复提醒并告知管理员；languageCode 为添加机器人的用户的 language_code
+func handleBotAdded(chatID int64, languageCode string) {
+	var reason, lastError string
+	var failures int
+	var archived bool
+	err := db.WriteDB.QueryRow(`
+		SELECT disabled_reason, permission_failures, last_send_error, archived_at IS NOT NULL
+		FROM chat_configs WHERE chat_id = ?
+	`, chatID).Scan(&reason, &failures, &lastError, &archived)
+	if err != nil {
+		ensureChatConfig(chatID, languageCode)
+		send(chatID, tgbotapi.NewMessage(chatID, i18n.T(chatLanguage(chatID), "welcome.group")))
+		log.Printf("[加入群组] 群组:%d 已创建默认配置", chatID)
+		return
+	}
+
+	// 只恢复被自动停用的群组，管理员手动关闭的保持关闭
+	db.WriteDB.Exec(`
+		UPDATE chat_configs
+		SET enabled = IF(disabled_reason = '', enabled, TRUE), disabled_reason = '', permission_failures = 0, archived_at = NULL
+		WHERE chat_id = ?
+	`, chatID)
+	failingChats.Delete(chatID)
+	ensureDefaultRules(chatID)
+
+	if !archived && reason == "" && failures == 0 {
+		return
+	}
+
+	lang := chatLanguage(chatID)
+	var text string
+	switch reason {
+	case disabledRemoved, "":
+		text = i18n.T(lang, "rejoin.welcome")
+		if reason == disabledRemoved {
+			text += i18n.T(lang, "rejoin.resumed")
+		}
+		text += "\n\n" + i18n.T(lang, "rejoin.hint")
+	default:
+		text = i18n.T(lang, "rejoin.restored") + "\n\n"
+		switch reason {
+		case disabledKicked:
+			text += i18n.T(lang, "rejoin.kicked")
+		case disabledNotFound:
+			text += i18n.T(lang, "rejoin.not_found")
+		case disabledPermission:
+			text += i18n.T(lang, "rejoin.permission", permissionFailureLimit)
+		}
+		if lastError != "" {
+			text += "\n" + i18n.T(lang, "rejoin.last_error", lastError)
+		}
+		text += "\n\n" + i18n.T(lang, "rejoin.check")
+	}
+
+	send(chatID, tgbotapi.NewMessage(chatID, text))
+	log.Printf("[加入群组] 群组:%d 已恢复 (原因:%s)", chatID, reason)
+}
+
+// archiveChat 机器人被移出群组后归档：停止提醒，保留配置以便重新加入时恢复
+func archiveChat(chatID int64, status string) {
+	// SET 按顺序执行，disabled_reason 取决于归档前是否启用
+	_, err := db.WriteDB.Exec(`
+		UPDATE chat_configs
+		SET disabled_reason = IF(enabled, ?, disabled_reason), enabled = FALSE, archived_at = NOW()
+		WHERE chat_id = ?
+	`, disabledRemoved, chatID)
+	if err != nil {
+		log.Printf("[移出群组] 群组:%d 归档失败: %v", chatID, err)
+		return
+	}
+
+	failingChats.Delete(chatID)
+	outbox.dropChat(chatID, fmt.Errorf("bot %s from chat %d", status, chatID))
+	log.Printf("[移出群组] 群组:%d 已归档 (%s)", chatID, status)
+}
@@ -1,144 +1,295 @@
 package bot
 
 import (
+	"dragon-alert-bot/db"
 	"dragon-alert-bot/dragon"
+	"dragon-alert-bot/i18n"
 	"fmt"
 	"strings"
+	"time"
 )
 
-// FormatAlertMessage 格式化提醒消息
-func FormatAlertMessage(results []*dragon.PatternResult, currentData *dragon.CurrentLotteryInfo) string {
-	if len(results) == 0 {
+// FormatAlertMessage 使用内置的 detailed 模板格式化提醒消息
+func FormatAlertMessage(lang string, results []*dragon.PatternResult, currentData *dragon.CurrentLotteryInfo) string {
+	text, err := RenderAlert(TemplateDetailed, "", lang, results, currentData)
+	if err != nil {
 		return ""
 	}
+	return text
+}
 
-	var text strings.Builder
-	text.WriteString("🔥 <b>长龙提醒</b>\n")
+// patternName 提醒中的格式显示名称（如"连续"）
+func patternName(lang, pattern string) string {
+	return i18n.T(lang, "pattern."+pattern)
+}
 
-	if currentData != nil {
-		text.WriteString(fmt.Sprintf("<code>%s</code>期 开奖号码: <b>%s=%d</b> %s%s\n",
-			currentData.Qihao,
-			currentData.OpenNum,
-			currentData.SumValue,
-			currentData.Size,
-			currentData.Parity,
-		))
-	} else {
-		text.WriteString(fmt.Sprintf("当前期号: <code>%s</code>期\n", results[0].CurrentQihao))
-	}
-
-	// 按属性类型分组
-	sizeResults := []*dragon.PatternResult{}
-	parityResults := []*dragon.PatternResult{}
-	sumResults := []*dragon.PatternResult{}
-	comboResults := []*dragon.PatternResult{}
-
-	for _, r := range results {
-		switch r.AttributeType {
-		case "size":
-			sizeResults = append(sizeResults, r)
-		case "parity":
-			parityResults = append(parityResults, r)
-		case "sum":
-			sumResults = append(sumResults, r)
-		case "size_parity":
-			comboResults = append(comboResults, r)
+// recordPatternName 纪录、榜单中的格式显示名称（如"a连续"）
+func recordPatternName(lang, pattern string) string {
+	return i18n.T(lang, "pattern."+pattern+".full")
+}
+
+// attributeName 属性显示名称（如"大小"）
+func attributeName(lang, attr string) string {
+	return i18n.T(lang, "attr."+attr)
+}
+
+// attributeTitle 带图标的属性显示名称（如"📊大小"）
+func attributeTitle(lang, attr string) string {
+	return i18n.T(lang, "attr."+attr+".title")
+}
+
+// displayLength 提醒中显示的长度和单位：abb 格式按组计算（3个元素=1组），其余按期数
+func displayLength(lang string, r *dragon.PatternResult) (int, string) {
+	if r.PatternType == "abb" {
+		return r.Count / 3, i18n.Unit(lang, i18n.UnitGroup, r.Count/3)
+	}
+	return r.Count, i18n.Unit(lang, i18n.UnitDraw, r.Count)
+}
+
+// formatLength 期数，按组计算的格式附带组数（如"6期 (3组)"）
+func formatLength(lang string, count int, pattern string) string {
+	length := i18n.Count(lang, i18n.UnitDraw, count)
+	if groups := dragon.GroupCount(count, pattern); groups != count {
+		length += fmt.Sprintf(" (%s)", i18n.Count(lang, i18n.UnitGroup, groups))
+	}
+	return length
+}
+
+// formatDraw 格式化当前开奖行
+func formatDraw(lang string, currentData *dragon.CurrentLotteryInfo) string {
+	return i18n.T(lang, "alert.draw",
+		currentData.Qihao,
+		currentData.OpenNum,
+		currentData.SumValue,
+		i18n.Value(lang, currentData.Size),
+		i18n.Value(lang, currentData.Parity),
+	)
+}
+
+// formatPatternDetail 格式化模式详情，让它更直观（abb 格式用括号分组显示）
+func formatPatternDetail(lang string, r *dragon.PatternResult) string {
+	if r.PatternType != "abb" {
+		return i18n.Values(lang, r.PatternDetail)
+	}
+
+	parts := strings.Split(r.PatternDetail, " ")
+	var groups []string
+	for i := 0; i < len(parts); i += 3 {
+		if i+2 < len(parts) {
+			groups = append(groups, fmt.Sprintf("(%s %s %s)", parts[i], parts[i+1], parts[i+2]))
+		} else if i < len(parts) {
+			// 不完整的部分
+			remaining := parts[i:]
+			groups = append(groups, strings.Join(remaining, " "))
 		}
 	}
+	return i18n.Values(lang, strings.Join(groups, " "))
+}
+
+func formatSingleResult(lang string, r *dragon.PatternResult) string {
+	displayCount, countUnit := displayLength(lang, r)
+
+	return fmt.Sprintf("  • %s\n    <code>%s</code>\n    %s\n    %s\n\n",
+		i18n.T(lang, "alert.item", patternName(lang, r.PatternType), displayCount, countUnit),
+		formatPatternDetail(lang, r),
+		i18n.T(lang, "alert.start", r.StartQihao),
+		formatRarity(lang, r),
+	)
+}
+
+// formatRarity 格式化稀有度信息
+func formatRarity(lang string, r *dragon.PatternResult) string {
+	text := i18n.T(lang, "alert.rarity", r.Rarity, rarityStars(r.Rarity), formatOdds(r.Probability))
+	if r.HistoryRuns > 0 {
+		text += i18n.T(lang, "alert.percentile", r.HistoryPercentile)
+	}
+	return text
+}
+
+// rarityStars 稀有度星级（每20分一颗星）
+func rarityStars(rarity int) string {
+	stars := strings.Repeat("★", (rarity+19)/20)
+	if stars == "" {
+		stars = "☆"
+	}
+	return stars
+}
+
+// formatOdds 将概率格式化为"1/N"形式
+func formatOdds(p float64) string {
+	if p <= 0 || p >= 1 {
+		return "1/1"
+	}
+
+	odds := 1 / p
+	if odds < 10 {
+		return fmt.Sprintf("1/%.1f", odds)
+	}
+	return fmt.Sprintf("1/%.0f", odds)
+}
+
+// FormatRecordMessage 格式化平/破纪录提醒
+func FormatRecordMessage(lang string, event *dragon.RecordEvent, currentData *dragon.CurrentLotteryInfo) string {
+	r := event.Result
+
+	kind := "record.broken"
+	if event.Tie {
+		kind = "record.tied"
+	}
+
+	var text strings.Builder
+	text.WriteString(i18n.T(lang, kind+".title") + "\n")
+	if currentData != nil {
+		text.WriteString(formatDraw(lang, currentData) + "\n")
+	}
 
-	// 排序函数：按Count降序排序
-	sortResults := func(results []*dragon.PatternResult) {
-		for i := 0; i < len(results)-1; i++ {
-			for j := i + 1; j < len(results); j++ {
-				if results[i].Count < results[j].Count {
-					results[i], results[j] = results[j], results[i]
+	text.WriteString("<blockquote>" + i18n.T(lang, kind,
+		attributeTitle(lang, r.AttributeType),
+		recordPatternName(lang, r.PatternType),
+		i18n.Count(lang, i18n.UnitDraw, r.Count),
+		i18n.T(lang, "record.window."+event.Window),
+		i18n.Count(lang, i18n.UnitDraw, event.Previous),
+	) + "</blockquote>\n")
+	text.WriteString(formatSingleResult(lang, r))
+
+	return strings.TrimRight(text.String(), "\n")
+}
+
+// FormatLeaderboard 格式化纪录榜
+func FormatLeaderboard(lang string, entries []dragon.RecordEntry) string {
+	var text strings.Builder
+	text.WriteString(i18n.T(lang, "leaderboard.title") + "\n")
+
+	if len(entries) == 0 {
+		text.WriteString("\n" + i18n.T(lang, "leaderboard.empty"))
+		return text.String()
+	}
+
+	for _, attr := range []string{"size", "parity", "sum", "size_parity"} {
+		var lines []string
+		for _, pattern := range []string{"a", "ab", "abb", "ab_ac", "ab_cd", "abab"} {
+			for _, e := range entries {
+				if e.AttributeType == attr && e.PatternType == pattern {
+					lines = append(lines, fmt.Sprintf("  • %s: <code>%d / %d / %d</code>",
+						recordPatternName(lang, pattern), e.Day, e.Week, e.All))
 				}
 			}
 		}
-	}
 
-	// 大小长龙
-	if len(sizeResults) > 0 {
-		sortResults(sizeResults)
-		text.WriteString("<blockquote>📊 <b>【大小长龙】</b></blockquote>\n")
-		for _, r := range sizeResults {
-			text.WriteString(formatSingleResult(r))
+		if len(lines) > 0 {
+			text.WriteString(fmt.Sprintf("\n<b>%s</b>\n%s\n", attributeTitle(lang, attr), strings.Join(lines, "\n")))
 		}
 	}
 
-	// 单双长龙
-	if len(parityResults) > 0 {
-		sortResults(parityResults)
-		text.WriteString("<blockquote>🎯 <b>【单双长龙】</b></blockquote>\n")
-		for _, r := range parityResults {
-			text.WriteString(formatSingleResult(r))
-		}
+	return strings.TrimRight(text.String(), "\n")
+}
+
+// FormatLiveBoard 格式化实时长龙榜：列出所有正在进行的模式（包括未达到阈值的），达到提醒条件的标记🔥
+func FormatLiveBoard(lang string, runs []*dragon.PatternResult, rules []db.DragonRule, currentData *dragon.CurrentLotteryInfo) string {
+	var text strings.Builder
+	text.WriteString(i18n.T(lang, "board.title") + "\n")
+
+	if currentData != nil {
+		text.WriteString(formatDraw(lang, currentData) + "\n")
+	}
+
+	hot := make(map[*dragon.PatternResult]bool)
+	for _, r := range dragon.FilterResultsByRules(runs, rules) {
+		hot[r] = true
 	}
 
-	// 和值长龙
-	if len(sumResults) > 0 {
-		sortResults(sumResults)
-		text.WriteString("<blockquote>🔢 <b>【和值长龙】</b></blockquote>\n")
-		for _, r := range sumResults {
-			text.WriteString(formatSingleResult(r))
+	for _, attr := range []string{"size", "parity", "sum", "size_parity"} {
+		var lines []string
+		for _, pattern := range []string{"a", "ab", "abb", "ab_ac", "ab_cd", "abab"} {
+			for _, r := range runs {
+				if r.AttributeType != attr || r.PatternType != pattern {
+					continue
+				}
+
+				mark := "•"
+				if hot[r] {
+					mark = "🔥"
+				}
+
+				lines = append(lines, fmt.Sprintf("  %s %s", mark, i18n.T(lang, "board.line",
+					recordPatternName(lang, pattern), formatLength(lang, r.Count, r.PatternType), r.StartQihao)))
+			}
 		}
+
+		if len(lines) == 0 {
+			lines = append(lines, "  "+i18n.T(lang, "board.none"))
+		}
+		text.WriteString(fmt.Sprintf("\n<b>%s</b>\n%s\n", attributeTitle(lang, attr), strings.Join(lines, "\n")))
 	}
 
-	// 组合长龙
-	if len(comboResults) > 0 {
-		sortResults(comboResults)
-		text.WriteString("<blockquote>🔄 <b>【组合长龙】</b></blockquote>\n")
-		for _, r := range comboResults {
-			text.WriteString(formatSingleResult(r))
+	text.WriteString("\n" + i18n.T(lang, "board.updated", time.Now().Format("15:04:05")))
+	return text.String()
+}
+
+// FormatQuietDigest 格式化静默时段汇总，active 为仍在进行的长龙（键为 格式/属性/起始期号）
+func FormatQuietDigest(lang string, entries []db.QuietDigest, active map[string]bool) string {
+	var text strings.Builder
+	text.WriteString(i18n.Plural(lang, "quiet.title", len(entries), len(entries)) + "\n")
+
+	for _, attr := range []string{"size", "parity", "sum", "size_parity"} {
+		var lines []string
+		for _, e := range entries {
+			if e.AttributeType != attr {
+				continue
+			}
+
+			status := i18n.T(lang, "quiet.ended")
+			if active[e.PatternType+"/"+e.AttributeType+"/"+e.StartQihao] {
+				status = i18n.T(lang, "quiet.active")
+			}
+
+			lines = append(lines, "  • "+i18n.T(lang, "quiet.line",
+				recordPatternName(lang, e.PatternType), formatLength(lang, e.Count, e.PatternType), e.StartQihao, e.CurrentQihao, status))
+		}
+
+		if len(lines) > 0 {
+			text.WriteString(fmt.Sprintf("\n<b>%s</b>\n%s\n", attributeTitle(lang, attr), strings.Join(lines, "\n")))
 		}
 	}
 
 	return strings.TrimRight(text.String(), "\n")
 }
 
-func formatSingleResult(r *dragon.PatternResult) string {
-	patternNames := map[string]string{
-		"a":     "连续",
-		"ab":    "交替",
-		"abb":   "abb",
-		"ab_ac": "固定交替",
-		"ab_cd": "双交替",
-		"abab":  "组合重复",
-	}
+// FormatDigest 格式化定时汇总：本周期达到提醒条件的长龙（进行中、已结束）和各属性最长的长龙
+// longest 为各属性本周期内最长的长龙（不受群组阈值限制），draws/minutes 为汇总间隔
+func FormatDigest(lang string, entries []db.DigestEntry, longest []db.Dragon, draws, minutes int) string {
+	var text strings.Builder
+	text.WriteString(i18n.T(lang, "digest.header", digestIntervalText(lang, draws, minutes)) + "\n")
 
-	// 计算显示的次数
-	displayCount := r.Count
-	countUnit := "期"
+	var active, ended []string
+	for _, e := range entries {
+		name := attributeTitle(lang, e.AttributeType) + " " + recordPatternName(lang, e.PatternType)
+		length := formatLength(lang, e.Count, e.PatternType)
+		if e.Status == "ended" {
+			ended = append(ended, "  • "+i18n.T(lang, "digest.ended.line", name, length, e.StartQihao, e.CurrentQihao))
+		} else {
+			active = append(active, "  • "+i18n.T(lang, "board.line", name, length, e.StartQihao))
+		}
+	}
 
-	// abb格式按组计算（3个元素=1组）
-	if r.PatternType == "abb" {
-		displayCount = r.Count / 3
-		countUnit = "组"
+	if len(active) > 0 {
+		text.WriteString("\n" + i18n.T(lang, "digest.active", len(active)) + "\n" + strings.Join(active, "\n") + "\n")
+	}
+	if len(ended) > 0 {
+		text.WriteString("\n" + i18n.T(lang, "digest.ended", len(ended)) + "\n" + strings.Join(ended, "\n") + "\n")
 	}
 
-	// 格式化模式详情，让它更直观
-	pattern := r.PatternDetail
-	if r.PatternType == "abb" {
-		// abb格式用括号分组显示
-		parts := strings.Split(r.PatternDetail, " ")
-		var groups []string
-		for i := 0; i < len(parts); i += 3 {
-			if i+2 < len(parts) {
-				groups = append(groups, fmt.Sprintf("(%s %s %s)", parts[i], parts[i+1], parts[i+2]))
-			} else if i < len(parts) {
-				// 不完整的部分
-				remaining := parts[i:]
-				groups = append(groups, strings.Join(remaining, " "))
+	if len(longest) > 0 {
+		text.WriteString("\n" + i18n.T(lang, "digest.longest") + "\n")
+		for _, attr := range []string{"size", "parity", "sum", "size_parity"} {
+			for _, d := range longest {
+				if d.AttributeType == attr {
+					text.WriteString(fmt.Sprintf("  • %s: %s <b>%s</b>\n",
+						attributeTitle(lang, attr), recordPatternName(lang, d.PatternType), formatLength(lang, d.Count, d.PatternType)))
+				}
 			}
 		}
-		pattern = strings.Join(groups, " ")
 	}
 
-	return fmt.Sprintf("  • %s格式 连续<b>%d%s</b>\n    <code>%s</code>\n    起始: %s期\n\n",
-		patternNames[r.PatternType],
-		displayCount,
-		countUnit,
-		pattern,
-		r.StartQihao,
-	)
+	return strings.TrimRight(text.String(), "\n")
 }
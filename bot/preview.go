@@ -0,0 +1,149 @@
+package bot
+
+import (
+	"dragon-alert-bot/db"
+	"dragon-alert-bot/dragon"
+	"dragon-alert-bot/i18n"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// sendPreview 按群组当前规则发送一条预览提醒
+// 优先使用当前真实的长龙，没有达到阈值的长龙时按规则阈值生成示例
+func sendPreview(chatID int64) {
+	rules, err := modules.Analyzer.GetChatRules(chatID)
+	if err != nil {
+		log.Printf("[预览] 查询规则失败: %v", err)
+		return
+	}
+
+	lang := chatLanguage(chatID)
+	if len(rules) == 0 {
+		send(chatID, tgbotapi.NewMessage(chatID, i18n.T(lang, "preview.no_rules")))
+		return
+	}
+
+	latest, err := modules.Monitor.GetHistoryData(1)
+	if err != nil || len(latest) == 0 {
+		log.Printf("[预览] 查询最新开奖失败: %v", err)
+		return
+	}
+
+	// 不调用 Analyze：预览不能改动开奖流程正在使用的分析器状态
+	attrHistory, history := runHistory()
+	runs := dragon.DetectAllRuns(attrHistory)
+	dragon.ScoreRarity(runs, history)
+
+	data := latest[0]
+	attrs := data.CalculateAttributes()
+	currentInfo := &dragon.CurrentLotteryInfo{
+		Qihao:    data.Qihao,
+		OpenNum:  data.OpenNum,
+		SumValue: data.SumValue,
+		Size:     attrs.Size,
+		Parity:   attrs.Parity,
+		OpenTime: data.OpenTime,
+	}
+
+	header := i18n.T(lang, "preview.real")
+	results := modules.Analyzer.FilterResultsByRules(runs, rules)
+	if len(results) == 0 {
+		header = i18n.T(lang, "preview.sample")
+		for _, rule := range rules {
+			results = append(results, ruleSample(rule, data.Qihao, history))
+		}
+	}
+
+	text := header + "\n" + i18n.T(lang, "preview.note") + "\n\n" + RenderChatAlert(previewConfig(chatID), results, currentInfo)
+
+	msg := tgbotapi.NewMessage(chatID, text)
+	msg.ParseMode = "HTML"
+	msg.DisableWebPagePreview = true
+	send(chatID, msg)
+}
+
+// previewConfig 预览使用群组当前选择的提醒模板和语言
+func previewConfig(chatID int64) *db.ChatConfig {
+	name, custom := chatTemplate(chatID)
+	return &db.ChatConfig{ChatID: chatID, AlertTemplate: name, CustomTemplate: custom, Language: chatLanguage(chatID)}
+}
+
+// sampleValues 示例长龙使用的属性值（a 为第一个值，b 为第二个值）
+var sampleValues = map[string][2]string{
+	"size":        {"大", "小"},
+	"parity":      {"单", "双"},
+	"sum":         {"13", "14"},
+	"size_parity": {"大单", "大双"},
+}
+
+// ruleSample 按规则生成一条示例长龙并计算稀有度
+// 按稀有度触发的规则逐步加长示例，直到稀有度达到规则的阈值
+func ruleSample(rule db.DragonRule, currentQihao string, history dragon.RunHistory) *dragon.PatternResult {
+	if rule.RarityThreshold <= 0 {
+		r := sampleResult(rule.PatternType, rule.AttributeType, rule.Threshold, currentQihao)
+		dragon.ScoreRarity([]*dragon.PatternResult{r}, history)
+		return r
+	}
+
+	_, max := dragon.ThresholdRange(rule.PatternType)
+	var r *dragon.PatternResult
+	for n := 1; n <= max; n++ {
+		r = sampleResult(rule.PatternType, rule.AttributeType, n, currentQihao)
+		dragon.ScoreRarity([]*dragon.PatternResult{r}, history)
+		if r.Rarity >= rule.RarityThreshold {
+			break
+		}
+	}
+	return r
+}
+
+// sampleResult 按规则阈值生成一条示例长龙
+func sampleResult(patternType, attrType string, threshold int, currentQihao string) *dragon.PatternResult {
+	values := sampleValues[attrType]
+	if threshold < 1 {
+		threshold = 1
+	}
+
+	var details []string
+	switch patternType {
+	case "a", "abab":
+		for i := 0; i < threshold; i++ {
+			details = append(details, values[0])
+		}
+		if patternType == "abab" {
+			details = append(details, details...)
+		}
+	case "ab", "ab_ac":
+		for i := 0; i < threshold; i++ {
+			details = append(details, values[0], values[1])
+		}
+	case "ab_cd":
+		for i := 0; i < threshold; i++ {
+			details = append(details, "大单", "小双")
+		}
+	case "abb":
+		for i := 0; i < threshold; i++ {
+			details = append(details, values[0], values[1], values[1])
+		}
+	}
+
+	// 期号为数字时倒推起始期号
+	startQihao := currentQihao
+	if n, err := strconv.ParseInt(currentQihao, 10, 64); err == nil {
+		startQihao = fmt.Sprintf("%d", n-int64(len(details))+1)
+	}
+
+	return &dragon.PatternResult{
+		PatternType:   patternType,
+		AttributeType: attrType,
+		Count:         len(details),
+		StartQihao:    startQihao,
+		CurrentQihao:  currentQihao,
+		PatternDetail: strings.Join(details, " "),
+		Matched:       true,
+	}
+}
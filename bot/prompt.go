@@ -0,0 +1,187 @@
+package bot
+
+import (
+	"dragon-alert-bot/db"
+	"dragon-alert-bot/dragon"
+	"dragon-alert-bot/i18n"
+	"fmt"
+	"html"
+	"log"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// thresholdPromptTTL 阈值输入提示的有效时间
+const thresholdPromptTTL = 2 * time.Minute
+
+// promptThreshold 点击规则菜单的触发值后，发送 ForceReply 提示管理员直接输入新的触发值
+// 同一管理员在同一群组只保留最新的提示
+func promptThreshold(chatID int64, menuMessageID int, user *tgbotapi.User, attrType, pattern string) {
+	if !hasPattern(attrType, pattern) {
+		return
+	}
+
+	sendThresholdPrompt(chatID, chatLanguage(chatID), user, attrType, pattern, menuMessageID, "")
+}
+
+// sendThresholdPrompt 发送（或输入错误时重新发送）阈值输入提示，errText 为上一次输入的错误
+// 提示发送成功后才保存提示记录，只接受对这条提示消息的回复
+func sendThresholdPrompt(chatID int64, lang string, user *tgbotapi.User, attrType, pattern string, menuMessageID int, errText string) {
+	rule := loadRules(chatID)[ruleTarget{attrType, pattern}]
+
+	valueRange := i18n.T(lang, "prompt.rarity", dragon.RarityMin, dragon.RarityMax)
+	if rule.rarity == 0 {
+		min, max := dragon.ThresholdRange(pattern)
+		valueRange = thresholdText(lang, pattern, min) + "-" + thresholdText(lang, pattern, max)
+	}
+
+	text := i18n.T(lang, "prompt.ask",
+		fmt.Sprintf(`<a href="tg://user?id=%d">%s</a>`, user.ID, html.EscapeString(user.FirstName)),
+		attributeTitle(lang, attrType), recordPatternName(lang, pattern), rule.triggerText(lang, pattern),
+		valueRange, int(thresholdPromptTTL.Minutes()))
+	if errText != "" {
+		text = html.EscapeString(errText) + "\n\n" + text
+	}
+
+	msg := tgbotapi.NewMessage(chatID, text)
+	msg.ParseMode = "HTML"
+	msg.ReplyMarkup = tgbotapi.ForceReply{
+		ForceReply:            true,
+		Selective:             true,
+		InputFieldPlaceholder: valueRange,
+	}
+
+	Enqueue(chatID, msg, PriorityNormal, func(sent tgbotapi.Message, err error) {
+		if err != nil {
+			return
+		}
+		saveThresholdPrompt(chatID, user.ID, pattern, attrType, menuMessageID, sent.MessageID)
+	})
+}
+
+// saveThresholdPrompt 保存提示记录，并删除该管理员之前的提示消息
+func saveThresholdPrompt(chatID, userID int64, pattern, attrType string, menuMessageID, promptMessageID int) {
+	var previous int
+	db.WriteDB.QueryRow("SELECT prompt_message_id FROM threshold_prompts WHERE chat_id = ? AND user_id = ?", chatID, userID).Scan(&previous)
+
+	_, err := db.WriteDB.Exec(`
+		INSERT INTO threshold_prompts (chat_id, user_id, pattern_type, attribute_type, menu_message_id, prompt_message_id, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?, NOW() + INTERVAL ? SECOND)
+		ON DUPLICATE KEY UPDATE
+			pattern_type = VALUES(pattern_type), attribute_type = VALUES(attribute_type),
+			menu_message_id = VALUES(menu_message_id), prompt_message_id = VALUES(prompt_message_id), expires_at = VALUES(expires_at)
+	`, chatID, userID, pattern, attrType, menuMessageID, promptMessageID, int(thresholdPromptTTL.Seconds()))
+	if err != nil {
+		log.Printf("[阈值输入] 群组:%d 保存提示失败: %v", chatID, err)
+		send(chatID, tgbotapi.NewDeleteMessage(chatID, promptMessageID))
+		return
+	}
+
+	if previous != 0 && previous != promptMessageID {
+		send(chatID, tgbotapi.NewDeleteMessage(chatID, previous))
+	}
+}
+
+// handleThresholdReply 处理管理员对阈值输入提示的回复：校验后更新规则并刷新菜单
+// 不是对提示的回复时直接忽略
+func handleThresholdReply(message *tgbotapi.Message) {
+	if message.From == nil || message.ReplyToMessage.From == nil || message.ReplyToMessage.From.ID != BotAPI.Self.ID {
+		return
+	}
+
+	chatID := message.Chat.ID
+	var p db.ThresholdPrompt
+	var expired bool
+	err := db.WriteDB.QueryRow(`
+		SELECT pattern_type, attribute_type, menu_message_id, prompt_message_id, expires_at < NOW()
+		FROM threshold_prompts
+		WHERE chat_id = ? AND user_id = ?
+	`, chatID, message.From.ID).Scan(&p.PatternType, &p.AttributeType, &p.MenuMessageID, &p.PromptMessageID, &expired)
+	if err != nil {
+		return
+	}
+	if p.PromptMessageID == 0 || p.PromptMessageID != message.ReplyToMessage.MessageID {
+		return
+	}
+
+	lang := chatLanguage(chatID)
+
+	if expired {
+		finishThresholdPrompt(chatID, message.From.ID, p.PromptMessageID)
+		send(chatID, tgbotapi.NewMessage(chatID, i18n.T(lang, "prompt.expired")))
+		return
+	}
+
+	// 按稀有度触发的规则直接输入数字即可
+	input := strings.TrimSpace(message.Text)
+	rule := loadRules(chatID)[ruleTarget{p.AttributeType, p.PatternType}]
+	if rule.rarity > 0 && !strings.HasPrefix(strings.ToLower(input), "r") {
+		input = "r" + input
+	}
+
+	value, errText := parseRuleValue(lang, p.PatternType, input)
+	if errText != "" {
+		sendThresholdPrompt(chatID, lang, message.From, p.AttributeType, p.PatternType, p.MenuMessageID, errText)
+		return
+	}
+
+	_, err = db.WriteDB.Exec(`
+		UPDATE dragon_rules
+		SET threshold = IF(? > 0, threshold, ?), rarity_threshold = ?
+		WHERE chat_id = ? AND pattern_type = ? AND attribute_type = ?
+	`, value.rarity, value.threshold, value.rarity, chatID, p.PatternType, p.AttributeType)
+	if err != nil {
+		log.Printf("[阈值输入] 群组:%d 更新规则失败: %v", chatID, err)
+		return
+	}
+
+	finishThresholdPrompt(chatID, message.From.ID, p.PromptMessageID)
+
+	if p.AttributeType == "size_parity" {
+		showComboMenu(chatID, p.MenuMessageID)
+	} else {
+		showAttributeMenu(chatID, p.MenuMessageID, p.AttributeType)
+	}
+}
+
+// finishThresholdPrompt 删除提示记录和提示消息
+func finishThresholdPrompt(chatID, userID int64, promptMessageID int) {
+	db.WriteDB.Exec("DELETE FROM threshold_prompts WHERE chat_id = ? AND user_id = ?", chatID, userID)
+	if promptMessageID != 0 {
+		send(chatID, tgbotapi.NewDeleteMessage(chatID, promptMessageID))
+	}
+}
+
+// DeleteExpiredPrompts 删除已过期的阈值输入提示和提示消息（由定时任务调用）
+func DeleteExpiredPrompts() {
+	rows, err := db.WriteDB.Query("SELECT chat_id, user_id, prompt_message_id FROM threshold_prompts WHERE expires_at < NOW()")
+	if err != nil {
+		log.Printf("[阈值输入] 查询过期提示失败: %v", err)
+		return
+	}
+
+	var expired []db.ThresholdPrompt
+	for rows.Next() {
+		var p db.ThresholdPrompt
+		if rows.Scan(&p.ChatID, &p.UserID, &p.PromptMessageID) == nil {
+			expired = append(expired, p)
+		}
+	}
+	rows.Close()
+
+	for _, p := range expired {
+		// 只删除仍是过期状态的记录，期间重新点击生成的新提示不受影响
+		res, err := db.WriteDB.Exec(`
+			DELETE FROM threshold_prompts
+			WHERE chat_id = ? AND user_id = ? AND prompt_message_id = ? AND expires_at < NOW()
+		`, p.ChatID, p.UserID, p.PromptMessageID)
+		if err != nil {
+			continue
+		}
+		if n, _ := res.RowsAffected(); n > 0 && p.PromptMessageID != 0 {
+			send(p.ChatID, tgbotapi.NewDeleteMessage(p.ChatID, p.PromptMessageID))
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package bot
+
+import (
+	"dragon-alert-bot/db"
+	"dragon-alert-bot/dragon"
+	"dragon-alert-bot/i18n"
+	"dragon-alert-bot/road"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// roadAliases /road 命令的属性参数（中英文）
+var roadAliases = map[string]string{
+	"size":   road.AttrSize,
+	"大小":     road.AttrSize,
+	"parity": road.AttrParity,
+	"单双":     road.AttrParity,
+	"單雙":     road.AttrParity,
+	"combo":  road.AttrCombo,
+	"组合":     road.AttrCombo,
+	"組合":     road.AttrCombo,
+}
+
+// handleRoad 发送路单图片：/road [size|parity|combo] [期数]
+func handleRoad(message *tgbotapi.Message) {
+	chatID := message.Chat.ID
+	lang := chatLanguage(chatID)
+	if chatID > 0 {
+		lang = i18n.FromLanguageCode(message.From.LanguageCode)
+	}
+
+	attr := road.AttrSize
+	draws := road.DefaultDraws
+	for _, arg := range strings.Fields(message.CommandArguments()) {
+		if a, ok := roadAliases[strings.ToLower(arg)]; ok {
+			attr = a
+			continue
+		}
+		n, err := strconv.Atoi(arg)
+		if err != nil || n < road.MinDraws || n > road.MaxDraws {
+			send(chatID, tgbotapi.NewMessage(chatID, i18n.T(lang, "road.usage", road.MinDraws, road.MaxDraws)))
+			return
+		}
+		draws = n
+	}
+
+	photo, remember, ok := RoadPhoto(chatID, lang, attr, draws)
+	if !ok {
+		send(chatID, tgbotapi.NewMessage(chatID, i18n.T(lang, "road.empty")))
+		return
+	}
+	Enqueue(chatID, photo, PriorityNormal, func(sent tgbotapi.Message, err error) {
+		if err == nil {
+			remember(sent)
+		}
+	})
+}
+
+// roadImage 最新一期的路单图片，发送成功后记录 Telegram 的 file_id
+type roadImage struct {
+	qihao  string
+	png    []byte
+	fileID string
+}
+
+var (
+	roadMu     sync.Mutex
+	roadImages = make(map[string]*roadImage) // 属性/期数 → 图片
+)
+
+// RoadPhoto 生成最近 draws 期的路单图片消息（上方珠盘路、下方大路）
+// 使用分析器最近一次分析的开奖历史，本实例还没有分析过时从数据库读取
+// 同一期同一属性的图片只绘制一次，发送成功后调用 remember，其他群组改用 file_id 发送
+func RoadPhoto(chatID int64, lang, attr string, draws int) (tgbotapi.PhotoConfig, func(tgbotapi.Message), bool) {
+	attrs := drawHistory()
+	values := road.Values(attrs, attr, draws)
+	if len(values) == 0 {
+		return tgbotapi.PhotoConfig{}, nil, false
+	}
+
+	qihao := attrs[len(attrs)-1].Qihao
+	key := attr + "/" + strconv.Itoa(draws)
+	file, err := roadFile(key, qihao, values)
+	if err != nil {
+		log.Printf("[路单] 绘制失败: %v", err)
+		return tgbotapi.PhotoConfig{}, nil, false
+	}
+
+	var legend []string
+	for _, v := range road.Legend[attr] {
+		legend = append(legend, roadMarks[v]+roadValueName(lang, attr, v))
+	}
+
+	photo := tgbotapi.NewPhoto(chatID, file)
+	photo.Caption = i18n.T(lang, "road.caption",
+		attributeName(lang, attr), i18n.Count(lang, i18n.UnitDraw, len(values)), strings.Join(legend, " "), qihao)
+
+	remember := func(sent tgbotapi.Message) {
+		if len(sent.Photo) == 0 {
+			return
+		}
+		roadMu.Lock()
+		defer roadMu.Unlock()
+		if img := roadImages[key]; img != nil && img.qihao == qihao && img.fileID == "" {
+			img.fileID = sent.Photo[len(sent.Photo)-1].FileID
+			img.png = nil
+		}
+	}
+	return photo, remember, true
+}
+
+// roadFile 返回路单图片：已发送过的使用 file_id，否则使用本期绘制的图片（没有时绘制）
+func roadFile(key, qihao string, values []string) (tgbotapi.RequestFileData, error) {
+	roadMu.Lock()
+	defer roadMu.Unlock()
+
+	img := roadImages[key]
+	if img == nil || img.qihao != qihao {
+		png, err := road.Render(values)
+		if err != nil {
+			return nil, err
+		}
+		img = &roadImage{qihao: qihao, png: png}
+		roadImages[key] = img
+	}
+
+	if img.fileID != "" {
+		return tgbotapi.FileID(img.fileID), nil
+	}
+	return tgbotapi.FileBytes{Name: "road.png", Bytes: img.png}, nil
+}
+
+// roadMarks 图例中与图片颜色对应的符号
+var roadMarks = map[string]string{
+	"大":  "🔴",
+	"小":  "🔵",
+	"单":  "🔴",
+	"双":  "🔵",
+	"大单": "🔴",
+	"大双": "🟠",
+	"小单": "🟢",
+	"小双": "🔵",
+}
+
+// roadValueName 路单值的显示名称，组合使用简写（如 BO）
+func roadValueName(lang, attr, value string) string {
+	if attr == road.AttrCombo {
+		return i18n.Values(lang, value)
+	}
+	return i18n.Value(lang, value)
+}
+
+// RoadAttribute 提醒附带的路单属性：取第一条有路单的长龙（和值没有路单）
+func RoadAttribute(results []*dragon.PatternResult) (string, bool) {
+	for _, r := range results {
+		switch r.AttributeType {
+		case road.AttrSize, road.AttrParity, road.AttrCombo:
+			return r.AttributeType, true
+		}
+	}
+	return "", false
+}
+
+// toggleRoadAttach 开启或关闭提醒附带路单图片
+func toggleRoadAttach(chatID int64, messageID int) {
+	_, err := db.WriteDB.Exec("UPDATE chat_configs SET road_attach = NOT road_attach WHERE chat_id = ?", chatID)
+	if err != nil {
+		log.Printf("切换路单图片失败: %v", err)
+	}
+
+	showMainMenu(chatID, messageID)
+}
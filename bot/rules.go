@@ -0,0 +1,395 @@
+package bot
+
+import (
+	"dragon-alert-bot/db"
+	"dragon-alert-bot/dragon"
+	"dragon-alert-bot/i18n"
+	"fmt"
+	"html"
+	"log"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// attributeAliases 命令中属性参数的别名（中英文）
+var attributeAliases = map[string]string{
+	"size":        "size",
+	"大小":          "size",
+	"parity":      "parity",
+	"单双":          "parity",
+	"單雙":          "parity",
+	"sum":         "sum",
+	"和值":          "sum",
+	"combo":       "size_parity",
+	"size_parity": "size_parity",
+	"组合":          "size_parity",
+	"組合":          "size_parity",
+}
+
+// patternAliases 命令中格式参数的别名（中英文）
+var patternAliases = map[string]string{
+	"a":           "a",
+	"streak":      "a",
+	"连续":          "a",
+	"連續":          "a",
+	"ab":          "ab",
+	"alternating": "ab",
+	"交替":          "ab",
+	"abb":         "abb",
+	"ab_ac":       "ab_ac",
+	"ab,ac":       "ab_ac",
+	"固定交替":        "ab_ac",
+	"ab_cd":       "ab_cd",
+	"ab,cd":       "ab_cd",
+	"双交替":         "ab_cd",
+	"雙交替":         "ab_cd",
+	"abab":        "abab",
+	"组合重复":        "abab",
+	"組合重複":        "abab",
+}
+
+// rulePatterns 各属性可设置的格式（与 dragon.DefaultRules 一致）
+var rulePatterns = map[string][]string{
+	"size":        {"a", "ab", "abb"},
+	"parity":      {"a", "ab", "abb"},
+	"sum":         {"a", "ab", "abb"},
+	"size_parity": {"ab_ac", "ab_cd", "abab"},
+}
+
+// ruleAttributes 规则列表的属性顺序
+var ruleAttributes = []string{"size", "parity", "sum", "size_parity"}
+
+// ruleAttributeKeys 规则块中使用的属性名称
+var ruleAttributeKeys = map[string]string{
+	"size":        "size",
+	"parity":      "parity",
+	"sum":         "sum",
+	"size_parity": "combo",
+}
+
+// ruleTarget 命令指定的一条规则
+type ruleTarget struct {
+	attribute string
+	pattern   string
+}
+
+// parseRuleTargets 解析属性和格式参数，"*" 表示全部
+// 返回的错误为已本地化的提示文字
+func parseRuleTargets(lang, attrArg, patternArg string) ([]ruleTarget, string) {
+	var attrs []string
+	if attrArg == "*" {
+		attrs = ruleAttributes
+	} else if attr, ok := attributeAliases[strings.ToLower(attrArg)]; ok {
+		attrs = []string{attr}
+	} else {
+		return nil, i18n.T(lang, "rules.cmd.bad_attr", attrArg)
+	}
+
+	var targets []ruleTarget
+	for _, attr := range attrs {
+		if patternArg == "*" {
+			for _, pattern := range rulePatterns[attr] {
+				targets = append(targets, ruleTarget{attr, pattern})
+			}
+			continue
+		}
+
+		pattern, ok := patternAliases[strings.ToLower(patternArg)]
+		if !ok {
+			return nil, i18n.T(lang, "rules.cmd.bad_pattern", patternArg)
+		}
+		if !hasPattern(attr, pattern) {
+			// 指定了全部属性时跳过没有该格式的属性
+			if attrArg == "*" {
+				continue
+			}
+			return nil, i18n.T(lang, "rules.cmd.bad_combo", attributeName(lang, attr), recordPatternName(lang, pattern))
+		}
+		targets = append(targets, ruleTarget{attr, pattern})
+	}
+
+	if len(targets) == 0 {
+		return nil, i18n.T(lang, "rules.cmd.bad_pattern", patternArg)
+	}
+	return targets, ""
+}
+
+func hasPattern(attr, pattern string) bool {
+	for _, p := range rulePatterns[attr] {
+		if p == pattern {
+			return true
+		}
+	}
+	return false
+}
+
+// ruleValue 规则的触发值：rarity 大于0时按稀有度触发
+type ruleValue struct {
+	threshold int
+	rarity    int
+}
+
+// parseRuleValue 解析触发值：数字为长度阈值（a 格式为期数，其他为组数），r 开头为稀有度（如 r60）
+func parseRuleValue(lang, pattern, arg string) (ruleValue, string) {
+	arg = strings.ToLower(arg)
+	if strings.HasPrefix(arg, "r") {
+		n, err := strconv.Atoi(arg[1:])
+		if err != nil || n < dragon.RarityMin || n > dragon.RarityMax {
+			return ruleValue{}, i18n.T(lang, "rules.cmd.bad_rarity", dragon.RarityMin, dragon.RarityMax)
+		}
+		return ruleValue{rarity: n}, ""
+	}
+
+	min, max := dragon.ThresholdRange(pattern)
+	n, err := strconv.Atoi(arg)
+	if err != nil || n < min || n > max {
+		return ruleValue{}, i18n.T(lang, "rules.cmd.bad_value",
+			recordPatternName(lang, pattern), thresholdText(lang, pattern, min), thresholdText(lang, pattern, max))
+	}
+	return ruleValue{threshold: n}, ""
+}
+
+// handleSetCommand 设置规则的触发值：/set <属性|*> <格式|*> <数值|r稀有度>
+func handleSetCommand(message *tgbotapi.Message) {
+	if !checkGroupAdmin(message) {
+		return
+	}
+
+	chatID := message.Chat.ID
+	lang := chatLanguage(chatID)
+
+	args := strings.Fields(message.CommandArguments())
+	if len(args) != 3 {
+		send(chatID, tgbotapi.NewMessage(chatID, i18n.T(lang, "rules.cmd.usage.set")))
+		return
+	}
+
+	targets, errText := parseRuleTargets(lang, args[0], args[1])
+	if errText != "" {
+		send(chatID, tgbotapi.NewMessage(chatID, errText))
+		return
+	}
+
+	// 所有目标都先校验，任意一个不合法时不做修改
+	values := make([]ruleValue, len(targets))
+	for i, t := range targets {
+		values[i], errText = parseRuleValue(lang, t.pattern, args[2])
+		if errText != "" {
+			send(chatID, tgbotapi.NewMessage(chatID, errText))
+			return
+		}
+	}
+
+	ensureDefaultRules(chatID)
+	for i, t := range targets {
+		_, err := db.WriteDB.Exec(`
+			UPDATE dragon_rules
+			SET threshold = IF(? > 0, threshold, ?), rarity_threshold = ?
+			WHERE chat_id = ? AND pattern_type = ? AND attribute_type = ?
+		`, values[i].rarity, values[i].threshold, values[i].rarity, chatID, t.pattern, t.attribute)
+		if err != nil {
+			log.Printf("[规则命令] 群组:%d 设置规则失败: %v", chatID, err)
+			return
+		}
+	}
+
+	replyRules(chatID, lang, targets)
+}
+
+// handleEnableCommand 启用或停用规则：/enable <属性|*> <格式|*>、/disable <属性|*> <格式|*>
+func handleEnableCommand(message *tgbotapi.Message, enabled bool) {
+	if !checkGroupAdmin(message) {
+		return
+	}
+
+	chatID := message.Chat.ID
+	lang := chatLanguage(chatID)
+
+	args := strings.Fields(message.CommandArguments())
+	if len(args) != 2 {
+		send(chatID, tgbotapi.NewMessage(chatID, i18n.T(lang, "rules.cmd.usage.enable", message.Command())))
+		return
+	}
+
+	targets, errText := parseRuleTargets(lang, args[0], args[1])
+	if errText != "" {
+		send(chatID, tgbotapi.NewMessage(chatID, errText))
+		return
+	}
+
+	ensureDefaultRules(chatID)
+	for _, t := range targets {
+		_, err := db.WriteDB.Exec(`
+			UPDATE dragon_rules SET enabled = ?
+			WHERE chat_id = ? AND pattern_type = ? AND attribute_type = ?
+		`, enabled, chatID, t.pattern, t.attribute)
+		if err != nil {
+			log.Printf("[规则命令] 群组:%d 切换规则失败: %v", chatID, err)
+			return
+		}
+	}
+
+	replyRules(chatID, lang, targets)
+}
+
+// handleRulesCommand 不带参数时输出规则块，带参数时按规则块批量更新：
+// 每行 "<属性> <格式> <数值|r稀有度> [off]"，全部校验通过后在一个事务中更新
+func handleRulesCommand(message *tgbotapi.Message) {
+	if !checkGroupAdmin(message) {
+		return
+	}
+
+	chatID := message.Chat.ID
+	lang := chatLanguage(chatID)
+	ensureDefaultRules(chatID)
+
+	body := strings.TrimSpace(message.CommandArguments())
+	if body == "" {
+		text := i18n.T(lang, "rules.cmd.block") + "\n<pre>" + html.EscapeString(rulesBlock(chatID)) + "</pre>"
+		msg := tgbotapi.NewMessage(chatID, text)
+		msg.ParseMode = "HTML"
+		send(chatID, msg)
+		return
+	}
+
+	type ruleLine struct {
+		target  ruleTarget
+		value   ruleValue
+		enabled bool
+	}
+
+	var lines []ruleLine
+	var targets []ruleTarget
+	for i, line := range strings.Split(body, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+
+		enabled := true
+		if len(fields) == 4 && (strings.EqualFold(fields[3], "off") || fields[3] == "停用") {
+			enabled = false
+			fields = fields[:3]
+		}
+		if len(fields) != 3 {
+			send(chatID, tgbotapi.NewMessage(chatID, i18n.T(lang, "rules.cmd.line_error", i+1, i18n.T(lang, "rules.cmd.line_format"))))
+			return
+		}
+
+		ts, errText := parseRuleTargets(lang, fields[0], fields[1])
+		if errText != "" {
+			send(chatID, tgbotapi.NewMessage(chatID, i18n.T(lang, "rules.cmd.line_error", i+1, errText)))
+			return
+		}
+		for _, t := range ts {
+			value, errText := parseRuleValue(lang, t.pattern, fields[2])
+			if errText != "" {
+				send(chatID, tgbotapi.NewMessage(chatID, i18n.T(lang, "rules.cmd.line_error", i+1, errText)))
+				return
+			}
+			lines = append(lines, ruleLine{t, value, enabled})
+			targets = append(targets, t)
+		}
+	}
+
+	if len(lines) == 0 {
+		send(chatID, tgbotapi.NewMessage(chatID, i18n.T(lang, "rules.cmd.line_error", 1, i18n.T(lang, "rules.cmd.line_format"))))
+		return
+	}
+
+	tx, err := db.WriteDB.Begin()
+	if err != nil {
+		log.Printf("[规则命令] 群组:%d 批量更新规则失败: %v", chatID, err)
+		return
+	}
+	for _, l := range lines {
+		_, err := tx.Exec(`
+			UPDATE dragon_rules
+			SET threshold = IF(? > 0, threshold, ?), rarity_threshold = ?, enabled = ?
+			WHERE chat_id = ? AND pattern_type = ? AND attribute_type = ?
+		`, l.value.rarity, l.value.threshold, l.value.rarity, l.enabled, chatID, l.target.pattern, l.target.attribute)
+		if err != nil {
+			tx.Rollback()
+			log.Printf("[规则命令] 群组:%d 批量更新规则失败: %v", chatID, err)
+			return
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		log.Printf("[规则命令] 群组:%d 批量更新规则失败: %v", chatID, err)
+		return
+	}
+
+	replyRules(chatID, lang, targets)
+}
+
+// rulesBlock 规则块：每行 "<属性> <格式> <数值|r稀有度> [off]"，可修改后用 /rules 发送
+func rulesBlock(chatID int64) string {
+	rules := loadRules(chatID)
+
+	var lines []string
+	for _, attr := range ruleAttributes {
+		for _, pattern := range rulePatterns[attr] {
+			r, ok := rules[ruleTarget{attr, pattern}]
+			if !ok {
+				continue
+			}
+
+			value := strconv.Itoa(r.threshold)
+			if r.rarity > 0 {
+				value = fmt.Sprintf("r%d", r.rarity)
+			}
+			line := fmt.Sprintf("%-6s %-5s %s", ruleAttributeKeys[attr], pattern, value)
+			if !r.enabled {
+				line += " off"
+			}
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// loadRules 读取群组的所有规则（包括停用的）
+func loadRules(chatID int64) map[ruleTarget]menuRule {
+	rules := make(map[ruleTarget]menuRule)
+
+	rows, err := db.WriteDB.Query(`
+		SELECT pattern_type, attribute_type, threshold, rarity_threshold, enabled
+		FROM dragon_rules WHERE chat_id = ?
+	`, chatID)
+	if err != nil {
+		log.Printf("[规则命令] 群组:%d 查询规则失败: %v", chatID, err)
+		return rules
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var t ruleTarget
+		var r menuRule
+		if err := rows.Scan(&t.pattern, &t.attribute, &r.threshold, &r.rarity, &r.enabled); err != nil {
+			continue
+		}
+		rules[t] = r
+	}
+	return rules
+}
+
+// replyRules 回复修改后的规则
+func replyRules(chatID int64, lang string, targets []ruleTarget) {
+	rules := loadRules(chatID)
+
+	var text strings.Builder
+	text.WriteString(i18n.Plural(lang, "rules.cmd.updated", len(targets), len(targets)))
+	for _, t := range targets {
+		r := rules[t]
+		status := i18n.T(lang, "menu.enabled")
+		if !r.enabled {
+			status = i18n.T(lang, "menu.disabled")
+		}
+		text.WriteString("\n" + i18n.T(lang, "rules.cmd.line",
+			attributeTitle(lang, t.attribute), recordPatternName(lang, t.pattern), r.triggerText(lang, t.pattern), status))
+	}
+
+	send(chatID, tgbotapi.NewMessage(chatID, text.String()))
+}
@@ -0,0 +1,207 @@
+package bot
+
+import (
+	"dragon-alert-bot/db"
+	"dragon-alert-bot/i18n"
+	"dragon-alert-bot/schedule"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// quietPolicies 静默时段的处理方式，名称在消息目录 schedule.policy.<方式> 中
+var quietPolicies = map[string]bool{
+	schedule.PolicyDrop:     true,
+	schedule.PolicyDigest:   true,
+	schedule.PolicyCritical: true,
+}
+
+// 紧急长龙的组数范围
+const (
+	minQuietCritical = 2
+	maxQuietCritical = 50
+)
+
+// loadSchedule 读取群组的提醒时段配置
+func loadSchedule(chatID int64) *db.ChatConfig {
+	cfg := &db.ChatConfig{
+		ChatID:        chatID,
+		Timezone:      schedule.DefaultTimezone,
+		ActiveDays:    schedule.AllDays,
+		WindowEnd:     24,
+		QuietPolicy:   schedule.PolicyDrop,
+		QuietCritical: 10,
+	}
+	db.WriteDB.QueryRow(`
+		SELECT timezone, active_days, window_start, window_end, quiet_policy, quiet_critical
+		FROM chat_configs WHERE chat_id = ?
+	`, chatID).Scan(&cfg.Timezone, &cfg.ActiveDays, &cfg.WindowStart, &cfg.WindowEnd, &cfg.QuietPolicy, &cfg.QuietCritical)
+	return cfg
+}
+
+// showScheduleMenu 显示提醒时段菜单
+func showScheduleMenu(chatID int64, messageID int) {
+	cfg := loadSchedule(chatID)
+	sched := schedule.FromConfig(cfg)
+	lang := chatLanguage(chatID)
+
+	status := i18n.T(lang, "schedule.active")
+	if !sched.Active(time.Now()) {
+		status = i18n.T(lang, "schedule.quiet")
+	}
+
+	text := i18n.T(lang, "schedule.title", cfg.Timezone, sched.Describe(lang), status, i18n.T(lang, "schedule.policy."+cfg.QuietPolicy))
+	if cfg.QuietPolicy == schedule.PolicyCritical {
+		text += i18n.T(lang, "schedule.critical", cfg.QuietCritical)
+	}
+	text += "\n\n" + i18n.T(lang, "schedule.hint")
+
+	var dayButtons []tgbotapi.InlineKeyboardButton
+	for _, day := range schedule.WeekdayOrder() {
+		label := schedule.WeekdayName(lang, day)
+		if cfg.ActiveDays&(1<<uint(day)) != 0 {
+			label = "✅" + label
+		}
+		dayButtons = append(dayButtons, tgbotapi.NewInlineKeyboardButtonData(label, fmt.Sprintf("dragon_sched_day_%d", day)))
+	}
+
+	policyButton := func(policy string) tgbotapi.InlineKeyboardButton {
+		label := i18n.T(lang, "schedule.policy."+policy)
+		if policy == cfg.QuietPolicy {
+			label = "✅ " + label
+		}
+		return tgbotapi.NewInlineKeyboardButtonData(label, "dragon_sched_policy_"+policy)
+	}
+
+	rows := [][]tgbotapi.InlineKeyboardButton{
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "schedule.timezone", cfg.Timezone), "dragon_sched_tz"),
+		),
+		dayButtons[:4],
+		dayButtons[4:],
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("➖", "dragon_sched_start_dec"),
+			tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "schedule.start", cfg.WindowStart), "dragon_noop"),
+			tgbotapi.NewInlineKeyboardButtonData("➕", "dragon_sched_start_inc"),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("➖", "dragon_sched_end_dec"),
+			tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "schedule.end", cfg.WindowEnd), "dragon_noop"),
+			tgbotapi.NewInlineKeyboardButtonData("➕", "dragon_sched_end_inc"),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			policyButton(schedule.PolicyDrop),
+			policyButton(schedule.PolicyDigest),
+			policyButton(schedule.PolicyCritical),
+		),
+	}
+
+	if cfg.QuietPolicy == schedule.PolicyCritical {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("➖", "dragon_sched_crit_dec"),
+			tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "schedule.critical_button", cfg.QuietCritical), "dragon_noop"),
+			tgbotapi.NewInlineKeyboardButtonData("➕", "dragon_sched_crit_inc"),
+		))
+	}
+
+	rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "menu.back"), "dragon_main"),
+	))
+
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(rows...)
+	msg := tgbotapi.NewEditMessageText(chatID, messageID, text)
+	msg.ReplyMarkup = &keyboard
+	send(chatID, msg)
+}
+
+// handleSchedule 处理提醒时段菜单的操作，args 为回调数据 dragon_sched_ 之后的部分
+func handleSchedule(chatID int64, messageID int, args []string) {
+	cfg := loadSchedule(chatID)
+
+	switch args[0] {
+	case "tz":
+		next := schedule.Timezones[0]
+		for i, tz := range schedule.Timezones {
+			if tz == cfg.Timezone && i+1 < len(schedule.Timezones) {
+				next = schedule.Timezones[i+1]
+			}
+		}
+		cfg.Timezone = next
+
+	case "day":
+		if len(args) < 2 {
+			return
+		}
+		day, err := strconv.Atoi(args[1])
+		if err != nil || day < 0 || day > 6 {
+			return
+		}
+		cfg.ActiveDays ^= 1 << uint(day)
+
+	case "start":
+		if len(args) < 2 {
+			return
+		}
+		cfg.WindowStart = stepValue(cfg.WindowStart, args[1], 0, 23)
+		// 开始与结束相同的时段为空，跳过
+		if cfg.WindowStart == cfg.WindowEnd {
+			cfg.WindowStart = stepValue(cfg.WindowStart, args[1], 0, 23)
+		}
+
+	case "end":
+		if len(args) < 2 {
+			return
+		}
+		cfg.WindowEnd = stepValue(cfg.WindowEnd, args[1], 1, 24)
+		if cfg.WindowEnd == cfg.WindowStart {
+			cfg.WindowEnd = stepValue(cfg.WindowEnd, args[1], 1, 24)
+		}
+
+	case "policy":
+		if len(args) < 2 || !quietPolicies[args[1]] {
+			return
+		}
+		cfg.QuietPolicy = args[1]
+
+	case "crit":
+		if len(args) < 2 {
+			return
+		}
+		cfg.QuietCritical = stepValue(cfg.QuietCritical, args[1], minQuietCritical, maxQuietCritical)
+
+	default:
+		return
+	}
+
+	_, err := db.WriteDB.Exec(`
+		UPDATE chat_configs
+		SET timezone = ?, active_days = ?, window_start = ?, window_end = ?, quiet_policy = ?, quiet_critical = ?
+		WHERE chat_id = ?
+	`, cfg.Timezone, cfg.ActiveDays, cfg.WindowStart, cfg.WindowEnd, cfg.QuietPolicy, cfg.QuietCritical, chatID)
+	if err != nil {
+		log.Printf("更新提醒时段失败: %v", err)
+	}
+
+	showScheduleMenu(chatID, messageID)
+}
+
+// stepValue 按 inc/dec 调整数值，超出 [min, max] 时循环
+func stepValue(value int, action string, min, max int) int {
+	switch action {
+	case "inc":
+		value++
+	case "dec":
+		value--
+	}
+
+	if value > max {
+		return min
+	}
+	if value < min {
+		return max
+	}
+	return value
+}
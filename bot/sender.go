@@ -0,0 +1,367 @@
+package bot
+
+import (
+	"dragon-alert-bot/db"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Priority 发送优先级，数值越小越优先
+type Priority int
+
+const (
+	PriorityAlert  Priority = iota // 长龙提醒
+	PriorityNormal                 // 菜单、命令回复
+	priorityCount
+)
+
+// Telegram 发送频率限制
+const (
+	globalRatePerSecond = 30 // 全局每秒30条
+	groupRatePerMinute  = 20 // 同一群组每分钟20条
+	groupBurst          = 10 // 群组允许的突发条数
+	privateRatePerSec   = 1  // 同一私聊每秒1条
+	maxSendAttempts     = 5  // 最多尝试次数（不含 429 等待）
+	maxSendWorkers      = 10 // 并发发送数
+	baseRetryBackoff    = time.Second
+
+	floodWindow         = 2 * time.Second // 判断全局限流的时间窗口
+	floodChats          = 3               // 窗口内有多少个不同会话触发限流时视为全局限流
+	bucketSweepInterval = time.Minute     // 回收空闲会话令牌桶的间隔
+)
+
+// outgoing 待发送的消息
+type outgoing struct {
+	chatID    int64
+	message   tgbotapi.Chattable
+	priority  Priority
+	onResult  func(tgbotapi.Message, error)
+	attempts  int
+	notBefore time.Time
+}
+
+// tokenBucket 令牌桶
+type tokenBucket struct {
+	tokens   float64
+	capacity float64
+	rate     float64 // 每秒补充的令牌数
+	last     time.Time
+	paused   time.Time // 收到 retry_after 后在此之前不发送
+}
+
+func newTokenBucket(capacity, rate float64) *tokenBucket {
+	return &tokenBucket{tokens: capacity, capacity: capacity, rate: rate, last: time.Now()}
+}
+
+// wait 返回距离有可用令牌还需等待的时间（0 表示可以立即发送）
+func (b *tokenBucket) wait(now time.Time) time.Duration {
+	if now.Before(b.paused) {
+		return b.paused.Sub(now)
+	}
+
+	b.tokens = math.Min(b.capacity, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	b.last = now
+	if b.tokens >= 1 {
+		return 0
+	}
+	return time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+}
+
+func (b *tokenBucket) take() {
+	b.tokens--
+}
+
+// idle 令牌已补满且没有暂停，与新建的令牌桶等价，可以回收
+func (b *tokenBucket) idle(now time.Time) bool {
+	return !now.Before(b.paused) && b.tokens+now.Sub(b.last).Seconds()*b.rate >= b.capacity
+}
+
+// Outbox 统一的发送队列：全局与群组令牌桶限流、遵守 retry_after、有限次数退避重试、
+// 提醒优先于菜单，多次失败的消息写入死信表
+// 同一会话同时只有一条消息在发送，重试的消息放回队首，保证会话内的发送顺序
+type Outbox struct {
+	mu       sync.Mutex
+	lanes    [priorityCount][]*outgoing
+	global   *tokenBucket
+	chats    map[int64]*tokenBucket
+	inflight map[int64]bool      // 正在发送的会话
+	floods   map[int64]time.Time // 最近触发频率限制的会话及时间
+	swept    time.Time           // 上次回收空闲令牌桶的时间
+
+	wake    chan struct{}
+	workers chan struct{}
+}
+
+var outbox = newOutbox()
+
+func newOutbox() *Outbox {
+	return &Outbox{
+		global:   newTokenBucket(globalRatePerSecond, globalRatePerSecond),
+		chats:    make(map[int64]*tokenBucket),
+		inflight: make(map[int64]bool),
+		floods:   make(map[int64]time.Time),
+		swept:    time.Now(),
+		wake:     make(chan struct{}, 1),
+		workers:  make(chan struct{}, maxSendWorkers),
+	}
+}
+
+// Enqueue 将消息加入发送队列，onResult 可为空，在发送成功或最终失败后调用
+func Enqueue(chatID int64, message tgbotapi.Chattable, priority Priority, onResult func(tgbotapi.Message, error)) {
+	outbox.push(&outgoing{
+		chatID:   chatID,
+		message:  message,
+		priority: priority,
+		onResult: onResult,
+	})
+}
+
+// send 以普通优先级发送命令回复或菜单
+func send(chatID int64, message tgbotapi.Chattable) {
+	Enqueue(chatID, message, PriorityNormal, nil)
+}
+
+func (o *Outbox) push(item *outgoing) {
+	o.mu.Lock()
+	o.lanes[item.priority] = append(o.lanes[item.priority], item)
+	o.mu.Unlock()
+
+	o.notify()
+}
+
+// requeue 将需要重试的消息放回队首，排在同一会话后续的消息之前
+func (o *Outbox) requeue(item *outgoing) {
+	o.mu.Lock()
+	o.lanes[item.priority] = append([]*outgoing{item}, o.lanes[item.priority]...)
+	o.mu.Unlock()
+
+	o.notify()
+}
+
+// release 会话的消息发送结束，可以发送该会话的下一条
+func (o *Outbox) release(chatID int64) {
+	o.mu.Lock()
+	delete(o.inflight, chatID)
+	o.mu.Unlock()
+
+	o.notify()
+}
+
+func (o *Outbox) notify() {
+	select {
+	case o.wake <- struct{}{}:
+	default:
+	}
+}
+
+// run 调度循环：按优先级取出可以发送的消息交给发送协程
+func (o *Outbox) run() {
+	for {
+		item, wait := o.next()
+		if item == nil {
+			timer := time.NewTimer(wait)
+			select {
+			case <-o.wake:
+			case <-timer.C:
+			}
+			timer.Stop()
+			continue
+		}
+
+		// 群组迁移时 item.chatID 会改为新的会话，释放的是取出时的会话
+		chatID := item.chatID
+		o.workers <- struct{}{}
+		go func() {
+			defer func() { <-o.workers }()
+			o.deliver(item)
+			o.release(chatID)
+		}()
+	}
+}
+
+// next 取出下一条可以立即发送的消息；没有时返回需要等待的时间
+func (o *Outbox) next() (*outgoing, time.Duration) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	now := time.Now()
+	minWait := time.Minute
+	o.sweepBuckets(now)
+
+	if wait := o.global.wait(now); wait > 0 {
+		return nil, wait
+	}
+
+	for p := range o.lanes {
+		blocked := make(map[int64]bool) // 保证同一会话内按顺序发送
+		for i, item := range o.lanes[p] {
+			if blocked[item.chatID] || o.inflight[item.chatID] {
+				continue
+			}
+
+			wait := item.notBefore.Sub(now)
+			if chatWait := o.chatBucket(item.chatID).wait(now); chatWait > wait {
+				wait = chatWait
+			}
+			if wait > 0 {
+				blocked[item.chatID] = true
+				if wait < minWait {
+					minWait = wait
+				}
+				continue
+			}
+
+			o.global.take()
+			o.chatBucket(item.chatID).take()
+			o.inflight[item.chatID] = true
+			o.lanes[p] = append(o.lanes[p][:i], o.lanes[p][i+1:]...)
+			return item, 0
+		}
+	}
+
+	return nil, minWait
+}
+
+func (o *Outbox) chatBucket(chatID int64) *tokenBucket {
+	bucket, ok := o.chats[chatID]
+	if !ok {
+		if chatID < 0 {
+			bucket = newTokenBucket(groupBurst, groupRatePerMinute/60.0)
+		} else {
+			bucket = newTokenBucket(1, privateRatePerSec)
+		}
+		o.chats[chatID] = bucket
+	}
+	return bucket
+}
+
+// sweepBuckets 定期回收空闲的会话令牌桶，避免发过消息的会话一直占用内存
+func (o *Outbox) sweepBuckets(now time.Time) {
+	if now.Sub(o.swept) < bucketSweepInterval {
+		return
+	}
+	o.swept = now
+
+	for chatID, bucket := range o.chats {
+		if !o.inflight[chatID] && bucket.idle(now) {
+			delete(o.chats, chatID)
+		}
+	}
+}
+
+// rateLimited 记录会话触发频率限制，暂停该会话；短时间内多个会话都被限流时说明触发的是全局限制，同时暂停全局发送
+func (o *Outbox) rateLimited(chatID int64, retryAfter time.Duration) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	now := time.Now()
+	until := now.Add(retryAfter)
+	o.chatBucket(chatID).paused = until
+
+	o.floods[chatID] = now
+	for id, at := range o.floods {
+		if now.Sub(at) > floodWindow {
+			delete(o.floods, id)
+		}
+	}
+	if len(o.floods) >= floodChats && until.After(o.global.paused) {
+		o.global.paused = until
+		log.Printf("[发送限流] %d个会话同时被限流，全局暂停%d秒", len(o.floods), int(retryAfter.Seconds()))
+	}
+}
+
+// deliver 发送一条消息并处理失败重试
+func (o *Outbox) deliver(item *outgoing) {
+	item.attempts++
+
+	resp, err := BotAPI.Request(item.message)
+	if err == nil {
+		// 发送/编辑消息返回 Message，删除、置顶等返回 true
+		var msg tgbotapi.Message
+		if len(resp.Result) > 0 && resp.Result[0] == '{' {
+			json.Unmarshal(resp.Result, &msg)
+		}
+		sendSucceeded(item.chatID)
+		if item.onResult != nil {
+			item.onResult(msg, nil)
+		}
+		return
+	}
+
+	var apiErr *tgbotapi.Error
+	if errors.As(err, &apiErr) {
+		// 群组迁移、机器人被移出、权限不足
+		if o.handleChatError(item, apiErr) {
+			return
+		}
+
+		// 触发频率限制：按 retry_after 暂停该会话（全局限流时暂停全局）后重试，不计入尝试次数
+		if apiErr.RetryAfter > 0 {
+			o.rateLimited(item.chatID, time.Duration(apiErr.RetryAfter)*time.Second)
+
+			item.attempts--
+			item.notBefore = time.Now().Add(time.Duration(apiErr.RetryAfter) * time.Second)
+			log.Printf("[发送限流] 会话:%d 等待%d秒后重试", item.chatID, apiErr.RetryAfter)
+			o.requeue(item)
+			return
+		}
+
+		// 4xx 为请求本身的问题，重试无意义
+		if apiErr.Code >= 400 && apiErr.Code < 500 {
+			o.fail(item, err)
+			return
+		}
+	}
+
+	if item.attempts >= maxSendAttempts {
+		o.fail(item, err)
+		return
+	}
+
+	// 网络错误或 5xx：指数退避后重试
+	item.notBefore = time.Now().Add(baseRetryBackoff * time.Duration(1<<(item.attempts-1)))
+	o.requeue(item)
+}
+
+// fail 消息最终发送失败，写入死信表
+func (o *Outbox) fail(item *outgoing, err error) {
+	if item.onResult != nil {
+		item.onResult(tgbotapi.Message{}, err)
+	}
+
+	// 编辑内容未变化、要删除的消息已不存在或已超过可删除时间，不算失败
+	desc := err.Error()
+	if strings.Contains(desc, "message is not modified") ||
+		strings.Contains(desc, "message to delete not found") ||
+		strings.Contains(desc, "message can't be deleted") {
+		return
+	}
+
+	log.Printf("[发送失败] 会话:%d 尝试:%d次 错误:%v", item.chatID, item.attempts, err)
+
+	payload := ""
+	if data, jerr := json.Marshal(item.message); jerr == nil {
+		payload = string(data)
+	}
+
+	_, dbErr := db.WriteDB.Exec(`
+		INSERT INTO send_dead_letters (chat_id, method, payload, error, attempts, priority)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, item.chatID, fmt.Sprintf("%T", item.message), payload, err.Error(), item.attempts, int(item.priority))
+	if dbErr != nil {
+		log.Printf("[发送失败] 写入死信表失败: %v", dbErr)
+	}
+}
+
+// startOutbox 启动发送队列
+func startOutbox() {
+	go outbox.run()
+}
@@ -0,0 +1,100 @@
+package bot
+
+import (
+	"dragon-alert-bot/backtest"
+	"dragon-alert-bot/i18n"
+	"dragon-alert-bot/lottery"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// simulateWindow 规则模拟回放的时间范围
+const simulateWindow = 24 * time.Hour
+
+// showSimulation 用群组当前规则回放最近24小时的开奖，展示会发送多少提醒
+func showSimulation(chatID int64, messageID int) {
+	rules, err := modules.Analyzer.GetChatRules(chatID)
+	if err != nil {
+		log.Printf("[规则模拟] 查询规则失败: %v", err)
+		return
+	}
+
+	lang := chatLanguage(chatID)
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "simulate.rerun"), "dragon_simulate"),
+			tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "menu.back"), "dragon_main"),
+		),
+	)
+
+	if len(rules) == 0 {
+		msg := tgbotapi.NewEditMessageText(chatID, messageID, i18n.T(lang, "simulate.no_rules"))
+		msg.ReplyMarkup = &keyboard
+		send(chatID, msg)
+		return
+	}
+
+	// 回放区间之前的500期用于预热检测窗口
+	since := time.Now().Add(-simulateWindow)
+	warmup, err := modules.Monitor.GetHistoryBefore(since, 500)
+	if err != nil {
+		log.Printf("[规则模拟] 查询历史数据失败: %v", err)
+		return
+	}
+
+	var draws []lottery.LotteryData
+	for i := len(warmup) - 1; i >= 0; i-- {
+		draws = append(draws, warmup[i])
+	}
+
+	err = modules.Monitor.StreamRange(since, time.Now().Add(time.Minute), func(data *lottery.LotteryData) error {
+		draws = append(draws, *data)
+		return nil
+	})
+	if err != nil {
+		log.Printf("[规则模拟] 查询开奖数据失败: %v", err)
+		return
+	}
+
+	report := backtest.Run(draws, len(warmup), rules)
+
+	msg := tgbotapi.NewEditMessageText(chatID, messageID, formatSimulation(lang, report))
+	msg.ReplyMarkup = &keyboard
+	send(chatID, msg)
+}
+
+// formatSimulation 格式化规则模拟结果
+func formatSimulation(lang string, report *backtest.Report) string {
+	var text strings.Builder
+	text.WriteString(i18n.T(lang, "simulate.title") + "\n")
+	text.WriteString(i18n.T(lang, "simulate.summary", report.Draws, report.AlertMessages) + "\n")
+
+	text.WriteString("\n" + i18n.T(lang, "simulate.by_rule") + "\n")
+	for _, r := range report.Rules {
+		text.WriteString(i18n.T(lang, "simulate.rule",
+			attributeTitle(lang, r.AttributeType), recordPatternName(lang, r.PatternType), r.Alerts, r.Dragons) + "\n")
+	}
+
+	text.WriteString("\n" + i18n.T(lang, "simulate.longest") + "\n")
+	for _, attr := range []string{"size", "parity", "sum", "size_parity"} {
+		longest := 0
+		pattern := ""
+		for _, l := range report.Lengths {
+			if l.AttributeType == attr && l.Longest > longest {
+				longest = l.Longest
+				pattern = l.PatternType
+			}
+		}
+
+		if longest > 0 {
+			text.WriteString(fmt.Sprintf("%s: %s (%s)\n", attributeTitle(lang, attr), i18n.Count(lang, i18n.UnitDraw, longest), recordPatternName(lang, pattern)))
+		}
+	}
+
+	text.WriteString("\n" + i18n.T(lang, "simulate.hint"))
+	return text.String()
+}
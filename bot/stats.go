@@ -0,0 +1,115 @@
+package bot
+
+import (
+	"dragon-alert-bot/db"
+	"dragon-alert-bot/i18n"
+	"html"
+	"log"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// statsDays /stats 显示的天数
+const statsDays = 7
+
+// dailyStats 一天（或多天合计）的提醒统计
+type dailyStats struct {
+	date         time.Time
+	sent         int
+	failed       int
+	dragons      int
+	latencyTotal int64
+	latencyCount int
+	latencyMax   int
+}
+
+func (s *dailyStats) add(other dailyStats) {
+	s.sent += other.sent
+	s.failed += other.failed
+	s.dragons += other.dragons
+	s.latencyTotal += other.latencyTotal
+	s.latencyCount += other.latencyCount
+	if other.latencyMax > s.latencyMax {
+		s.latencyMax = other.latencyMax
+	}
+}
+
+// averageLatency 平均延迟（从开奖到发送完成），没有数据时显示"-"
+func (s dailyStats) averageLatency(lang string) string {
+	if s.latencyCount == 0 {
+		return "-"
+	}
+	return formatLatency(lang, int(s.latencyTotal/int64(s.latencyCount)))
+}
+
+func formatLatency(lang string, ms int) string {
+	return i18n.T(lang, "stats.seconds", float64(ms)/1000)
+}
+
+// handleStats 查看本群组最近几天的提醒发送统计
+func handleStats(message *tgbotapi.Message) {
+	chatID := message.Chat.ID
+	lang := chatLanguage(chatID)
+
+	if chatID > 0 {
+		send(chatID, tgbotapi.NewMessage(chatID, i18n.T(lang, "stats.group_only")))
+		return
+	}
+
+	rows, err := db.WriteDB.Query(`
+		SELECT stat_date, sent, failed, dragons, latency_total, latency_count, latency_max
+		FROM alert_daily_stats
+		WHERE chat_id = ? AND stat_date > CURDATE() - INTERVAL ? DAY
+		ORDER BY stat_date DESC
+	`, chatID, statsDays)
+	if err != nil {
+		log.Printf("[提醒统计] 群组:%d 查询失败: %v", chatID, err)
+		return
+	}
+
+	var days []dailyStats
+	var total dailyStats
+	for rows.Next() {
+		var d dailyStats
+		if err := rows.Scan(&d.date, &d.sent, &d.failed, &d.dragons, &d.latencyTotal, &d.latencyCount, &d.latencyMax); err != nil {
+			continue
+		}
+		days = append(days, d)
+		total.add(d)
+	}
+	rows.Close()
+
+	var text strings.Builder
+	text.WriteString(i18n.T(lang, "stats.title", statsDays) + "\n")
+
+	if len(days) == 0 {
+		text.WriteString("\n" + i18n.T(lang, "stats.empty"))
+	} else {
+		text.WriteString("\n")
+		for _, d := range days {
+			text.WriteString(i18n.T(lang, "stats.day",
+				d.date.Format("01-02"), d.sent, d.failed, d.dragons, d.averageLatency(lang)) + "\n")
+		}
+
+		text.WriteString("\n" + i18n.T(lang, "stats.total",
+			total.sent, total.failed, total.dragons, total.averageLatency(lang), formatLatency(lang, total.latencyMax)))
+	}
+
+	// 最近一次发送失败
+	var lastError string
+	var failedAt time.Time
+	err = db.WriteDB.QueryRow(`
+		SELECT error, created_at FROM alert_deliveries
+		WHERE chat_id = ? AND status = 'failed'
+		ORDER BY id DESC LIMIT 1
+	`, chatID).Scan(&lastError, &failedAt)
+	if err == nil {
+		text.WriteString("\n\n" + i18n.T(lang, "stats.last_error", failedAt.Format("01-02 15:04"), html.EscapeString(lastError)))
+	}
+
+	msg := tgbotapi.NewMessage(chatID, text.String())
+	msg.ParseMode = "HTML"
+	send(chatID, msg)
+}
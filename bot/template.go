@@ -0,0 +1,552 @@
+package bot
+
+import (
+	"bytes"
+	"dragon-alert-bot/db"
+	"dragon-alert-bot/dragon"
+	"dragon-alert-bot/i18n"
+	"errors"
+	"html"
+	"log"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"text/template"
+	"text/template/parse"
+	"unicode/utf8"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// 内置模板名称，TemplateCustom 表示使用群组自定义的模板
+const (
+	TemplateDetailed = "detailed"
+	TemplateCompact  = "compact"
+	TemplateOneLine  = "one-line"
+	TemplateCustom   = "custom"
+)
+
+// 自定义模板的限制
+const (
+	maxTemplateSize    = 2000 // 模板本身的最大长度
+	maxMessageSize     = 4096 // Telegram 单条消息的最大长度
+	maxRangeDepth      = 3    // range 最多嵌套层数
+	maxCachedTemplates = 256  // 解析后缓存的自定义模板数量
+)
+
+// AlertData 提醒模板的数据
+//
+//	.Qihao    当前期号
+//	.Draw     当前开奖（可能为空）：.Qihao .OpenNum .Sum .Size .Parity
+//	.Total    长龙数量
+//	.Groups   按属性分组（大小、单双、和值、组合，只包含有长龙的分组）：.Attribute .Name .Emoji .Items
+//	.Results  所有长龙（按期数降序）
+//	.Lang     群组语言（zh-CN、zh-TW、en）
+//	.T        按群组语言翻译消息目录中的文字，如 {{$.T "alert.title"}}
+//
+// 每条长龙（.Items / .Results 中的元素）：
+//
+//	.Attribute .AttributeName  属性（size/大小）
+//	.Pattern .PatternName      格式（a/连续）
+//	.Count                     期数
+//	.Groups                    组数（与规则阈值的单位一致）
+//	.Length .Unit              提醒中显示的长度和单位（abb 按组，其余按期）
+//	.Detail                    模式详情（abb 按组加括号）
+//	.StartQihao .CurrentQihao  起始期号、当前期号
+//	.Rarity .Stars .Odds       稀有度评分、星级、理论概率（如 1/64）
+//	.HistoryRuns .Percentile   历史同类长龙数量、超过历史同类长龙的百分比
+type AlertData struct {
+	Lang    string
+	Qihao   string
+	Draw    *DrawData
+	Total   int
+	Groups  []AlertGroup
+	Results []AlertItem
+}
+
+// T 按群组语言翻译消息目录中的文字
+func (d *AlertData) T(key string, args ...interface{}) string {
+	return i18n.T(d.Lang, key, args...)
+}
+
+// DrawData 当前开奖
+type DrawData struct {
+	Qihao   string
+	OpenNum string
+	Sum     int
+	Size    string
+	Parity  string
+}
+
+// AlertGroup 同一属性的长龙
+type AlertGroup struct {
+	Attribute string
+	Name      string
+	Emoji     string
+	Items     []AlertItem
+}
+
+// AlertItem 单条长龙
+type AlertItem struct {
+	Attribute     string
+	AttributeName string
+	Pattern       string
+	PatternName   string
+	Count         int
+	Groups        int
+	Length        int
+	Unit          string
+	Detail        string
+	StartQihao    string
+	CurrentQihao  string
+	Rarity        int
+	Stars         string
+	Odds          string
+	HistoryRuns   int
+	Percentile    int
+}
+
+// 属性分组的顺序和图标
+var alertGroups = []struct {
+	attribute, emoji string
+}{
+	{"size", "📊"},
+	{"parity", "🎯"},
+	{"sum", "🔢"},
+	{"size_parity", "🔄"},
+}
+
+// 内置模板
+var builtinTemplateText = map[string]string{
+	TemplateDetailed: `🔥 <b>{{$.T "alert.title"}}</b>
+{{if .Draw}}{{$.T "alert.draw" .Draw.Qihao .Draw.OpenNum .Draw.Sum .Draw.Size .Draw.Parity}}{{else}}{{$.T "alert.qihao" .Qihao}}{{end}}
+{{range .Groups}}<blockquote>{{.Emoji}} <b>{{$.T "alert.group" .Name}}</b></blockquote>
+{{range .Items}}  • {{$.T "alert.item" .PatternName .Length .Unit}}
+    <code>{{.Detail}}</code>
+    {{$.T "alert.start" .StartQihao}}
+    {{$.T "alert.rarity" .Rarity .Stars .Odds}}{{if .HistoryRuns}}{{$.T "alert.percentile" .Percentile}}{{end}}
+
+{{end}}{{end}}`,
+
+	TemplateCompact: `🔥 <b>{{$.T "alert.title"}}</b> {{if .Draw}}{{$.T "alert.compact.draw" .Draw.Qihao .Draw.OpenNum .Draw.Sum .Draw.Size .Draw.Parity}}{{else}}{{$.T "alert.compact.qihao" .Qihao}}{{end}}
+{{range .Groups}}{{$g := .}}{{range .Items}}{{$g.Emoji}} {{$.T "alert.compact.item" $g.Name .PatternName .Length .Unit .StartQihao}} 💎{{.Rarity}}
+{{end}}{{end}}`,
+
+	TemplateOneLine: `🔥 {{$.T "alert.compact.qihao" .Qihao}}{{range .Results}} | {{$.T "alert.oneline.item" .AttributeName .PatternName .Length .Unit}}{{end}}`,
+}
+
+// TemplateNames 内置模板名称（按菜单显示顺序）
+var TemplateNames = []string{TemplateDetailed, TemplateCompact, TemplateOneLine}
+
+var templateFuncs = template.FuncMap{
+	"escape": html.EscapeString,
+}
+
+var (
+	builtinTemplates = make(map[string]*template.Template)
+
+	customMu        sync.Mutex
+	customTemplates = make(map[string]*template.Template) // 模板内容 → 解析后的模板
+)
+
+func init() {
+	for name, text := range builtinTemplateText {
+		builtinTemplates[name] = template.Must(template.New(name).Funcs(templateFuncs).Parse(text))
+	}
+}
+
+// RenderAlert 使用指定模板格式化提醒消息；name 为 TemplateCustom 时使用 custom 模板内容
+func RenderAlert(name, custom, lang string, results []*dragon.PatternResult, currentData *dragon.CurrentLotteryInfo) (string, error) {
+	if len(results) == 0 {
+		return "", nil
+	}
+
+	var tmpl *template.Template
+	if name == TemplateCustom {
+		var err error
+		if tmpl, err = parseCustomTemplate(custom); err != nil {
+			return "", err
+		}
+	} else if tmpl = builtinTemplates[name]; tmpl == nil {
+		tmpl = builtinTemplates[TemplateDetailed]
+	}
+
+	text, err := executeTemplate(tmpl, newAlertData(lang, results, currentData))
+	if err != nil {
+		return "", err
+	}
+
+	text = strings.TrimRight(text, "\n")
+	if strings.TrimSpace(text) == "" {
+		return "", errors.New(i18n.T(lang, "template.error.empty"))
+	}
+	return text, nil
+}
+
+// errMessageTooLong 模板输出超过 Telegram 单条消息的长度
+var errMessageTooLong = errors.New(i18n.T(i18n.Default, "template.error.too_long", maxMessageSize))
+
+// limitWriter 输出超过 maxMessageSize 字时返回错误，模板随即中止执行
+type limitWriter struct {
+	buf   bytes.Buffer
+	runes int
+}
+
+func (w *limitWriter) Write(p []byte) (int, error) {
+	w.runes += utf8.RuneCount(p)
+	if w.runes > maxMessageSize {
+		return 0, errMessageTooLong
+	}
+	return w.buf.Write(p)
+}
+
+// executeTemplate 执行模板，输出超过消息长度时中止
+func executeTemplate(tmpl *template.Template, data *AlertData) (string, error) {
+	var w limitWriter
+	if err := tmpl.Execute(&w, data); err != nil {
+		return "", err
+	}
+	return w.buf.String(), nil
+}
+
+// RenderChatAlert 使用群组选择的模板格式化提醒；自定义模板出错时退回 detailed 模板
+func RenderChatAlert(chatConfig *db.ChatConfig, results []*dragon.PatternResult, currentData *dragon.CurrentLotteryInfo) string {
+	text, err := RenderAlert(chatConfig.AlertTemplate, chatConfig.CustomTemplate, chatConfig.Language, results, currentData)
+	if err != nil {
+		log.Printf("[提醒模板] 群组:%d 模板%s执行失败，使用默认模板: %v", chatConfig.ChatID, chatConfig.AlertTemplate, err)
+		return FormatAlertMessage(chatConfig.Language, results, currentData)
+	}
+	return text
+}
+
+func parseCustomTemplate(text string) (*template.Template, error) {
+	customMu.Lock()
+	defer customMu.Unlock()
+
+	if cached, ok := customTemplates[text]; ok {
+		return cached, nil
+	}
+
+	tmpl, err := parseTemplate(i18n.Default, text)
+	if err != nil {
+		return nil, err
+	}
+
+	// 缓存满时整体清空，正在使用的模板下次发送时重新解析
+	if len(customTemplates) >= maxCachedTemplates {
+		customTemplates = make(map[string]*template.Template)
+	}
+	customTemplates[text] = tmpl
+	return tmpl, nil
+}
+
+// parseTemplate 解析自定义模板并检查结构，保证执行时间有上限；lang 为错误信息的语言
+func parseTemplate(lang, text string) (*template.Template, error) {
+	tmpl, err := template.New(TemplateCustom).Funcs(templateFuncs).Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	if len(tmpl.Templates()) > 1 {
+		return nil, errors.New(i18n.T(lang, "template.error.define"))
+	}
+	if err := checkTemplateNode(lang, tmpl.Tree.Root, 0); err != nil {
+		return nil, err
+	}
+	return tmpl, nil
+}
+
+// checkTemplateNode 检查模板结构：range 只能遍历模板数据中的字段（如 .Results、$g.Items）且嵌套不超过 maxRangeDepth 层，
+// 不能调用其他模板。遍历整数或多层嵌套会让模板长时间执行而不产生输出
+func checkTemplateNode(lang string, node parse.Node, depth int) error {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return nil
+		}
+		for _, child := range n.Nodes {
+			if err := checkTemplateNode(lang, child, depth); err != nil {
+				return err
+			}
+		}
+
+	case *parse.IfNode:
+		return checkBranch(lang, &n.BranchNode, depth)
+
+	case *parse.WithNode:
+		return checkBranch(lang, &n.BranchNode, depth)
+
+	case *parse.RangeNode:
+		if depth+1 > maxRangeDepth {
+			return errors.New(i18n.T(lang, "template.error.range_depth", maxRangeDepth))
+		}
+		if !rangesOverField(n.Pipe) {
+			return errors.New(i18n.T(lang, "template.error.range_field"))
+		}
+		return checkBranch(lang, &n.BranchNode, depth+1)
+
+	case *parse.TemplateNode:
+		return errors.New(i18n.T(lang, "template.error.template"))
+	}
+
+	return nil
+}
+
+func checkBranch(lang string, n *parse.BranchNode, depth int) error {
+	if err := checkTemplateNode(lang, n.List, depth); err != nil {
+		return err
+	}
+	return checkTemplateNode(lang, n.ElseList, depth)
+}
+
+// rangesOverField range 的对象是否为单独的数据字段
+func rangesOverField(pipe *parse.PipeNode) bool {
+	if pipe == nil || len(pipe.Cmds) != 1 || len(pipe.Cmds[0].Args) != 1 {
+		return false
+	}
+
+	switch arg := pipe.Cmds[0].Args[0].(type) {
+	case *parse.FieldNode:
+		return true
+	case *parse.VariableNode:
+		return len(arg.Ident) > 1
+	}
+	return false
+}
+
+// newAlertData 构建模板数据
+func newAlertData(lang string, results []*dragon.PatternResult, currentData *dragon.CurrentLotteryInfo) *AlertData {
+	data := &AlertData{
+		Lang:  i18n.Normalize(lang),
+		Qihao: results[0].CurrentQihao,
+		Total: len(results),
+	}
+
+	if currentData != nil {
+		data.Qihao = currentData.Qihao
+		data.Draw = &DrawData{
+			Qihao:   currentData.Qihao,
+			OpenNum: currentData.OpenNum,
+			Sum:     currentData.SumValue,
+			Size:    i18n.Value(lang, currentData.Size),
+			Parity:  i18n.Value(lang, currentData.Parity),
+		}
+	}
+
+	// 按期数降序
+	sorted := append([]*dragon.PatternResult(nil), results...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Count > sorted[j].Count })
+
+	for _, g := range alertGroups {
+		group := AlertGroup{Attribute: g.attribute, Name: attributeName(lang, g.attribute), Emoji: g.emoji}
+		for _, r := range sorted {
+			if r.AttributeType == g.attribute {
+				group.Items = append(group.Items, newAlertItem(lang, r))
+			}
+		}
+		if len(group.Items) > 0 {
+			data.Groups = append(data.Groups, group)
+		}
+	}
+
+	for _, r := range sorted {
+		data.Results = append(data.Results, newAlertItem(lang, r))
+	}
+
+	return data
+}
+
+func newAlertItem(lang string, r *dragon.PatternResult) AlertItem {
+	length, unit := displayLength(lang, r)
+
+	return AlertItem{
+		Attribute:     r.AttributeType,
+		AttributeName: attributeName(lang, r.AttributeType),
+		Pattern:       r.PatternType,
+		PatternName:   patternName(lang, r.PatternType),
+		Count:         r.Count,
+		Groups:        dragon.GroupCount(r.Count, r.PatternType),
+		Length:        length,
+		Unit:          unit,
+		Detail:        formatPatternDetail(lang, r),
+		StartQihao:    r.StartQihao,
+		CurrentQihao:  r.CurrentQihao,
+		Rarity:        r.Rarity,
+		Stars:         rarityStars(r.Rarity),
+		Odds:          formatOdds(r.Probability),
+		HistoryRuns:   r.HistoryRuns,
+		Percentile:    r.HistoryPercentile,
+	}
+}
+
+// ValidateTemplate 校验自定义模板：能够解析、用示例数据执行，输出不超过消息长度且 HTML 标签合法
+// 错误信息使用 lang 语言
+func ValidateTemplate(lang, text string) error {
+	if strings.TrimSpace(text) == "" {
+		return errors.New(i18n.T(lang, "template.error.blank"))
+	}
+	if len([]rune(text)) > maxTemplateSize {
+		return errors.New(i18n.T(lang, "template.error.size", maxTemplateSize))
+	}
+
+	tmpl, err := parseTemplate(lang, text)
+	if err != nil {
+		return errors.New(i18n.T(lang, "template.error.syntax", err))
+	}
+
+	var samples []*dragon.PatternResult
+	for _, rule := range dragon.DefaultRules {
+		samples = append(samples, sampleResult(rule.PatternType, rule.AttributeType, rule.Threshold+3, "20260101001"))
+	}
+	current := &dragon.CurrentLotteryInfo{Qihao: "20260101001", OpenNum: "3+5+8", SumValue: 16, Size: "大", Parity: "双"}
+
+	output, err := executeTemplate(tmpl, newAlertData(lang, samples, current))
+	if errors.Is(err, errMessageTooLong) {
+		return errors.New(i18n.T(lang, "template.error.sample_too_long", maxMessageSize))
+	}
+	if err != nil {
+		return errors.New(i18n.T(lang, "template.error.exec", err))
+	}
+
+	output = strings.TrimSpace(output)
+	if output == "" {
+		return errors.New(i18n.T(lang, "template.error.empty"))
+	}
+
+	return checkTelegramHTML(lang, output)
+}
+
+// Telegram 支持的 HTML 标签
+var telegramTags = map[string]bool{
+	"b": true, "strong": true, "i": true, "em": true, "u": true, "ins": true,
+	"s": true, "strike": true, "del": true, "code": true, "pre": true, "a": true,
+	"blockquote": true, "tg-spoiler": true, "span": true,
+}
+
+var tagPattern = regexp.MustCompile(`<(/?)([a-zA-Z-]+)[^>]*>`)
+
+// checkTelegramHTML 检查输出只使用 Telegram 支持的标签且正确闭合
+func checkTelegramHTML(lang, text string) error {
+	var stack []string
+	for _, m := range tagPattern.FindAllStringSubmatch(text, -1) {
+		name := strings.ToLower(m[2])
+		if !telegramTags[name] {
+			return errors.New(i18n.T(lang, "template.error.tag", name))
+		}
+
+		if m[1] == "" {
+			stack = append(stack, name)
+			continue
+		}
+		if len(stack) == 0 || stack[len(stack)-1] != name {
+			return errors.New(i18n.T(lang, "template.error.tag_close", name))
+		}
+		stack = stack[:len(stack)-1]
+	}
+
+	if len(stack) > 0 {
+		return errors.New(i18n.T(lang, "template.error.tag_open", stack[len(stack)-1]))
+	}
+	return nil
+}
+
+// handleTemplate 处理 /template 命令
+//
+//	/template                查看当前模板和用法
+//	/template compact        使用内置模板（detailed、compact、one-line）
+//	/template set <模板内容>  设置自定义模板（可换行）
+//	/template show           查看当前模板内容
+//	/template preview        按当前模板发送预览
+//	/template reset          恢复默认模板
+func handleTemplate(message *tgbotapi.Message) {
+	chatID := message.Chat.ID
+	if chatID > 0 {
+		lang := i18n.FromLanguageCode(message.From.LanguageCode)
+		send(chatID, tgbotapi.NewMessage(chatID, i18n.T(lang, "stats.group_only")))
+		return
+	}
+
+	// 子命令之后的内容（可能换行）都是模板内容
+	args := strings.TrimSpace(message.CommandArguments())
+	sub, body := args, ""
+	if i := strings.IndexAny(args, " \n"); i >= 0 {
+		sub, body = args[:i], strings.TrimSpace(args[i+1:])
+	}
+	sub = strings.ToLower(sub)
+
+	name, custom := chatTemplate(chatID)
+	lang := chatLanguage(chatID)
+
+	// 查看类操作所有成员都可以使用
+	switch sub {
+	case "":
+		send(chatID, tgbotapi.NewMessage(chatID, templateUsage(lang, name)))
+		return
+	case "show":
+		text := i18n.T(lang, "template.show", name, html.EscapeString(templateText(name, custom)))
+		msg := tgbotapi.NewMessage(chatID, text)
+		msg.ParseMode = "HTML"
+		send(chatID, msg)
+		return
+	}
+
+	if !isAdmin(chatID, message.From.ID) {
+		send(chatID, tgbotapi.NewMessage(chatID, i18n.T(lang, "admin_only")))
+		return
+	}
+
+	ensureChatConfig(chatID, message.From.LanguageCode)
+
+	switch sub {
+	case "preview":
+		sendPreview(chatID)
+		return
+
+	case "reset":
+		name, custom = TemplateDetailed, ""
+
+	case "set":
+		if err := ValidateTemplate(lang, body); err != nil {
+			send(chatID, tgbotapi.NewMessage(chatID, i18n.T(lang, "template.invalid", err)))
+			return
+		}
+		name, custom = TemplateCustom, body
+
+	case TemplateDetailed, TemplateCompact, TemplateOneLine:
+		name = sub
+
+	default:
+		send(chatID, tgbotapi.NewMessage(chatID, templateUsage(lang, name)))
+		return
+	}
+
+	_, err := db.WriteDB.Exec("UPDATE chat_configs SET alert_template = ?, custom_template = ? WHERE chat_id = ?", name, custom, chatID)
+	if err != nil {
+		log.Printf("[提醒模板] 群组:%d 保存失败: %v", chatID, err)
+		return
+	}
+
+	send(chatID, tgbotapi.NewMessage(chatID, i18n.T(lang, "template.switched", name)))
+}
+
+// chatTemplate 读取群组选择的模板
+func chatTemplate(chatID int64) (string, string) {
+	name := TemplateDetailed
+	var custom string
+	db.WriteDB.QueryRow("SELECT alert_template, COALESCE(custom_template, '') FROM chat_configs WHERE chat_id = ?", chatID).Scan(&name, &custom)
+	return name, custom
+}
+
+func templateText(name, custom string) string {
+	if name == TemplateCustom {
+		return custom
+	}
+	if text, ok := builtinTemplateText[name]; ok {
+		return text
+	}
+	return builtinTemplateText[TemplateDetailed]
+}
+
+func templateUsage(lang, current string) string {
+	return i18n.T(lang, "template.usage", current, strings.Join(TemplateNames, i18n.T(lang, "list.separator")))
+}
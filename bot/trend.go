@@ -0,0 +1,215 @@
+package bot
+
+import (
+	"dragon-alert-bot/i18n"
+	"dragon-alert-bot/lottery"
+	"dragon-alert-bot/road"
+	"fmt"
+	"html"
+	"log"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// /trend 每页显示的期数
+const (
+	trendDefault = 10
+	trendMin     = 5
+	trendMax     = 30
+	trendDepth   = 500 // 最多往前翻到的期数
+)
+
+// handleTrend 显示最近 N 期的走势：/trend [期数]
+func handleTrend(message *tgbotapi.Message) {
+	chatID := message.Chat.ID
+	lang := chatLanguage(chatID)
+	if chatID > 0 {
+		lang = i18n.FromLanguageCode(message.From.LanguageCode)
+	}
+
+	n := trendDefault
+	if arg := strings.TrimSpace(message.CommandArguments()); arg != "" {
+		v, err := strconv.Atoi(arg)
+		if err != nil || v < trendMin || v > trendMax {
+			send(chatID, tgbotapi.NewMessage(chatID, i18n.T(lang, "trend.usage", trendMin, trendMax)))
+			return
+		}
+		n = v
+	}
+
+	text, keyboard := trendPage(lang, n, 0, "")
+	msg := tgbotapi.NewMessage(chatID, text)
+	msg.ParseMode = "HTML"
+	msg.ReplyMarkup = keyboard
+	send(chatID, msg)
+}
+
+// handleTrendPage 处理走势翻页按钮，所有成员都可以翻页
+// 回调数据为 trend_<期数>_<页码>_<游标>：游标 b<期号> 表示该期之前的一页，a<期号> 表示该期之后的一页，没有游标时为最新一页
+// 按期号翻页，翻页期间有新开奖时不会重复或跳过
+func handleTrendPage(chatID int64, messageID int, lang string, args []string) {
+	if len(args) < 2 {
+		return
+	}
+	n, err1 := strconv.Atoi(args[0])
+	page, err2 := strconv.Atoi(args[1])
+	if err1 != nil || err2 != nil || n < trendMin || n > trendMax || page < 0 {
+		return
+	}
+
+	cursor := ""
+	if len(args) > 2 {
+		cursor = args[2]
+	}
+
+	text, keyboard := trendPage(lang, n, page, cursor)
+	msg := tgbotapi.NewEditMessageText(chatID, messageID, text)
+	msg.ParseMode = "HTML"
+	msg.ReplyMarkup = &keyboard
+	send(chatID, msg)
+}
+
+// trendPage 生成第 page 页（0 为最新）的走势表和翻页按钮，cursor 见 handleTrendPage
+func trendPage(lang string, n, page int, cursor string) (string, tgbotapi.InlineKeyboardMarkup) {
+	var draws []lottery.LotteryData
+	var err error
+	hasOlder := false
+
+	if page > 0 && len(cursor) > 1 && cursor[0] == 'a' {
+		// 往较新的方向翻：该期之后不足一页时显示最新一页
+		draws, err = modules.Monitor.GetHistoryAfterQihao(cursor[1:], n)
+		hasOlder = true
+		if err == nil && len(draws) < n {
+			draws, page = nil, 0
+		}
+	}
+
+	if draws == nil && err == nil {
+		before := ""
+		if page > 0 && len(cursor) > 1 && cursor[0] == 'b' {
+			before = cursor[1:]
+		} else {
+			page = 0
+		}
+
+		// 多取一期用于判断是否还有更早的数据
+		draws, err = modules.Monitor.GetHistoryBeforeQihao(before, n+1)
+		hasOlder = len(draws) > n
+		if len(draws) > n {
+			draws = draws[:n]
+		}
+	}
+	if err != nil {
+		log.Printf("[走势] 查询开奖数据失败: %v", err)
+	}
+	hasOlder = hasOlder && len(draws) > 0 && (page+1)*n < trendDepth
+
+	var text strings.Builder
+	text.WriteString(i18n.T(lang, "trend.title", i18n.Count(lang, i18n.UnitDraw, n), page+1) + "\n")
+	if len(draws) == 0 {
+		text.WriteString("\n" + i18n.T(lang, "road.empty"))
+	} else {
+		text.WriteString("<pre>" + html.EscapeString(formatTrendTable(lang, draws)) + "</pre>")
+	}
+
+	// 当前各属性的连续期数（与翻到哪一页无关）
+	if runs := trendRuns(lang); runs != "" {
+		text.WriteString("\n" + i18n.T(lang, "trend.runs") + "\n" + runs)
+	}
+
+	var row []tgbotapi.InlineKeyboardButton
+	if hasOlder {
+		older := fmt.Sprintf("trend_%d_%d_b%s", n, page+1, draws[len(draws)-1].Qihao)
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "trend.older"), older))
+	}
+	if page > 1 && len(draws) > 0 {
+		newer := fmt.Sprintf("trend_%d_%d_a%s", n, page-1, draws[0].Qihao)
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "trend.newer"), newer))
+	} else if page == 1 {
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "trend.newer"), fmt.Sprintf("trend_%d_0", n)))
+	}
+	row = append(row, tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "trend.latest"), fmt.Sprintf("trend_%d_0", n)))
+
+	return text.String(), tgbotapi.NewInlineKeyboardMarkup(row)
+}
+
+// formatTrendTable 按列对齐的走势表（从新到旧），中文按两个字符宽度对齐
+func formatTrendTable(lang string, draws []lottery.LotteryData) string {
+	table := [][]string{{
+		i18n.T(lang, "trend.qihao"),
+		i18n.T(lang, "trend.numbers"),
+		i18n.T(lang, "trend.sum"),
+		attributeName(lang, road.AttrSize),
+		attributeName(lang, road.AttrParity),
+		attributeName(lang, road.AttrCombo),
+	}}
+	for i := range draws {
+		attrs := draws[i].CalculateAttributes()
+		table = append(table, []string{
+			attrs.Qihao,
+			draws[i].OpenNum,
+			strconv.Itoa(attrs.SumValue),
+			i18n.Values(lang, attrs.Size),
+			i18n.Values(lang, attrs.Parity),
+			i18n.Values(lang, attrs.Size+attrs.Parity),
+		})
+	}
+
+	widths := make([]int, len(table[0]))
+	for _, cells := range table {
+		for i, cell := range cells {
+			if w := displayWidth(cell); w > widths[i] {
+				widths[i] = w
+			}
+		}
+	}
+
+	var b strings.Builder
+	for _, cells := range table {
+		for i, cell := range cells {
+			b.WriteString(cell)
+			if i < len(cells)-1 {
+				b.WriteString(strings.Repeat(" ", widths[i]-displayWidth(cell)+1))
+			}
+		}
+		b.WriteString("\n")
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// displayWidth 等宽字体下的显示宽度（中日韩字符占两格）
+func displayWidth(s string) int {
+	w := 0
+	for _, r := range s {
+		if r >= 0x2e80 {
+			w += 2
+		} else {
+			w++
+		}
+	}
+	return w
+}
+
+// trendRuns 各属性当前连续出现的值和期数（如"大小: 大 连续3期"）
+func trendRuns(lang string) string {
+	attrs := drawHistory()
+	if len(attrs) == 0 {
+		return ""
+	}
+
+	var lines []string
+	for _, attr := range []string{road.AttrSize, road.AttrParity, road.AttrCombo} {
+		values := road.Values(attrs, attr, len(attrs))
+		last := values[len(values)-1]
+		count := 0
+		for i := len(values) - 1; i >= 0 && values[i] == last; i-- {
+			count++
+		}
+
+		lines = append(lines, i18n.T(lang, "trend.run",
+			attributeName(lang, attr), roadValueName(lang, attr, last), i18n.Count(lang, i18n.UnitDraw, count)))
+	}
+	return strings.Join(lines, "\n")
+}
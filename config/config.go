@@ -16,6 +16,18 @@ type Config struct {
 
 	// 轮询间隔（秒）
 	PollInterval int
+
+	// 长龙跟踪写入日志（未落库的变更，崩溃后启动时重放）
+	TrackerJournal string
+
+	// 高可用模式：多实例通过 MySQL GET_LOCK 选举一个主节点负责开奖监测和提醒发送
+	HAMode         bool
+	LeaderLockName string
+
+	// Webhook 模式（高可用时推荐：所有实例都能处理 Telegram 更新）
+	// 为空时使用长轮询，高可用模式下只有主节点轮询
+	WebhookURL    string
+	WebhookListen string
 }
 
 type DatabaseConfig struct {
@@ -48,7 +60,12 @@ func Load() *Config {
 			Password: "04By0302",
 			Database: "t3bot",
 		},
-		PollInterval: 1,
+		PollInterval:   1,
+		TrackerJournal: "tracker_journal.jsonl",
+		HAMode:         false,
+		LeaderLockName: "dragon_alert_bot_leader",
+		WebhookURL:     "",
+		WebhookListen:  ":8443",
 	}
 }
 
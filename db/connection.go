@@ -15,9 +15,22 @@ var (
 )
 
 func InitDB(cfg *config.Config) error {
+	if err := InitReadDB(cfg); err != nil {
+		return err
+	}
+
+	if err := InitWriteDB(cfg); err != nil {
+		return err
+	}
+
+	// 初始化表结构
+	return InitTables()
+}
+
+// InitReadDB 初始化只读数据库（开奖数据）
+func InitReadDB(cfg *config.Config) error {
 	var err error
 
-	// 初始化只读数据库
 	ReadDB, err = sql.Open("mysql", cfg.ReadDB.DSN())
 	if err != nil {
 		return err
@@ -32,7 +45,13 @@ func InitDB(cfg *config.Config) error {
 	}
 	log.Printf("只读数据库连接成功 (%s)", cfg.ReadDB.Database)
 
-	// 初始化读写数据库
+	return nil
+}
+
+// InitWriteDB 初始化读写数据库（用户数据）
+func InitWriteDB(cfg *config.Config) error {
+	var err error
+
 	WriteDB, err = sql.Open("mysql", cfg.WriteDB.DSN())
 	if err != nil {
 		return err
@@ -47,11 +66,6 @@ func InitDB(cfg *config.Config) error {
 	}
 	log.Printf("读写数据库连接成功 (%s)", cfg.WriteDB.Database)
 
-	// 初始化表结构
-	if err = InitTables(); err != nil {
-		return err
-	}
-
 	return nil
 }
 
@@ -1,6 +1,7 @@
 package db
 
 import (
+	"fmt"
 	"log"
 )
 
@@ -10,6 +11,30 @@ func InitTables() error {
 		`CREATE TABLE IF NOT EXISTS chat_configs (
 			chat_id BIGINT PRIMARY KEY,
 			enabled BOOLEAN DEFAULT TRUE,
+			disabled_reason VARCHAR(20) DEFAULT '',
+			permission_failures INT DEFAULT 0,
+			last_send_error VARCHAR(255) DEFAULT '',
+			last_send_error_at DATETIME NULL,
+			archived_at DATETIME NULL,
+			live_board BOOLEAN DEFAULT FALSE,
+			live_board_message_id INT DEFAULT 0,
+			cleanup_mode VARCHAR(20) DEFAULT 'none',
+			cleanup_minutes INT DEFAULT 30,
+			timezone VARCHAR(64) DEFAULT 'Asia/Shanghai',
+			active_days INT DEFAULT 127,
+			window_start INT DEFAULT 0,
+			window_end INT DEFAULT 24,
+			quiet_policy VARCHAR(20) DEFAULT 'drop',
+			quiet_critical INT DEFAULT 10,
+			alert_template VARCHAR(20) DEFAULT 'detailed',
+			custom_template TEXT,
+			language VARCHAR(8) DEFAULT '',
+			delivery_mode VARCHAR(20) DEFAULT 'immediate',
+			digest_draws INT DEFAULT 0,
+			digest_minutes INT DEFAULT 60,
+			digest_draw_count INT DEFAULT 0,
+			last_digest_at DATETIME NULL,
+			road_attach BOOLEAN DEFAULT FALSE,
 			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
 			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
 		) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4`,
@@ -21,6 +46,7 @@ func InitTables() error {
 			pattern_type VARCHAR(20) NOT NULL,
 			attribute_type VARCHAR(20) NOT NULL,
 			threshold INT DEFAULT 4,
+			rarity_threshold INT DEFAULT 0,
 			enabled BOOLEAN DEFAULT TRUE,
 			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
 			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
@@ -28,10 +54,29 @@ func InitTables() error {
 			INDEX idx_chat_id (chat_id)
 		) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4`,
 
-		// 长龙提醒记录表
+		// 全局长龙登记表（每条实际出现的长龙只记录一次）
+		`CREATE TABLE IF NOT EXISTS dragons (
+			id BIGINT PRIMARY KEY AUTO_INCREMENT,
+			pattern_type VARCHAR(20) NOT NULL,
+			attribute_type VARCHAR(20) NOT NULL,
+			start_qihao VARCHAR(20) NOT NULL,
+			current_qihao VARCHAR(20) NOT NULL,
+			end_qihao VARCHAR(20) DEFAULT '',
+			count INT NOT NULL,
+			pattern_detail TEXT,
+			status VARCHAR(20) DEFAULT 'active',
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+			ended_at DATETIME NULL,
+			UNIQUE KEY unique_dragon (pattern_type, attribute_type, start_qihao),
+			INDEX idx_status (status)
+		) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4`,
+
+		// 长龙提醒记录表（群组维度，dragon_id 引用 dragons 表）
 		`CREATE TABLE IF NOT EXISTS dragon_alerts (
 			id BIGINT PRIMARY KEY AUTO_INCREMENT,
 			chat_id BIGINT NOT NULL,
+			dragon_id BIGINT NULL,
 			pattern_type VARCHAR(20) NOT NULL,
 			attribute_type VARCHAR(20) NOT NULL,
 			start_qihao VARCHAR(20) NOT NULL,
@@ -42,7 +87,27 @@ func InitTables() error {
 			status VARCHAR(20) DEFAULT 'active',
 			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
 			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+			UNIQUE KEY unique_delivery (chat_id, dragon_id),
 			INDEX idx_chat_status (chat_id, status),
+			INDEX idx_status (status),
+			INDEX idx_dragon (dragon_id)
+		) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4`,
+
+		// 长龙纪录表（全局，每条长龙一行）
+		`CREATE TABLE IF NOT EXISTS dragon_records (
+			id BIGINT PRIMARY KEY AUTO_INCREMENT,
+			pattern_type VARCHAR(20) NOT NULL,
+			attribute_type VARCHAR(20) NOT NULL,
+			start_qihao VARCHAR(20) NOT NULL,
+			end_qihao VARCHAR(20) NOT NULL,
+			count INT NOT NULL,
+			pattern_detail TEXT,
+			status VARCHAR(20) DEFAULT 'active',
+			announced_level INT DEFAULT 0,
+			started_at DATETIME NOT NULL,
+			last_seen_at DATETIME NOT NULL,
+			UNIQUE KEY unique_run (pattern_type, attribute_type, start_qihao),
+			INDEX idx_type_seen (pattern_type, attribute_type, last_seen_at),
 			INDEX idx_status (status)
 		) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4`,
 
@@ -52,6 +117,107 @@ func InitTables() error {
 			last_qihao VARCHAR(20) DEFAULT '',
 			last_check_time DATETIME DEFAULT CURRENT_TIMESTAMP
 		) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4`,
+
+		// 已发送的提醒消息（用于删除旧提醒和回复串联）
+		`CREATE TABLE IF NOT EXISTS alert_messages (
+			id BIGINT PRIMARY KEY AUTO_INCREMENT,
+			chat_id BIGINT NOT NULL,
+			message_id INT NOT NULL,
+			pattern_type VARCHAR(20) NOT NULL,
+			attribute_type VARCHAR(20) NOT NULL,
+			start_qihao VARCHAR(20) NOT NULL,
+			kind VARCHAR(20) DEFAULT 'alert',
+			sent_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			deleted_at DATETIME NULL,
+			INDEX idx_chat_dragon (chat_id, pattern_type, attribute_type, start_qihao),
+			INDEX idx_chat_message (chat_id, message_id),
+			INDEX idx_sent (sent_at)
+		) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4`,
+
+		// 静默时段暂存的长龙（提醒时段开始时汇总发送）
+		`CREATE TABLE IF NOT EXISTS quiet_digests (
+			chat_id BIGINT NOT NULL,
+			pattern_type VARCHAR(20) NOT NULL,
+			attribute_type VARCHAR(20) NOT NULL,
+			start_qihao VARCHAR(20) NOT NULL,
+			current_qihao VARCHAR(20) NOT NULL,
+			count INT NOT NULL,
+			rarity INT DEFAULT 0,
+			queued_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (chat_id, pattern_type, attribute_type, start_qihao)
+		) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4`,
+
+		// 汇总模式下等待汇总的长龙（每个群组每条长龙一行，汇总发送后清空）
+		`CREATE TABLE IF NOT EXISTS digest_entries (
+			chat_id BIGINT NOT NULL,
+			pattern_type VARCHAR(20) NOT NULL,
+			attribute_type VARCHAR(20) NOT NULL,
+			start_qihao VARCHAR(20) NOT NULL,
+			current_qihao VARCHAR(20) NOT NULL,
+			count INT NOT NULL,
+			rarity INT DEFAULT 0,
+			status VARCHAR(20) DEFAULT 'active',
+			queued_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (chat_id, pattern_type, attribute_type, start_qihao),
+			INDEX idx_dragon (pattern_type, attribute_type, start_qihao)
+		) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4`,
+
+		// 提醒发送日志（每次发送一行，成功或失败）
+		`CREATE TABLE IF NOT EXISTS alert_deliveries (
+			id BIGINT AUTO_INCREMENT PRIMARY KEY,
+			chat_id BIGINT NOT NULL,
+			kind VARCHAR(20) NOT NULL,
+			qihao VARCHAR(20) DEFAULT '',
+			dragons VARCHAR(1000) DEFAULT '',
+			dragon_count INT DEFAULT 0,
+			message_id INT DEFAULT 0,
+			latency_ms INT NULL,
+			status VARCHAR(20) NOT NULL,
+			error VARCHAR(255) DEFAULT '',
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			INDEX idx_chat (chat_id, created_at),
+			INDEX idx_created (created_at)
+		) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4`,
+
+		// 每个群组每天的提醒统计（由发送日志累加）
+		`CREATE TABLE IF NOT EXISTS alert_daily_stats (
+			chat_id BIGINT NOT NULL,
+			stat_date DATE NOT NULL,
+			sent INT DEFAULT 0,
+			failed INT DEFAULT 0,
+			dragons INT DEFAULT 0,
+			latency_total BIGINT DEFAULT 0,
+			latency_count INT DEFAULT 0,
+			latency_max INT DEFAULT 0,
+			PRIMARY KEY (chat_id, stat_date),
+			INDEX idx_date (stat_date)
+		) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4`,
+
+		// 等待管理员回复的阈值输入提示（每个群组每个管理员最多一条，超时后失效）
+		`CREATE TABLE IF NOT EXISTS threshold_prompts (
+			chat_id BIGINT NOT NULL,
+			user_id BIGINT NOT NULL,
+			pattern_type VARCHAR(20) NOT NULL,
+			attribute_type VARCHAR(20) NOT NULL,
+			menu_message_id INT NOT NULL,
+			prompt_message_id INT DEFAULT 0,
+			expires_at DATETIME NOT NULL,
+			PRIMARY KEY (chat_id, user_id),
+			INDEX idx_expires (expires_at)
+		) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4`,
+
+		// 发送失败的消息（死信）
+		`CREATE TABLE IF NOT EXISTS send_dead_letters (
+			id BIGINT AUTO_INCREMENT PRIMARY KEY,
+			chat_id BIGINT NOT NULL,
+			method VARCHAR(50) NOT NULL,
+			payload TEXT,
+			error VARCHAR(500) DEFAULT '',
+			attempts INT DEFAULT 0,
+			priority INT DEFAULT 0,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			INDEX idx_chat (chat_id, created_at)
+		) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4`,
 	}
 
 	for _, table := range tables {
@@ -60,6 +226,63 @@ func InitTables() error {
 		}
 	}
 
+	// 为已存在的旧表补充新增字段
+	columns := []struct {
+		table      string
+		column     string
+		definition string
+	}{
+		{"dragon_rules", "rarity_threshold", "INT DEFAULT 0"},
+		{"dragon_alerts", "dragon_id", "BIGINT NULL AFTER chat_id"},
+		{"chat_configs", "disabled_reason", "VARCHAR(20) DEFAULT '' AFTER enabled"},
+		{"chat_configs", "permission_failures", "INT DEFAULT 0 AFTER disabled_reason"},
+		{"chat_configs", "last_send_error", "VARCHAR(255) DEFAULT '' AFTER permission_failures"},
+		{"chat_configs", "last_send_error_at", "DATETIME NULL AFTER last_send_error"},
+		{"chat_configs", "archived_at", "DATETIME NULL AFTER last_send_error_at"},
+		{"chat_configs", "live_board", "BOOLEAN DEFAULT FALSE AFTER archived_at"},
+		{"chat_configs", "live_board_message_id", "INT DEFAULT 0 AFTER live_board"},
+		{"chat_configs", "cleanup_mode", "VARCHAR(20) DEFAULT 'none' AFTER live_board_message_id"},
+		{"chat_configs", "cleanup_minutes", "INT DEFAULT 30 AFTER cleanup_mode"},
+		{"chat_configs", "timezone", "VARCHAR(64) DEFAULT 'Asia/Shanghai' AFTER cleanup_minutes"},
+		{"chat_configs", "active_days", "INT DEFAULT 127 AFTER timezone"},
+		{"chat_configs", "window_start", "INT DEFAULT 0 AFTER active_days"},
+		{"chat_configs", "window_end", "INT DEFAULT 24 AFTER window_start"},
+		{"chat_configs", "quiet_policy", "VARCHAR(20) DEFAULT 'drop' AFTER window_end"},
+		{"chat_configs", "quiet_critical", "INT DEFAULT 10 AFTER quiet_policy"},
+		{"chat_configs", "alert_template", "VARCHAR(20) DEFAULT 'detailed' AFTER quiet_critical"},
+		{"chat_configs", "custom_template", "TEXT AFTER alert_template"},
+		{"chat_configs", "language", "VARCHAR(8) DEFAULT '' AFTER custom_template"},
+		{"chat_configs", "delivery_mode", "VARCHAR(20) DEFAULT 'immediate' AFTER language"},
+		{"chat_configs", "digest_draws", "INT DEFAULT 0 AFTER delivery_mode"},
+		{"chat_configs", "digest_minutes", "INT DEFAULT 60 AFTER digest_draws"},
+		{"chat_configs", "digest_draw_count", "INT DEFAULT 0 AFTER digest_minutes"},
+		{"chat_configs", "last_digest_at", "DATETIME NULL AFTER digest_draw_count"},
+		{"chat_configs", "road_attach", "BOOLEAN DEFAULT FALSE AFTER last_digest_at"},
+		{"alert_messages", "kind", "VARCHAR(20) DEFAULT 'alert' AFTER start_qihao"},
+	}
+
+	for _, c := range columns {
+		if err := addColumnIfMissing(c.table, c.column, c.definition); err != nil {
+			return err
+		}
+	}
+
+	// 为已存在的旧表补充新增索引
+	indexes := []struct {
+		table      string
+		index      string
+		definition string
+	}{
+		{"dragon_alerts", "unique_delivery", "UNIQUE KEY unique_delivery (chat_id, dragon_id)"},
+		{"dragon_alerts", "idx_dragon", "INDEX idx_dragon (dragon_id)"},
+	}
+
+	for _, idx := range indexes {
+		if err := addIndexIfMissing(idx.table, idx.index, idx.definition); err != nil {
+			return err
+		}
+	}
+
 	log.Println("数据库表初始化完成")
 
 	// 初始化检查状态
@@ -70,5 +293,52 @@ func InitTables() error {
 	WriteDB.Exec("DELETE FROM dragon_rules WHERE chat_id > 0")
 	WriteDB.Exec("DELETE FROM dragon_alerts WHERE chat_id > 0")
 
+	// 旧版本按群组记录的活跃长龙没有关联全局长龙，直接结束
+	WriteDB.Exec("UPDATE dragon_alerts SET status = 'ended' WHERE status = 'active' AND dragon_id IS NULL")
+
 	return nil
 }
+
+// addColumnIfMissing 字段不存在时添加字段（MySQL 不支持 ADD COLUMN IF NOT EXISTS）
+func addColumnIfMissing(table, column, definition string) error {
+	var exists bool
+	err := WriteDB.QueryRow(`
+		SELECT EXISTS(SELECT 1 FROM information_schema.COLUMNS 
+		WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ? AND COLUMN_NAME = ?)
+	`, table, column).Scan(&exists)
+	if err != nil {
+		return err
+	}
+
+	if exists {
+		return nil
+	}
+
+	_, err = WriteDB.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, definition))
+	if err == nil {
+		log.Printf("[数据库升级] %s 表新增字段 %s", table, column)
+	}
+	return err
+}
+
+// addIndexIfMissing 索引不存在时添加索引
+func addIndexIfMissing(table, index, definition string) error {
+	var exists bool
+	err := WriteDB.QueryRow(`
+		SELECT EXISTS(SELECT 1 FROM information_schema.STATISTICS 
+		WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ? AND INDEX_NAME = ?)
+	`, table, index).Scan(&exists)
+	if err != nil {
+		return err
+	}
+
+	if exists {
+		return nil
+	}
+
+	_, err = WriteDB.Exec(fmt.Sprintf("ALTER TABLE %s ADD %s", table, definition))
+	if err == nil {
+		log.Printf("[数据库升级] %s 表新增索引 %s", table, index)
+	}
+	return err
+}
@@ -6,28 +6,69 @@ import (
 
 // ChatConfig 群组配置
 type ChatConfig struct {
-	ChatID    int64     `db:"chat_id"`
-	Enabled   bool      `db:"enabled"`
-	CreatedAt time.Time `db:"created_at"`
-	UpdatedAt time.Time `db:"updated_at"`
+	ChatID             int64      `db:"chat_id"`
+	Enabled            bool       `db:"enabled"`
+	DisabledReason     string     `db:"disabled_reason"`     // 自动停用原因：kicked, not_found, permission
+	PermissionFailures int        `db:"permission_failures"` // 连续因权限不足发送失败的次数
+	LastSendError      string     `db:"last_send_error"`
+	LastSendErrorAt    *time.Time `db:"last_send_error_at"`
+	ArchivedAt         *time.Time `db:"archived_at"`           // 机器人被移出群组后归档，重新加入时恢复
+	LiveBoard          bool       `db:"live_board"`            // 实时榜单模式：每期编辑同一条置顶消息代替单独提醒
+	LiveBoardMessageID int        `db:"live_board_message_id"` // 实时榜单消息ID，0 表示尚未发送
+	CleanupMode        string     `db:"cleanup_mode"`          // 旧提醒清理方式：none, previous, expire, thread
+	CleanupMinutes     int        `db:"cleanup_minutes"`       // expire 模式下提醒保留的分钟数
+	Timezone           string     `db:"timezone"`              // 提醒时段使用的时区
+	ActiveDays         int        `db:"active_days"`           // 提醒的星期掩码（第 n 位为 time.Weekday(n)）
+	WindowStart        int        `db:"window_start"`          // 每天提醒开始的小时（0-23）
+	WindowEnd          int        `db:"window_end"`            // 每天提醒结束的小时（1-24），小于开始时表示跨午夜
+	QuietPolicy        string     `db:"quiet_policy"`          // 静默时段处理方式：drop, digest, critical
+	QuietCritical      int        `db:"quiet_critical"`        // critical 方式下仍然提醒的最小组数
+	AlertTemplate      string     `db:"alert_template"`        // 提醒模板：detailed, compact, one-line, custom
+	CustomTemplate     string     `db:"custom_template"`       // 自定义模板内容（text/template）
+	Language           string     `db:"language"`              // 界面和提醒语言：zh-CN, zh-TW, en，空表示未设置（使用简体中文）
+	DeliveryMode       string     `db:"delivery_mode"`         // 提醒方式：immediate 逐条提醒, digest 定时汇总, both 两者都发送
+	DigestDraws        int        `db:"digest_draws"`          // 每隔多少期发送一次汇总，0 表示按时间间隔
+	DigestMinutes      int        `db:"digest_minutes"`        // digest_draws 为 0 时每隔多少分钟发送一次汇总
+	DigestDrawCount    int        `db:"digest_draw_count"`     // 上次汇总后经过的期数
+	LastDigestAt       *time.Time `db:"last_digest_at"`
+	RoadAttach         bool       `db:"road_attach"` // 提醒后附带路单图片
+	CreatedAt          time.Time  `db:"created_at"`
+	UpdatedAt          time.Time  `db:"updated_at"`
 }
 
 // DragonRule 长龙规则配置
 type DragonRule struct {
+	ID              int64     `db:"id"`
+	ChatID          int64     `db:"chat_id"`
+	PatternType     string    `db:"pattern_type"`   // a, ab, abb, ab_ac, ab_cd
+	AttributeType   string    `db:"attribute_type"` // size, parity, sum, size_parity
+	Threshold       int       `db:"threshold"`
+	RarityThreshold int       `db:"rarity_threshold"` // 大于0时按稀有度触发（0-100）
+	Enabled         bool      `db:"enabled"`
+	CreatedAt       time.Time `db:"created_at"`
+	UpdatedAt       time.Time `db:"updated_at"`
+}
+
+// Dragon 全局长龙登记（每条实际出现的长龙一行）
+type Dragon struct {
 	ID            int64     `db:"id"`
-	ChatID        int64     `db:"chat_id"`
-	PatternType   string    `db:"pattern_type"`   // a, ab, abb, ab_ac, ab_cd
-	AttributeType string    `db:"attribute_type"` // size, parity, sum, size_parity
-	Threshold     int       `db:"threshold"`
-	Enabled       bool      `db:"enabled"`
+	PatternType   string    `db:"pattern_type"`
+	AttributeType string    `db:"attribute_type"`
+	StartQihao    string    `db:"start_qihao"`
+	CurrentQihao  string    `db:"current_qihao"`
+	EndQihao      string    `db:"end_qihao"`
+	Count         int       `db:"count"`
+	PatternDetail string    `db:"pattern_detail"`
+	Status        string    `db:"status"` // active, ended
 	CreatedAt     time.Time `db:"created_at"`
 	UpdatedAt     time.Time `db:"updated_at"`
 }
 
-// DragonAlert 长龙提醒记录
+// DragonAlert 长龙提醒记录（群组维度，引用全局长龙）
 type DragonAlert struct {
 	ID             int64     `db:"id"`
 	ChatID         int64     `db:"chat_id"`
+	DragonID       int64     `db:"dragon_id"`
 	PatternType    string    `db:"pattern_type"`
 	AttributeType  string    `db:"attribute_type"`
 	StartQihao     string    `db:"start_qihao"`
@@ -40,6 +81,21 @@ type DragonAlert struct {
 	UpdatedAt      time.Time `db:"updated_at"`
 }
 
+// DragonRecord 长龙纪录（全局）
+type DragonRecord struct {
+	ID             int64     `db:"id"`
+	PatternType    string    `db:"pattern_type"`
+	AttributeType  string    `db:"attribute_type"`
+	StartQihao     string    `db:"start_qihao"`
+	EndQihao       string    `db:"end_qihao"`
+	Count          int       `db:"count"`
+	PatternDetail  string    `db:"pattern_detail"`
+	Status         string    `db:"status"`          // active, ended
+	AnnouncedLevel int       `db:"announced_level"` // 已提醒的最高纪录级别
+	StartedAt      time.Time `db:"started_at"`
+	LastSeenAt     time.Time `db:"last_seen_at"`
+}
+
 // LotteryCheckState 数据检查状态
 type LotteryCheckState struct {
 	ID            int       `db:"id"`
@@ -47,5 +103,77 @@ type LotteryCheckState struct {
 	LastCheckTime time.Time `db:"last_check_time"`
 }
 
+// AlertMessage 已发送的提醒消息（每条消息中的每条长龙一行，用于删除旧提醒和回复串联）
+type AlertMessage struct {
+	ID            int64      `db:"id"`
+	ChatID        int64      `db:"chat_id"`
+	MessageID     int        `db:"message_id"`
+	PatternType   string     `db:"pattern_type"`
+	AttributeType string     `db:"attribute_type"`
+	StartQihao    string     `db:"start_qihao"`
+	SentAt        time.Time  `db:"sent_at"`
+	DeletedAt     *time.Time `db:"deleted_at"`
+}
+
+// QuietDigest 静默时段内暂存的长龙，提醒时段开始时汇总发送（每个群组每条长龙一行）
+type QuietDigest struct {
+	ChatID        int64     `db:"chat_id"`
+	PatternType   string    `db:"pattern_type"`
+	AttributeType string    `db:"attribute_type"`
+	StartQihao    string    `db:"start_qihao"`
+	CurrentQihao  string    `db:"current_qihao"`
+	Count         int       `db:"count"`
+	Rarity        int       `db:"rarity"`
+	QueuedAt      time.Time `db:"queued_at"`
+}
 
+// DigestEntry 汇总模式下等待汇总的长龙（每个群组每条长龙一行）
+type DigestEntry struct {
+	ChatID        int64     `db:"chat_id"`
+	PatternType   string    `db:"pattern_type"`
+	AttributeType string    `db:"attribute_type"`
+	StartQihao    string    `db:"start_qihao"`
+	CurrentQihao  string    `db:"current_qihao"`
+	Count         int       `db:"count"`
+	Rarity        int       `db:"rarity"`
+	Status        string    `db:"status"` // active, ended
+	QueuedAt      time.Time `db:"queued_at"`
+}
 
+// AlertDelivery 提醒发送日志（每次发送一行）
+type AlertDelivery struct {
+	ID          int64     `db:"id"`
+	ChatID      int64     `db:"chat_id"`
+	Kind        string    `db:"kind"`    // alert, record, digest
+	Qihao       string    `db:"qihao"`   // 触发发送的期号
+	Dragons     string    `db:"dragons"` // 包含的长龙（属性/格式/起始期号:期数，空格分隔）
+	DragonCount int       `db:"dragon_count"`
+	MessageID   int       `db:"message_id"`
+	LatencyMs   *int      `db:"latency_ms"` // 从开奖时间到发送完成的毫秒数，没有开奖时间时为空
+	Status      string    `db:"status"`     // sent, failed
+	Error       string    `db:"error"`
+	CreatedAt   time.Time `db:"created_at"`
+}
+
+// AlertDailyStats 群组每天的提醒统计
+type AlertDailyStats struct {
+	ChatID       int64     `db:"chat_id"`
+	StatDate     time.Time `db:"stat_date"`
+	Sent         int       `db:"sent"`
+	Failed       int       `db:"failed"`
+	Dragons      int       `db:"dragons"`
+	LatencyTotal int64     `db:"latency_total"`
+	LatencyCount int       `db:"latency_count"`
+	LatencyMax   int       `db:"latency_max"`
+}
+
+// ThresholdPrompt 等待管理员回复的阈值输入提示
+type ThresholdPrompt struct {
+	ChatID          int64     `db:"chat_id"`
+	UserID          int64     `db:"user_id"`
+	PatternType     string    `db:"pattern_type"`
+	AttributeType   string    `db:"attribute_type"`
+	MenuMessageID   int       `db:"menu_message_id"`   // 回复后要刷新的规则菜单
+	PromptMessageID int       `db:"prompt_message_id"` // ForceReply 提示消息，只接受对它的回复
+	ExpiresAt       time.Time `db:"expires_at"`
+}
@@ -3,10 +3,15 @@ package dragon
 import (
 	"dragon-alert-bot/db"
 	"dragon-alert-bot/lottery"
+	"sync"
 )
 
 type Analyzer struct {
 	monitor *lottery.Monitor
+
+	mu      sync.RWMutex
+	attrs   []lottery.Attributes // 最近一次分析使用的属性列表（从旧到新）
+	history RunHistory           // attrs 中已结束的长龙长度（每期统计一次）
 }
 
 func NewAnalyzer(monitor *lottery.Monitor) *Analyzer {
@@ -18,22 +23,48 @@ func NewAnalyzer(monitor *lottery.Monitor) *Analyzer {
 // Analyze 分析长龙
 func (a *Analyzer) Analyze(newData *lottery.LotteryData) []*PatternResult {
 	// 获取历史数据（最近500期，足够检测长龙）
-	historyData, err := a.monitor.GetHistoryData(500)
-	if err != nil {
+	attrs, err := a.LoadAttrs(500)
+	if err != nil || len(attrs) == 0 {
 		return nil
 	}
 
-	if len(historyData) == 0 {
-		return nil
+	// 现在attrs是从旧到新排列，attrs[0]是最老的，attrs[len-1]是最新的
+	history := BuildRunHistory(attrs)
+	a.mu.Lock()
+	a.attrs = attrs
+	a.history = history
+	a.mu.Unlock()
+
+	results := DetectPatterns(attrs)
+
+	// 计算稀有度
+	ScoreRarity(results, history)
+
+	return results
+}
+
+// LoadAttrs 读取最近 limit 期开奖并转换为属性列表（从旧到新）
+func (a *Analyzer) LoadAttrs(limit int) ([]lottery.Attributes, error) {
+	historyData, err := a.monitor.GetHistoryData(limit)
+	if err != nil {
+		return nil, err
 	}
 
-	// 转换为属性列表（注意：数据库返回的是从新到旧，需要反转为从旧到新）
-	var attrs []lottery.Attributes
+	// 注意：数据库返回的是从新到旧，需要反转为从旧到新
+	attrs := make([]lottery.Attributes, 0, len(historyData))
 	for i := len(historyData) - 1; i >= 0; i-- {
 		attrs = append(attrs, historyData[i].CalculateAttributes())
 	}
 
-	// 现在attrs是从旧到新排列，attrs[0]是最老的，attrs[len-1]是最新的
+	return attrs, nil
+}
+
+// DetectPatterns 对属性列表（从旧到新）执行长龙检测
+// 每个单属性只保留最长的格式，不计算稀有度
+func DetectPatterns(attrs []lottery.Attributes) []*PatternResult {
+	if len(attrs) == 0 {
+		return nil
+	}
 
 	// 检测所有模式（使用最小阈值进行检测）
 	var results []*PatternResult
@@ -83,6 +114,55 @@ func (a *Analyzer) Analyze(newData *lottery.LotteryData) []*PatternResult {
 	return results
 }
 
+// History 返回最近一次分析使用的属性列表（从旧到新）
+func (a *Analyzer) History() []lottery.Attributes {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	return a.attrs
+}
+
+// Snapshot 返回最近一次分析使用的属性列表和其中已结束的长龙长度
+func (a *Analyzer) Snapshot() ([]lottery.Attributes, RunHistory) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	return a.attrs, a.history
+}
+
+// CurrentRuns 返回最近一次分析时所有正在进行的模式（不做同属性去重）
+func (a *Analyzer) CurrentRuns() []*PatternResult {
+	attrs, history := a.Snapshot()
+
+	results := DetectAllRuns(attrs)
+	ScoreRarity(results, history)
+	return results
+}
+
+// DetectAllRuns 检测所有属性、所有格式当前正在进行的模式
+func DetectAllRuns(attrs []lottery.Attributes) []*PatternResult {
+	if len(attrs) == 0 {
+		return nil
+	}
+
+	var results []*PatternResult
+	for _, attrType := range []string{"size", "parity", "sum"} {
+		for _, patternType := range []string{"a", "ab", "abb"} {
+			if result := checkPattern(attrs, patternType, attrType); result.Matched {
+				results = append(results, result)
+			}
+		}
+	}
+
+	for _, patternType := range []string{"ab_ac", "ab_cd", "abab"} {
+		if result := checkPattern(attrs, patternType, "size_parity"); result.Matched {
+			results = append(results, result)
+		}
+	}
+
+	return results
+}
+
 // GetActiveChats 获取所有启用的群组
 func (a *Analyzer) GetActiveChats() ([]int64, error) {
 	rows, err := db.WriteDB.Query("SELECT chat_id FROM chat_configs WHERE enabled = TRUE")
@@ -103,10 +183,30 @@ func (a *Analyzer) GetActiveChats() ([]int64, error) {
 	return chatIDs, nil
 }
 
+// GetChatConfig 获取群组配置
+func (a *Analyzer) GetChatConfig(chatID int64) (*db.ChatConfig, error) {
+	cfg := &db.ChatConfig{ChatID: chatID}
+	err := db.WriteDB.QueryRow(`
+		SELECT enabled, live_board, live_board_message_id, cleanup_mode, cleanup_minutes,
+			timezone, active_days, window_start, window_end, quiet_policy, quiet_critical,
+			alert_template, COALESCE(custom_template, ''), language,
+			delivery_mode, digest_draws, digest_minutes, digest_draw_count, last_digest_at, road_attach
+		FROM chat_configs
+		WHERE chat_id = ?
+	`, chatID).Scan(&cfg.Enabled, &cfg.LiveBoard, &cfg.LiveBoardMessageID, &cfg.CleanupMode, &cfg.CleanupMinutes,
+		&cfg.Timezone, &cfg.ActiveDays, &cfg.WindowStart, &cfg.WindowEnd, &cfg.QuietPolicy, &cfg.QuietCritical,
+		&cfg.AlertTemplate, &cfg.CustomTemplate, &cfg.Language,
+		&cfg.DeliveryMode, &cfg.DigestDraws, &cfg.DigestMinutes, &cfg.DigestDrawCount, &cfg.LastDigestAt, &cfg.RoadAttach)
+	if err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
 // GetChatRules 获取群组的规则配置
 func (a *Analyzer) GetChatRules(chatID int64) ([]db.DragonRule, error) {
 	rows, err := db.WriteDB.Query(`
-		SELECT id, chat_id, pattern_type, attribute_type, threshold, enabled, created_at, updated_at 
+		SELECT id, chat_id, pattern_type, attribute_type, threshold, rarity_threshold, enabled, created_at, updated_at 
 		FROM dragon_rules 
 		WHERE chat_id = ? AND enabled = TRUE
 	`, chatID)
@@ -119,7 +219,7 @@ func (a *Analyzer) GetChatRules(chatID int64) ([]db.DragonRule, error) {
 	for rows.Next() {
 		var rule db.DragonRule
 		err := rows.Scan(&rule.ID, &rule.ChatID, &rule.PatternType, &rule.AttributeType,
-			&rule.Threshold, &rule.Enabled, &rule.CreatedAt, &rule.UpdatedAt)
+			&rule.Threshold, &rule.RarityThreshold, &rule.Enabled, &rule.CreatedAt, &rule.UpdatedAt)
 		if err != nil {
 			continue
 		}
@@ -131,14 +231,28 @@ func (a *Analyzer) GetChatRules(chatID int64) ([]db.DragonRule, error) {
 
 // FilterResultsByRules 根据规则过滤结果
 func (a *Analyzer) FilterResultsByRules(results []*PatternResult, rules []db.DragonRule) []*PatternResult {
+	return FilterResultsByRules(results, rules)
+}
+
+// FilterResultsByRules 根据规则过滤结果（不依赖 Analyzer，供回测等离线场景使用）
+func FilterResultsByRules(results []*PatternResult, rules []db.DragonRule) []*PatternResult {
 	var filtered []*PatternResult
 
 	for _, result := range results {
 		for _, rule := range rules {
 			if result.PatternType == rule.PatternType &&
 				result.AttributeType == rule.AttributeType {
+				// 设置了稀有度阈值的规则按稀有度触发
+				if rule.RarityThreshold > 0 {
+					if result.Rarity >= rule.RarityThreshold {
+						filtered = append(filtered, result)
+						break
+					}
+					continue
+				}
+
 				// 将期数转换为组数后再比较
-				groupCount := getGroupCount(result.Count, result.PatternType)
+				groupCount := GroupCount(result.Count, result.PatternType)
 				if groupCount >= rule.Threshold {
 					filtered = append(filtered, result)
 					break
@@ -150,8 +264,8 @@ func (a *Analyzer) FilterResultsByRules(results []*PatternResult, rules []db.Dra
 	return filtered
 }
 
-// getGroupCount 将期数转换为组数
-func getGroupCount(count int, patternType string) int {
+// GroupCount 将期数转换为组数
+func GroupCount(count int, patternType string) int {
 	switch patternType {
 	case "a":
 		return count // a类型按期数计算
@@ -163,3 +277,45 @@ func getGroupCount(count int, patternType string) int {
 		return count
 	}
 }
+
+// ThresholdRange 各格式长度阈值的允许范围（a 格式为期数，其他为组数）
+// 上限按分析使用的500期历史和实际出现过的长度留出余量
+func ThresholdRange(patternType string) (int, int) {
+	switch patternType {
+	case "a":
+		return 1, 50
+	case "abb":
+		return 1, 16
+	default:
+		return 1, 25
+	}
+}
+
+// 按稀有度触发的阈值范围
+const (
+	RarityMin = 5
+	RarityMax = 100
+)
+
+// DefaultRule 默认规则（threshold 为组数，a 格式为期数）
+type DefaultRule struct {
+	PatternType   string
+	AttributeType string
+	Threshold     int
+}
+
+// DefaultRules 新群组的默认规则
+var DefaultRules = []DefaultRule{
+	{"a", "size", 5},
+	{"a", "parity", 5},
+	{"a", "sum", 5},
+	{"ab", "size", 2},
+	{"ab", "parity", 2},
+	{"ab", "sum", 2},
+	{"abb", "size", 2},
+	{"abb", "parity", 2},
+	{"abb", "sum", 2},
+	{"ab_ac", "size_parity", 2},
+	{"ab_cd", "size_parity", 2},
+	{"abab", "size_parity", 2},
+}
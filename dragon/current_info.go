@@ -1,5 +1,7 @@
 package dragon
 
+import "time"
+
 // CurrentLotteryInfo 当前开奖信息
 type CurrentLotteryInfo struct {
 	Qihao    string
@@ -7,6 +9,7 @@ type CurrentLotteryInfo struct {
 	SumValue int
 	Size     string // 大/小
 	Parity   string // 单/双
+	OpenTime time.Time
 }
 
 
@@ -15,6 +15,13 @@ type PatternResult struct {
 	CurrentQihao  string
 	PatternDetail string
 	Matched       bool
+	DragonID      int64 // 全局长龙ID（由 Tracker.Track 填充）
+
+	// 稀有度信息（由 ScoreRarity 填充）
+	Probability       float64 // 理论概率
+	HistoryRuns       int     // 历史数据中已结束的同类长龙数量
+	HistoryPercentile int     // 历史百分位（短于当前长度的比例）
+	Rarity            int     // 稀有度评分 0-100
 }
 
 // CheckPatternA 检测 a 格式（连续相同）
@@ -0,0 +1,210 @@
+package dragon
+
+import (
+	"dragon-alert-bot/lottery"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// sumWays 三个0-9号码之和为 s 的组合数（共1000种组合）
+// PC28 的和值分布并不均匀（13、14 最常见，0、27 最罕见），
+// 各属性的概率都从这张表推导，而不是假设 50/50
+var sumWays = func() [28]int {
+	var ways [28]int
+	for a := 0; a <= 9; a++ {
+		for b := 0; b <= 9; b++ {
+			for c := 0; c <= 9; c++ {
+				ways[a+b+c]++
+			}
+		}
+	}
+	return ways
+}()
+
+// valueProbability 计算单期开出某个属性值的理论概率
+func valueProbability(attrType, value string) float64 {
+	total := 0
+	for s := 0; s <= 27; s++ {
+		size := "大"
+		if s < 14 {
+			size = "小"
+		}
+		parity := "双"
+		if s%2 != 0 {
+			parity = "单"
+		}
+
+		matched := false
+		switch attrType {
+		case "size":
+			matched = size == value
+		case "parity":
+			matched = parity == value
+		case "sum":
+			matched = strconv.Itoa(s) == value
+		case "size_parity":
+			matched = size+parity == value
+		}
+
+		if matched {
+			total += sumWays[s]
+		}
+	}
+
+	return float64(total) / 1000
+}
+
+// patternProbability 计算长龙从第一期起按当前形态延续到当前长度的理论概率
+func patternProbability(r *PatternResult) float64 {
+	values := strings.Fields(r.PatternDetail)
+	if len(values) < 2 {
+		return 1
+	}
+
+	p := 1.0
+	for _, v := range values[1:] {
+		p *= valueProbability(r.AttributeType, v)
+	}
+	return p
+}
+
+// checkPattern 按模式类型调用对应的检测函数（最小次数与 Analyze 保持一致）
+func checkPattern(attrs []lottery.Attributes, patternType, attrType string) *PatternResult {
+	switch patternType {
+	case "a":
+		return CheckPatternA(attrs, attrType, 2)
+	case "ab":
+		return CheckPatternAB(attrs, attrType, 2)
+	case "abb":
+		return CheckPatternABB(attrs, attrType, 3)
+	case "ab_ac":
+		return CheckPatternABAC(attrs, 2)
+	case "ab_cd":
+		return CheckPatternABCD(attrs, 2)
+	case "abab":
+		return CheckPatternABAB(attrs, 2)
+	default:
+		return &PatternResult{Matched: false}
+	}
+}
+
+// RunHistory 历史数据中已结束的各类长龙长度（不含当前正在进行的），按格式和属性分组
+type RunHistory map[string][]int
+
+// Runs 返回某一格式、属性已结束的长龙长度
+func (h RunHistory) Runs(patternType, attrType string) []int {
+	return h[patternType+"/"+attrType]
+}
+
+// runKinds 统计历史长龙的格式和属性（与 DetectAllRuns 一致）
+var runKinds = [][2]string{
+	{"a", "size"}, {"ab", "size"}, {"abb", "size"},
+	{"a", "parity"}, {"ab", "parity"}, {"abb", "parity"},
+	{"a", "sum"}, {"ab", "sum"}, {"abb", "sum"},
+	{"ab_ac", "size_parity"}, {"ab_cd", "size_parity"}, {"abab", "size_parity"},
+}
+
+// BuildRunHistory 从旧到新扫描一遍，统计所有格式、属性已结束的长龙长度
+// 以每一期结尾的长龙长度由上一期的长度递推，结果与逐期调用 checkPattern 相同
+func BuildRunHistory(attrs []lottery.Attributes) RunHistory {
+	history := make(RunHistory, len(runKinds))
+	for _, kind := range runKinds {
+		patternType, attrType := kind[0], kind[1]
+
+		var runs []int
+		prev := 0
+		for i := range attrs {
+			n := runLength(attrs, i, prev, patternType, attrType)
+			// 上一期的长龙在这一期没有延续，说明它已经结束
+			if prev > 0 && n != prev+1 {
+				runs = append(runs, prev)
+			}
+			prev = n
+		}
+		history[patternType+"/"+attrType] = runs
+	}
+	return history
+}
+
+// runLength 以第 i 期结尾的长龙长度，没有长龙时为0；prev 为以第 i-1 期结尾的长度
+func runLength(attrs []lottery.Attributes, i, prev int, patternType, attrType string) int {
+	if i < 1 {
+		return 0
+	}
+
+	value := func(j int) string {
+		switch attrType {
+		case "size":
+			return attrs[j].Size
+		case "parity":
+			return attrs[j].Parity
+		case "sum":
+			return strconv.Itoa(attrs[j].SumValue)
+		default:
+			return attrs[j].Size + attrs[j].Parity
+		}
+	}
+
+	// extend 上一期的长龙可以延续到这一期时长度加一，否则从最近两期重新开始
+	extend := func(ok bool) int {
+		if ok && prev > 0 {
+			return prev + 1
+		}
+		return 2
+	}
+
+	switch patternType {
+	case "a", "abab":
+		if value(i) == value(i-1) {
+			return extend(true)
+		}
+	case "ab":
+		if value(i) != value(i-1) {
+			return extend(i >= 2 && value(i-2) == value(i))
+		}
+	case "ab_ac":
+		if attrs[i].Size == attrs[i-1].Size && attrs[i].Parity != attrs[i-1].Parity {
+			return extend(i >= 2 && value(i-2) == value(i))
+		}
+	case "ab_cd":
+		if attrs[i].Size != attrs[i-1].Size && attrs[i].Parity != attrs[i-1].Parity {
+			return extend(i >= 2 && value(i-2) == value(i))
+		}
+	case "abb":
+		// 与 CheckPatternABB 一致：只计最近一组完整的 A-B-B
+		if i >= 2 && value(i-2) != value(i-1) && value(i-1) == value(i) {
+			return 3
+		}
+	}
+	return 0
+}
+
+// ScoreRarity 为检测结果计算稀有度
+// 稀有度（0-100）= 60% 理论概率得分 + 40% 历史百分位
+// 理论概率得分：概率每降低一个数量级加20分，1/100000 及以下为满分
+// 历史百分位：历史数据中短于当前长度的同类长龙所占比例
+// history 为 BuildRunHistory 的统计结果；没有同类历史长龙时历史百分位为0
+func ScoreRarity(results []*PatternResult, history RunHistory) {
+	for _, r := range results {
+		r.Probability = patternProbability(r)
+
+		theoretical := math.Min(100, -math.Log10(r.Probability)*20)
+
+		runs := history.Runs(r.PatternType, r.AttributeType)
+		shorter := 0
+		for _, n := range runs {
+			if n < r.Count {
+				shorter++
+			}
+		}
+
+		r.HistoryRuns = len(runs)
+		r.HistoryPercentile = 0
+		if len(runs) > 0 {
+			r.HistoryPercentile = shorter * 100 / len(runs)
+		}
+
+		r.Rarity = int(math.Round(theoretical*0.6 + float64(r.HistoryPercentile)*0.4))
+	}
+}
@@ -0,0 +1,209 @@
+package dragon
+
+import (
+	"dragon-alert-bot/db"
+	"log"
+	"time"
+)
+
+// 纪录统计窗口
+const (
+	RecordWindowDay  = "day"
+	RecordWindowWeek = "week"
+	RecordWindowAll  = "all"
+)
+
+// recordMinGroups 达到该组数的长龙才写入纪录表，避免大量短龙占用空间
+const recordMinGroups = 3
+
+// recordWindows 按级别从低到高排列的纪录窗口（0 表示不限时间）
+var recordWindows = []struct {
+	name     string
+	duration time.Duration
+}{
+	{RecordWindowDay, 24 * time.Hour},
+	{RecordWindowWeek, 7 * 24 * time.Hour},
+	{RecordWindowAll, 0},
+}
+
+// RecordEvent 破纪录事件
+type RecordEvent struct {
+	Result   *PatternResult
+	Window   string // day, week, all
+	Previous int    // 原纪录长度（期数）
+	Tie      bool   // 是否为平纪录
+}
+
+// RecordEntry 纪录榜单条目（期数）
+type RecordEntry struct {
+	PatternType   string
+	AttributeType string
+	Day           int
+	Week          int
+	All           int
+}
+
+type RecordKeeper struct{}
+
+func NewRecordKeeper() *RecordKeeper {
+	return &RecordKeeper{}
+}
+
+// Update 用本期所有正在进行的模式更新纪录表，返回平/破纪录事件
+func (k *RecordKeeper) Update(runs []*PatternResult) []*RecordEvent {
+	var events []*RecordEvent
+	now := time.Now()
+
+	for _, r := range runs {
+		if GroupCount(r.Count, r.PatternType) < recordMinGroups {
+			continue
+		}
+
+		// 写入或更新长龙长度（只增不减，保证最终长度可靠）
+		_, err := db.WriteDB.Exec(`
+			INSERT INTO dragon_records
+			(pattern_type, attribute_type, start_qihao, end_qihao, count, pattern_detail, status, started_at, last_seen_at)
+			VALUES (?, ?, ?, ?, ?, ?, 'active', ?, ?)
+			ON DUPLICATE KEY UPDATE
+				end_qihao = IF(VALUES(count) >= count, VALUES(end_qihao), end_qihao),
+				pattern_detail = IF(VALUES(count) >= count, VALUES(pattern_detail), pattern_detail),
+				count = GREATEST(count, VALUES(count)),
+				status = 'active',
+				last_seen_at = VALUES(last_seen_at)
+		`, r.PatternType, r.AttributeType, r.StartQihao, r.CurrentQihao, r.Count, r.PatternDetail, now, now)
+		if err != nil {
+			log.Printf("[纪录] 更新失败: %v", err)
+			continue
+		}
+
+		if event := k.checkRecord(r); event != nil {
+			events = append(events, event)
+		}
+	}
+
+	k.endInactive(runs)
+
+	return events
+}
+
+// checkRecord 检查长龙是否平/破纪录，同一条长龙每个级别只提醒一次
+func (k *RecordKeeper) checkRecord(r *PatternResult) *RecordEvent {
+	var id int64
+	var announced int
+	err := db.WriteDB.QueryRow(`
+		SELECT id, announced_level FROM dragon_records
+		WHERE pattern_type = ? AND attribute_type = ? AND start_qihao = ?
+	`, r.PatternType, r.AttributeType, r.StartQihao).Scan(&id, &announced)
+	if err != nil {
+		return nil
+	}
+
+	// 级别 = 窗口序号*2 + 是否打破（平纪录为偶数，破纪录为奇数），取最高级别
+	var event *RecordEvent
+	level := 0
+	for i, w := range recordWindows {
+		query := `
+			SELECT COALESCE(MAX(count), 0) FROM dragon_records
+			WHERE pattern_type = ? AND attribute_type = ? AND id != ?`
+		args := []interface{}{r.PatternType, r.AttributeType, id}
+		if w.duration > 0 {
+			query += " AND last_seen_at >= ?"
+			args = append(args, time.Now().Add(-w.duration))
+		}
+
+		var previous int
+		if err := db.WriteDB.QueryRow(query, args...).Scan(&previous); err != nil {
+			return nil
+		}
+
+		// 窗口内没有其他长龙时不算纪录
+		if previous == 0 || r.Count < previous {
+			break
+		}
+
+		level = (i+1)*2 + 1
+		if r.Count == previous {
+			level--
+		}
+		event = &RecordEvent{
+			Result:   r,
+			Window:   w.name,
+			Previous: previous,
+			Tie:      r.Count == previous,
+		}
+	}
+
+	if event == nil || level <= announced {
+		return nil
+	}
+
+	db.WriteDB.Exec("UPDATE dragon_records SET announced_level = ? WHERE id = ?", level, id)
+	log.Printf("[纪录] %s/%s 长度:%d 窗口:%s 平纪录:%v", r.AttributeType, r.PatternType, r.Count, event.Window, event.Tie)
+
+	return event
+}
+
+// endInactive 将本期不再延续的纪录标记为结束
+func (k *RecordKeeper) endInactive(runs []*PatternResult) {
+	rows, err := db.WriteDB.Query(`
+		SELECT id, pattern_type, attribute_type, start_qihao
+		FROM dragon_records
+		WHERE status = 'active'
+	`)
+	if err != nil {
+		return
+	}
+
+	var ended []int64
+	for rows.Next() {
+		var id int64
+		var pattern, attr, start string
+		if err := rows.Scan(&id, &pattern, &attr, &start); err != nil {
+			continue
+		}
+
+		found := false
+		for _, r := range runs {
+			if r.PatternType == pattern && r.AttributeType == attr && r.StartQihao == start {
+				found = true
+				break
+			}
+		}
+		if !found {
+			ended = append(ended, id)
+		}
+	}
+	rows.Close()
+
+	for _, id := range ended {
+		db.WriteDB.Exec("UPDATE dragon_records SET status = 'ended' WHERE id = ?", id)
+	}
+}
+
+// GetLeaderboard 获取各属性、各格式在日/周/全部时间窗口内的最长纪录
+func (k *RecordKeeper) GetLeaderboard() ([]RecordEntry, error) {
+	now := time.Now()
+	rows, err := db.WriteDB.Query(`
+		SELECT pattern_type, attribute_type,
+			COALESCE(MAX(CASE WHEN last_seen_at >= ? THEN count END), 0),
+			COALESCE(MAX(CASE WHEN last_seen_at >= ? THEN count END), 0),
+			MAX(count)
+		FROM dragon_records
+		GROUP BY pattern_type, attribute_type
+	`, now.Add(-24*time.Hour), now.Add(-7*24*time.Hour))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []RecordEntry
+	for rows.Next() {
+		var e RecordEntry
+		if err := rows.Scan(&e.PatternType, &e.AttributeType, &e.Day, &e.Week, &e.All); err != nil {
+			continue
+		}
+		entries = append(entries, e)
+	}
+
+	return entries, nil
+}
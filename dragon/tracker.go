@@ -2,125 +2,283 @@ package dragon
 
 import (
 	"dragon-alert-bot/db"
+	"dragon-alert-bot/leader"
+	"dragon-alert-bot/lottery"
+	"log"
+	"sync"
 	"time"
 )
 
-type Tracker struct{}
+// 长龙状态：started → extended → ended
+const (
+	StateStarted  = "started"
+	StateExtended = "extended"
+	StateEnded    = "ended"
+)
 
-func NewTracker() *Tracker {
-	return &Tracker{}
+// DragonState 内存中的长龙状态
+type DragonState struct {
+	ID            int64 // 数据库ID，写入数据库后回填，之前为0
+	PatternType   string
+	AttributeType string
+	StartQihao    string
+	CurrentQihao  string
+	Count         int
+	PatternDetail string
+	State         string
+	StartedAt     time.Time
+	UpdatedAt     time.Time
 }
 
-// TrackDragon 跟踪长龙状态
-func (t *Tracker) TrackDragon(chatID int64, result *PatternResult) (shouldAlert bool, isNew bool) {
-	// 查找活跃的长龙记录
-	var alert db.DragonAlert
-	err := db.WriteDB.QueryRow(`
-		SELECT id, chat_id, pattern_type, attribute_type, start_qihao, current_qihao, 
-		       count, pattern_detail, last_alert_count, status, created_at, updated_at
-		FROM dragon_alerts 
-		WHERE chat_id = ? AND pattern_type = ? AND attribute_type = ? AND status = 'active'
-		ORDER BY id DESC LIMIT 1
-	`, chatID, result.PatternType, result.AttributeType).Scan(
-		&alert.ID, &alert.ChatID, &alert.PatternType, &alert.AttributeType,
-		&alert.StartQihao, &alert.CurrentQihao, &alert.Count, &alert.PatternDetail,
-		&alert.LastAlertCount, &alert.Status, &alert.CreatedAt, &alert.UpdatedAt,
-	)
+// Key 长龙唯一标识（格式/属性/起始期号），与 dragons 表的唯一键一致
+func (s *DragonState) Key() string {
+	return s.PatternType + "/" + s.AttributeType + "/" + s.StartQihao
+}
 
-	if err != nil {
-		// 没有活跃记录，创建新记录
-		_, err = db.WriteDB.Exec(`
-			INSERT INTO dragon_alerts 
-			(chat_id, pattern_type, attribute_type, start_qihao, current_qihao, count, pattern_detail, last_alert_count, status)
-			VALUES (?, ?, ?, ?, ?, ?, ?, ?, 'active')
-		`, chatID, result.PatternType, result.AttributeType, result.StartQihao, result.CurrentQihao,
-			result.Count, result.PatternDetail, result.Count)
+// Tracker 全局长龙跟踪：活跃长龙保存在内存中按状态机流转，
+// 状态变化由 writeBehind 异步批量写入 dragons / dragon_alerts 表，提醒路径上不再访问数据库
+type Tracker struct {
+	mu     sync.Mutex
+	active map[string]*DragonState // 格式/属性 → 活跃长龙（同一类型同时只有一条）
+	writer *writeBehind
+}
 
-		if err != nil {
-			return false, false
+// NewTracker 创建跟踪器，journalPath 为写入日志文件（用于崩溃后恢复未落库的变更）
+func NewTracker(journalPath string) *Tracker {
+	t := &Tracker{
+		active: make(map[string]*DragonState),
+	}
+	t.writer = newWriteBehind(journalPath, t.setID)
+	return t
+}
+
+// Restore 启动时恢复状态：先重放上次未落库的日志，再从数据库加载活跃长龙，然后启动异步写入
+// 高可用模式下启动时还不是主节点，日志在成为主节点调用 Reload 时再重放
+func (t *Tracker) Restore() error {
+	if err := t.Reload(); err != nil {
+		return err
+	}
+
+	t.writer.start()
+	return nil
+}
+
+// Reload 从数据库重新加载活跃长龙（成为主节点时调用，接管其他实例写入的状态）
+// 持有主节点锁时先重放日志中尚未落库的变更
+func (t *Tracker) Reload() error {
+	if leader.HoldsLock() {
+		if err := t.writer.replay(); err != nil {
+			return err
 		}
+	}
 
-		return true, true // 新长龙，需要提醒
+	rows, err := db.WriteDB.Query(`
+		SELECT id, pattern_type, attribute_type, start_qihao, current_qihao, count, pattern_detail, created_at, updated_at
+		FROM dragons
+		WHERE status = 'active'
+		ORDER BY id
+	`)
+	if err != nil {
+		return err
 	}
+	defer rows.Close()
 
-	// 检查是否是同一个长龙的延续
-	if result.StartQihao == alert.StartQihao {
-		// 长龙延续，更新记录
-		_, err = db.WriteDB.Exec(`
-			UPDATE dragon_alerts 
-			SET current_qihao = ?, count = ?, pattern_detail = ?, last_alert_count = ?, updated_at = ?
-			WHERE id = ?
-		`, result.CurrentQihao, result.Count, result.PatternDetail, result.Count, time.Now(), alert.ID)
+	t.mu.Lock()
+	defer t.mu.Unlock()
 
+	t.active = make(map[string]*DragonState)
+	for rows.Next() {
+		s := &DragonState{State: StateExtended}
+		err := rows.Scan(&s.ID, &s.PatternType, &s.AttributeType, &s.StartQihao, &s.CurrentQihao,
+			&s.Count, &s.PatternDetail, &s.StartedAt, &s.UpdatedAt)
 		if err != nil {
-			return false, false
+			continue
 		}
-
-		return true, false // 延续的长龙，每次都提醒
+		t.active[s.PatternType+"/"+s.AttributeType] = s
 	}
 
-	// 旧长龙已结束，标记为结束
-	db.WriteDB.Exec("UPDATE dragon_alerts SET status = 'ended' WHERE id = ?", alert.ID)
+	log.Printf("[长龙跟踪] 已加载 %d 条活跃长龙", len(t.active))
+	return nil
+}
+
+// Close 停止异步写入并落库剩余变更
+func (t *Tracker) Close() {
+	t.writer.close()
+}
+
+// Track 每期执行一次，与群组数量无关：登记新长龙、更新延续的长龙、结束中断的长龙
+// 会为每个结果填充 DragonID（尚未落库的新长龙为0），返回本期结束的长龙
+func (t *Tracker) Track(results []*PatternResult) []DragonState {
+	t.mu.Lock()
+	defer t.mu.Unlock()
 
-	// 创建新长龙记录
-	_, err = db.WriteDB.Exec(`
-		INSERT INTO dragon_alerts 
-		(chat_id, pattern_type, attribute_type, start_qihao, current_qihao, count, pattern_detail, last_alert_count, status)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, 'active')
-	`, chatID, result.PatternType, result.AttributeType, result.StartQihao, result.CurrentQihao,
-		result.Count, result.PatternDetail, result.Count)
+	now := time.Now()
+	seen := make(map[string]bool)
+	var ended []DragonState
 
-	if err != nil {
-		return false, false
+	for _, result := range results {
+		typeKey := result.PatternType + "/" + result.AttributeType
+		seen[typeKey] = true
+
+		state, ok := t.active[typeKey]
+		if ok && state.StartQihao == result.StartQihao {
+			// 长龙延续
+			if state.Count != result.Count || state.CurrentQihao != result.CurrentQihao {
+				state.State = StateExtended
+				state.CurrentQihao = result.CurrentQihao
+				state.Count = result.Count
+				state.PatternDetail = result.PatternDetail
+				state.UpdatedAt = now
+				t.writer.enqueue(writeOp{Kind: opUpsertDragon, Dragon: *state})
+			}
+
+			result.DragonID = state.ID
+			continue
+		}
+
+		// 同类型的旧长龙已中断
+		if ok {
+			ended = append(ended, t.end(state, now))
+		}
+
+		// 新长龙
+		state = &DragonState{
+			PatternType:   result.PatternType,
+			AttributeType: result.AttributeType,
+			StartQihao:    result.StartQihao,
+			CurrentQihao:  result.CurrentQihao,
+			Count:         result.Count,
+			PatternDetail: result.PatternDetail,
+			State:         StateStarted,
+			StartedAt:     now,
+			UpdatedAt:     now,
+		}
+		t.active[typeKey] = state
+		t.writer.enqueue(writeOp{Kind: opUpsertDragon, Dragon: *state})
+
+		result.DragonID = state.ID
+	}
+
+	// 本期没有出现的类型，长龙已结束
+	for typeKey, state := range t.active {
+		if !seen[typeKey] {
+			ended = append(ended, t.end(state, now))
+			delete(t.active, typeKey)
+		}
 	}
 
-	return true, true
+	return ended
 }
 
-// EndInactiveDragons 结束不活跃的长龙
-func (t *Tracker) EndInactiveDragons(chatID int64, activeResults []*PatternResult) {
-	// 获取所有活跃的长龙记录
-	rows, err := db.WriteDB.Query(`
-		SELECT id, pattern_type, attribute_type, start_qihao 
-		FROM dragon_alerts 
-		WHERE chat_id = ? AND status = 'active'
-	`, chatID)
-	if err != nil {
-		return
+// end 将长龙标记为结束（调用方持有锁）
+func (t *Tracker) end(state *DragonState, now time.Time) DragonState {
+	state.State = StateEnded
+	state.UpdatedAt = now
+	t.writer.enqueue(writeOp{Kind: opEndDragon, Dragon: *state})
+	return *state
+}
+
+// ActiveDragons 返回当前所有活跃长龙的快照
+func (t *Tracker) ActiveDragons() []DragonState {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var states []DragonState
+	for _, state := range t.active {
+		states = append(states, *state)
 	}
-	defer rows.Close()
+	return states
+}
 
-	type ActiveAlert struct {
-		ID            int64
-		PatternType   string
-		AttributeType string
-		StartQihao    string
+// RecordDelivery 记录向群组提醒了哪些长龙（异步写入，每个群组每条长龙一行）
+func (t *Tracker) RecordDelivery(chatID int64, results []*PatternResult) {
+	for _, result := range results {
+		t.writer.enqueue(writeOp{
+			Kind:   opDelivery,
+			ChatID: chatID,
+			Dragon: DragonState{
+				PatternType:   result.PatternType,
+				AttributeType: result.AttributeType,
+				StartQihao:    result.StartQihao,
+				CurrentQihao:  result.CurrentQihao,
+				Count:         result.Count,
+				PatternDetail: result.PatternDetail,
+			},
+		})
 	}
+}
 
-	var activeAlerts []ActiveAlert
-	for rows.Next() {
-		var alert ActiveAlert
-		if err := rows.Scan(&alert.ID, &alert.PatternType, &alert.AttributeType, &alert.StartQihao); err != nil {
-			continue
+// setID 新长龙落库后回填数据库ID
+func (t *Tracker) setID(key string, id int64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, state := range t.active {
+		if state.Key() == key {
+			state.ID = id
+			return
 		}
-		activeAlerts = append(activeAlerts, alert)
 	}
+}
+
+// Reconcile 启动时按真实开奖历史校正活跃长龙（attrs 从旧到新）
+// 停机期间已经中断的长龙按实际结束期号和长度结束，仍在延续的长龙更新为当前长度
+func (t *Tracker) Reconcile(attrs []lottery.Attributes) {
+	if len(attrs) == 0 {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
 
-	// 检查每个活跃记录是否还在当前结果中
-	for _, alert := range activeAlerts {
-		found := false
-		for _, result := range activeResults {
-			if result.PatternType == alert.PatternType &&
-				result.AttributeType == alert.AttributeType &&
-				result.StartQihao == alert.StartQihao {
-				found = true
+	now := time.Now()
+	fixed, ended := 0, 0
+
+	for typeKey, state := range t.active {
+		startIdx := -1
+		for i := range attrs {
+			if attrs[i].Qihao == state.StartQihao {
+				startIdx = i
 				break
 			}
 		}
 
-		// 如果不在当前结果中，标记为结束
-		if !found {
-			db.WriteDB.Exec("UPDATE dragon_alerts SET status = 'ended' WHERE id = ?", alert.ID)
+		// 逐期重放，找到这条长龙最后一次延续的位置
+		var last *PatternResult
+		stillActive := false
+		if startIdx >= 0 {
+			for i := startIdx; i < len(attrs); i++ {
+				result := checkPattern(attrs[:i+1], state.PatternType, state.AttributeType)
+				if !result.Matched || result.StartQihao != state.StartQihao {
+					if last != nil {
+						break
+					}
+					continue
+				}
+				last = result
+				stillActive = i == len(attrs)-1
+			}
 		}
+
+		if last != nil && (last.Count != state.Count || last.CurrentQihao != state.CurrentQihao) {
+			state.CurrentQihao = last.CurrentQihao
+			state.Count = last.Count
+			state.PatternDetail = last.PatternDetail
+			state.UpdatedAt = now
+			fixed++
+		}
+
+		if stillActive {
+			state.State = StateExtended
+			t.writer.enqueue(writeOp{Kind: opUpsertDragon, Dragon: *state})
+			continue
+		}
+
+		// 已中断（起始期号不在历史范围内的也按已知长度结束）
+		t.end(state, now)
+		delete(t.active, typeKey)
+		ended++
 	}
+
+	log.Printf("[长龙跟踪] 启动校正完成：结束 %d 条，修正长度 %d 条，仍活跃 %d 条", ended, fixed, len(t.active))
 }
@@ -0,0 +1,483 @@
+package dragon
+
+import (
+	"bufio"
+	"database/sql"
+	"dragon-alert-bot/db"
+	"dragon-alert-bot/leader"
+	"encoding/json"
+	"errors"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// 写入操作类型
+const (
+	opUpsertDragon = "upsert" // 登记或更新长龙
+	opEndDragon    = "end"    // 结束长龙（同时结束各群组的提醒记录）
+	opDelivery     = "delivery"
+)
+
+const (
+	writeFlushInterval = 500 * time.Millisecond
+	writeBatchSize     = 200
+	writeMaxAttempts   = 5     // 同一批连续失败的次数，达到后逐条写入找出无法写入的变更
+	writeQueueLimit    = 20000 // 队列上限，数据库长时间不可用时丢弃最早的变更
+)
+
+// writeOp 一次待落库的变更，所有操作都按唯一键幂等执行，可以安全地重复重放
+// 长龙状态（opUpsertDragon、opEndDragon）只能由持有主节点锁的实例写入；
+// 提醒记录（opDelivery）只由发送提醒的实例产生且只增不减，失去主节点身份后仍继续写入
+type writeOp struct {
+	Kind   string      `json:"kind"`
+	ChatID int64       `json:"chat_id,omitempty"`
+	Dragon DragonState `json:"dragon"`
+}
+
+// errNotLeader 本实例已失去主节点锁，变更不能再写入（会覆盖新主节点的状态）
+var errNotLeader = errors.New("已失去主节点身份")
+
+// failedOp 无法写入数据库的变更，记录到失败日志（日志文件名加 .failed）供人工处理
+type failedOp struct {
+	writeOp
+	Error    string    `json:"error"`
+	FailedAt time.Time `json:"failed_at"`
+}
+
+// writeBehind 异步批量写入
+// 每个变更先追加到本地日志文件再入队，批量落库成功且队列清空后截断日志；
+// 进程崩溃后由 replay 将日志中的变更重新写入数据库
+type writeBehind struct {
+	journalPath string
+	onInsert    func(key string, id int64)
+
+	mu        sync.Mutex // 保护日志文件与队列的一致性
+	journal   *os.File
+	queue     []writeOp
+	dropped   int // 因队列已满累计丢弃的变更数
+	compacted int // 上次按队列重写日志时的 dropped
+	failures  int // 队首这一批连续失败的次数（只在写入协程中访问）
+	started   bool
+
+	wake chan struct{}
+	stop chan struct{}
+	done chan struct{}
+}
+
+func newWriteBehind(journalPath string, onInsert func(key string, id int64)) *writeBehind {
+	return &writeBehind{
+		journalPath: journalPath,
+		onInsert:    onInsert,
+		wake:        make(chan struct{}, 1),
+		stop:        make(chan struct{}),
+		done:        make(chan struct{}),
+	}
+}
+
+// replay 重放日志中未落库的变更（上次运行遗留的，包括失去主节点身份后还没有写入的提醒记录），
+// 并清空队列：其中的变更都已包含在日志中
+func (w *writeBehind) replay() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.queue = nil
+	w.failures = 0
+
+	f, err := os.Open(w.journalPath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var ops []writeOp
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var op writeOp
+		if err := json.Unmarshal(scanner.Bytes(), &op); err != nil {
+			// 最后一行可能在崩溃时只写了一半
+			continue
+		}
+		ops = append(ops, op)
+	}
+
+	if len(ops) == 0 {
+		return nil
+	}
+
+	if err := w.apply(ops); err != nil {
+		if !permanentError(err) {
+			return err
+		}
+		// 日志中有无法写入的变更时逐条重放，避免每次启动都失败
+		if done := w.applyEach(ops); done < len(ops) {
+			return err
+		}
+	}
+
+	log.Printf("[长龙跟踪] 已重放 %d 条未落库的变更", len(ops))
+	w.compacted = w.dropped
+	return os.Truncate(w.journalPath, 0)
+}
+
+// start 打开日志文件并启动后台写入
+func (w *writeBehind) start() {
+	f, err := os.OpenFile(w.journalPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		log.Printf("[长龙跟踪] 打开日志文件失败，崩溃后将无法恢复未落库的变更: %v", err)
+	}
+	w.journal = f
+	w.started = true
+
+	go w.run()
+}
+
+// enqueue 记录一条变更（只写本地日志，不访问数据库，日志在每次 flush 时同步到磁盘）
+func (w *writeBehind) enqueue(op writeOp) {
+	w.mu.Lock()
+	if w.journal != nil {
+		if err := w.writeJournal(op); err != nil {
+			log.Printf("[长龙跟踪] 写入日志失败，崩溃后将无法恢复这条变更: %v", err)
+		}
+	}
+
+	if len(w.queue) >= writeQueueLimit {
+		if w.dropped == w.compacted {
+			log.Printf("[长龙跟踪] 待写入队列已满 (%d条)，开始丢弃最早的变更", len(w.queue))
+		}
+		w.queue = w.queue[1:]
+		w.dropped++
+	}
+	w.queue = append(w.queue, op)
+	full := len(w.queue) >= writeBatchSize
+	w.mu.Unlock()
+
+	if full {
+		select {
+		case w.wake <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (w *writeBehind) run() {
+	defer close(w.done)
+
+	ticker := time.NewTicker(writeFlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			w.flush()
+		case <-w.wake:
+			w.flush()
+		case <-w.stop:
+			w.flush()
+			return
+		}
+	}
+}
+
+// flush 将队列中的变更批量落库，失败时保留在队列中下次重试；
+// 连续失败多次后逐条写入，无法写入的变更移入失败日志，不再阻塞后面的变更
+func (w *writeBehind) flush() {
+	w.mu.Lock()
+	if w.journal != nil {
+		if err := w.journal.Sync(); err != nil {
+			log.Printf("[长龙跟踪] 日志同步到磁盘失败: %v", err)
+		}
+	}
+	// 失去主节点身份后长龙状态的变更已经过时，新的主节点会从数据库和开奖历史恢复；
+	// 各群组的提醒记录新的主节点无法恢复，继续写入
+	if !leader.HoldsLock() {
+		w.dropStateOps()
+	}
+
+	batch := w.queue
+	dropped := w.dropped
+	w.mu.Unlock()
+
+	if len(batch) == 0 {
+		return
+	}
+
+	done := len(batch)
+	if err := w.apply(batch); err != nil {
+		if errors.Is(err, errNotLeader) {
+			return
+		}
+
+		w.failures++
+		if w.failures < writeMaxAttempts || !permanentError(err) {
+			log.Printf("[长龙跟踪] 批量写入失败，稍后重试 (%d条): %v", len(batch), err)
+			w.compactJournal()
+			return
+		}
+
+		done = w.applyEach(batch)
+		log.Printf("[长龙跟踪] 批量写入连续失败 %d 次，已逐条处理 %d/%d 条", w.failures, done, len(batch))
+	}
+	if done == len(batch) {
+		w.failures = 0
+	}
+
+	w.mu.Lock()
+	// 写入期间队列已满时队首的变更被丢弃，已处理的部分要扣除这些
+	done -= w.dropped - dropped
+	if done > 0 {
+		w.queue = w.queue[done:]
+	}
+	if len(w.queue) == 0 && w.journal != nil {
+		w.journal.Truncate(0)
+		w.compacted = w.dropped
+	}
+	w.mu.Unlock()
+
+	w.compactJournal()
+}
+
+// dropStateOps 丢弃队列中长龙状态的变更，只保留提醒记录，并按队列重写日志（调用方持有 mu）
+func (w *writeBehind) dropStateOps() {
+	kept := make([]writeOp, 0, len(w.queue))
+	for _, op := range w.queue {
+		if op.Kind == opDelivery {
+			kept = append(kept, op)
+		}
+	}
+	if len(kept) == len(w.queue) {
+		return
+	}
+
+	log.Printf("[长龙跟踪] 已失去主节点身份，丢弃 %d 条未写入的长龙变更，保留 %d 条提醒记录", len(w.queue)-len(kept), len(kept))
+	w.queue = kept
+	w.failures = 0
+	w.compacted = w.dropped
+	w.rewriteJournal()
+}
+
+// applyEach 逐条写入，无法写入的变更记入失败日志后跳过；
+// 遇到连接断开等临时错误时停止，返回已处理的条数
+func (w *writeBehind) applyEach(ops []writeOp) int {
+	for i, op := range ops {
+		err := w.apply([]writeOp{op})
+		if err == nil {
+			continue
+		}
+		if errors.Is(err, errNotLeader) || !permanentError(err) {
+			return i
+		}
+		w.deadLetter(op, err)
+	}
+	return len(ops)
+}
+
+// deadLetter 将无法写入的变更追加到失败日志
+func (w *writeBehind) deadLetter(op writeOp, cause error) {
+	log.Printf("[长龙跟踪] 变更无法写入数据库，已移入失败日志: %s %s 错误: %v", op.Kind, op.Dragon.Key(), cause)
+
+	line, err := json.Marshal(failedOp{writeOp: op, Error: cause.Error(), FailedAt: time.Now()})
+	if err != nil {
+		return
+	}
+
+	f, err := os.OpenFile(w.journalPath+".failed", os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		log.Printf("[长龙跟踪] 打开失败日志失败: %v", err)
+		return
+	}
+	defer f.Close()
+
+	f.Write(append(line, '\n'))
+}
+
+// compactJournal 队列丢弃过变更时按当前队列重写日志，避免日志随队列无限增长
+func (w *writeBehind) compactJournal() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.journal == nil || w.dropped == w.compacted {
+		return
+	}
+
+	log.Printf("[长龙跟踪] 队列已满期间共丢弃 %d 条变更", w.dropped-w.compacted)
+	w.compacted = w.dropped
+	w.rewriteJournal()
+}
+
+// rewriteJournal 按当前队列重写日志（调用方持有 mu）
+func (w *writeBehind) rewriteJournal() {
+	if w.journal == nil {
+		return
+	}
+
+	if err := w.journal.Truncate(0); err != nil {
+		log.Printf("[长龙跟踪] 重写日志失败: %v", err)
+		return
+	}
+	for _, op := range w.queue {
+		if err := w.writeJournal(op); err != nil {
+			log.Printf("[长龙跟踪] 重写日志失败: %v", err)
+			return
+		}
+	}
+	w.journal.Sync()
+}
+
+// writeJournal 向日志追加一条变更（调用方持有 mu）
+func (w *writeBehind) writeJournal(op writeOp) error {
+	line, err := json.Marshal(op)
+	if err != nil {
+		return err
+	}
+	_, err = w.journal.Write(append(line, '\n'))
+	return err
+}
+
+// permanentError 是否为重试也无法成功的错误（约束冲突、数据错误等 MySQL 返回的错误），
+// 锁等待超时、死锁和连接数过多仍按临时错误重试
+func permanentError(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	if !errors.As(err, &mysqlErr) {
+		return false
+	}
+	switch mysqlErr.Number {
+	case 1040, 1205, 1213:
+		return false
+	}
+	return true
+}
+
+// apply 在一个事务中按顺序执行一批变更
+func (w *writeBehind) apply(ops []writeOp) error {
+	tx, err := db.WriteDB.Begin()
+	if err != nil {
+		return err
+	}
+
+	inserted := make(map[string]int64)
+	stateOps := false
+	for _, op := range ops {
+		if op.Kind != opDelivery {
+			stateOps = true
+		}
+
+		switch op.Kind {
+		case opUpsertDragon:
+			id, err := upsertDragon(tx, &op.Dragon)
+			if err != nil {
+				tx.Rollback()
+				return err
+			}
+			if op.Dragon.ID == 0 && id > 0 {
+				inserted[op.Dragon.Key()] = id
+			}
+
+		case opEndDragon:
+			if err := endDragon(tx, &op.Dragon); err != nil {
+				tx.Rollback()
+				return err
+			}
+
+		case opDelivery:
+			if err := recordDelivery(tx, op.ChatID, &op.Dragon); err != nil {
+				tx.Rollback()
+				return err
+			}
+		}
+	}
+
+	// 执行期间可能已经失去主节点锁，提交长龙状态前再确认一次
+	if stateOps && !leader.HoldsLock() {
+		tx.Rollback()
+		return errNotLeader
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	if w.onInsert != nil {
+		for key, id := range inserted {
+			w.onInsert(key, id)
+		}
+	}
+
+	return nil
+}
+
+// close 停止后台写入并落库剩余变更
+func (w *writeBehind) close() {
+	if !w.started {
+		return
+	}
+
+	close(w.stop)
+	<-w.done
+
+	w.mu.Lock()
+	if w.journal != nil {
+		w.journal.Close()
+	}
+	w.mu.Unlock()
+}
+
+func upsertDragon(tx *sql.Tx, s *DragonState) (int64, error) {
+	res, err := tx.Exec(`
+		INSERT INTO dragons
+		(pattern_type, attribute_type, start_qihao, current_qihao, count, pattern_detail, status, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, 'active', ?)
+		ON DUPLICATE KEY UPDATE
+			id = LAST_INSERT_ID(id), current_qihao = VALUES(current_qihao), count = VALUES(count),
+			pattern_detail = VALUES(pattern_detail), status = 'active', end_qihao = '', ended_at = NULL
+	`, s.PatternType, s.AttributeType, s.StartQihao, s.CurrentQihao, s.Count, s.PatternDetail, s.StartedAt)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.LastInsertId()
+}
+
+func endDragon(tx *sql.Tx, s *DragonState) error {
+	// 结束期号即最后一次延续时的期号
+	_, err := tx.Exec(`
+		UPDATE dragons SET status = 'ended', end_qihao = ?, count = ?, ended_at = ?
+		WHERE pattern_type = ? AND attribute_type = ? AND start_qihao = ?
+	`, s.CurrentQihao, s.Count, s.UpdatedAt, s.PatternType, s.AttributeType, s.StartQihao)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`
+		UPDATE dragon_alerts a
+		JOIN dragons d ON d.id = a.dragon_id
+		SET a.status = 'ended'
+		WHERE a.status = 'active' AND d.pattern_type = ? AND d.attribute_type = ? AND d.start_qihao = ?
+	`, s.PatternType, s.AttributeType, s.StartQihao)
+	return err
+}
+
+// recordDelivery 登记群组的提醒记录：长度只增不减，长龙已结束时直接记为结束，
+// 重放或在新的主节点更新之后写入都不会覆盖更新的记录
+func recordDelivery(tx *sql.Tx, chatID int64, s *DragonState) error {
+	_, err := tx.Exec(`
+		INSERT INTO dragon_alerts
+		(chat_id, dragon_id, pattern_type, attribute_type, start_qihao, current_qihao, count, pattern_detail, last_alert_count, status)
+		SELECT ?, id, pattern_type, attribute_type, start_qihao, ?, ?, ?, ?, IF(status = 'ended', 'ended', 'active')
+		FROM dragons
+		WHERE pattern_type = ? AND attribute_type = ? AND start_qihao = ?
+		ON DUPLICATE KEY UPDATE
+			current_qihao = IF(VALUES(count) >= count, VALUES(current_qihao), current_qihao),
+			pattern_detail = IF(VALUES(count) >= count, VALUES(pattern_detail), pattern_detail),
+			last_alert_count = GREATEST(last_alert_count, VALUES(last_alert_count)),
+			count = GREATEST(count, VALUES(count))
+	`, chatID, s.CurrentQihao, s.Count, s.PatternDetail, s.Count, s.PatternType, s.AttributeType, s.StartQihao)
+	return err
+}
@@ -0,0 +1,355 @@
+package i18n
+
+// en 英文
+var en = map[string]string{
+	"language.name": "English",
+
+	// 单位
+	"unit.count":       "%d %s",
+	"unit.draw.one":    "draw",
+	"unit.draw.other":  "draws",
+	"unit.group.one":   "group",
+	"unit.group.other": "groups",
+	"unit.time.one":    "draw",
+	"unit.time.other":  "draws",
+
+	// 开奖属性值
+	"value.big":         "Big",
+	"value.big.short":   "B",
+	"value.small":       "Small",
+	"value.small.short": "S",
+	"value.odd":         "Odd",
+	"value.odd.short":   "O",
+	"value.even":        "Even",
+	"value.even.short":  "E",
+
+	// 格式与属性名称
+	"pattern.a":          "streak",
+	"pattern.ab":         "alternating",
+	"pattern.abb":        "abb",
+	"pattern.ab_ac":      "fixed+alternating",
+	"pattern.ab_cd":      "double alternating",
+	"pattern.abab":       "combo repeat",
+	"pattern.a.full":     "a streak",
+	"pattern.ab.full":    "ab alternating",
+	"pattern.abb.full":   "abb",
+	"pattern.ab_ac.full": "ab,ac fixed+alternating",
+	"pattern.ab_cd.full": "ab,cd double alternating",
+	"pattern.abab.full":  "abab combo repeat",
+
+	"attr.size":              "Size",
+	"attr.parity":            "Parity",
+	"attr.sum":               "Sum",
+	"attr.size_parity":       "Combo",
+	"attr.size.title":        "📊Size",
+	"attr.parity.title":      "🎯Parity",
+	"attr.sum.title":         "🔢Sum",
+	"attr.size_parity.title": "🔄Combo",
+
+	// 长龙提醒
+	"alert.title":         "Dragon alert",
+	"alert.draw":          "Draw <code>%s</code>: <b>%s=%d</b> %s %s",
+	"alert.qihao":         "Current draw: <code>%s</code>",
+	"alert.group":         "[%s dragons]",
+	"alert.item":          "%s pattern, <b>%d %s</b> in a row",
+	"alert.start":         "Started: %s",
+	"alert.rarity":        "Rarity: <b>%d</b> %s, odds about %s",
+	"alert.percentile":    ", longer than %d%% of past runs",
+	"alert.compact.draw":  "<code>%s</code> <b>%s=%d</b> %s %s",
+	"alert.compact.qihao": "<code>%s</code>",
+	"alert.compact.item":  "%s %s <b>%d %s</b> from %s",
+	"alert.oneline.item":  "%s %s <b>%d %s</b>",
+
+	// 纪录
+	"record.broken.title": "🏆 <b>Record broken</b>",
+	"record.tied.title":   "🏅 <b>Record tied</b>",
+	"record.broken":       "%s %s has run <b>%s</b>, breaking the %s record (%s)",
+	"record.tied":         "%s %s has run <b>%s</b>, tying the %s record (%s)",
+	"record.window.day":   "24-hour",
+	"record.window.week":  "7-day",
+	"record.window.all":   "all-time",
+	"leaderboard.title":   "🏆 <b>Dragon records</b>\nLongest in 24 hours / 7 days / all time (draws)",
+	"leaderboard.empty":   "No records yet",
+
+	// 实时榜单
+	"board.title":   "📌 <b>Live dragon board</b>",
+	"board.line":    "%s: <b>%s</b> since %s",
+	"board.none":    "None",
+	"board.updated": "🕒 Updated at %s",
+
+	// 静默时段汇总
+	"quiet.title.one":   "🌙 <b>Quiet hours summary</b>\n%d dragon",
+	"quiet.title.other": "🌙 <b>Quiet hours summary</b>\n%d dragons",
+	"quiet.line":        "%s: <b>%s</b> %s~%s %s",
+	"quiet.ended":       "ended",
+	"quiet.active":      "🔥running",
+
+	// 命令
+	"welcome.group": `Welcome to the dragon alert bot! 🎲
+
+Features:
+• Monitors every draw automatically
+• Detects many kinds of dragons (long runs)
+• Custom alert rules
+
+Commands:
+/long - Configure dragon alerts (admins only)`,
+	"welcome.private": `Welcome to the dragon alert bot! 🎲
+
+⚠️ This bot only works in groups
+
+Features:
+• Monitors every draw automatically
+• Detects many kinds of dragons (long runs)
+• Flexible custom rules
+
+Getting started:
+1. Tap the button below to add the bot to a group
+2. Send /long in the group
+3. Admins can configure the alert rules`,
+	"welcome.add_button": "➕ Add the bot to a group",
+	"dragon.group_only":  "⚠️ Dragon alerts only work in groups\n\nTap the button below to add the bot to a group",
+	"dragon.add_button":  "➕ Add to group",
+	"admin_only":         "⚠️ Only group admins can do this",
+	"data.text": `📊 <b>Bot statistics</b>
+
+👥 <b>Groups</b>
+• Total groups: <code>%d</code>
+• Alerts enabled: <code>%d</code>
+• Alerts disabled: <code>%d</code>
+
+⚙️ <b>Configuration</b>
+• Enabled rules: <code>%d</code>
+
+🔥 <b>Dragons</b>
+• Active dragons: <code>%d</code>
+
+📨 <b>Alerts today</b>
+• Sent: <code>%d</code>
+• Failed: <code>%d</code>
+• Average latency: <code>%s</code>
+
+💡 Use /long to configure dragon alerts`,
+
+	// 主菜单
+	"menu.title":       "🎲 Dragon alert settings\nStatus: %s\nDelivery: %s",
+	"menu.enabled":     "✅ Enabled",
+	"menu.disabled":    "❌ Disabled",
+	"menu.enable":      "✅ Enable alerts",
+	"menu.disable":     "❌ Disable alerts",
+	"menu.mode.alerts": "Individual alerts",
+	"menu.mode.board":  "📌 Live board (pinned message edited every draw)",
+	"menu.to_board":    "📌 Switch to live board",
+	"menu.to_alerts":   "🔔 Switch to individual alerts",
+	"menu.schedule":    "⏰ Alert hours",
+	"menu.cleanup":     "🧹 Old alert cleanup",
+	"menu.size":        "📊 Size dragons",
+	"menu.parity":      "🎯 Parity dragons",
+	"menu.sum":         "🔢 Sum dragons",
+	"menu.combo":       "🔄 Combo dragons",
+	"menu.status":      "📋 View settings",
+	"menu.simulate":    "📈 Simulate",
+	"menu.preview":     "👁 Preview alert",
+	"menu.back_main":   "◀️ Back to main menu",
+	"menu.back":        "◀️ Back",
+
+	// 规则菜单
+	"rules.attr.title":           "🎲 %s dragon settings\n[+][-] adjust the trigger | tap a name to toggle it\ntap the trigger to type a value\n📏 trigger by length | 💎 trigger by rarity",
+	"rules.combo.title":          "🔄 Combo dragon settings\nSize + parity combined | [+][-] adjust the trigger\ntap the trigger to type a value\n📏 trigger by length | 💎 trigger by rarity",
+	"rules.pattern.a":            "a (streak)",
+	"rules.pattern.ab":           "ab (alternating)",
+	"rules.pattern.abb":          "abb (A-B-B groups)",
+	"rules.pattern.ab_ac":        "ab,ac (fixed+alternating)",
+	"rules.pattern.ab_cd":        "ab,cd (both alternating)",
+	"rules.pattern.abab":         "abab (combo repeat)",
+	"rules.mode.rarity":          "💎Rarity",
+	"rules.mode.length":          "📏Length",
+	"rules.trigger.rarity":       "Rarity≥%d",
+	"rules.trigger.length":       "Trigger: %s",
+	"status.title":               "📋 Settings",
+	"status.enabled_rules.one":   "%d rule enabled",
+	"status.enabled_rules.other": "%d rules enabled",
+	"status.refresh":             "🔄 Refresh",
+	"status.preview":             "👁 Preview",
+
+	// 规则模拟
+	"simulate.title":    "📈 Rule simulation (last 24 hours)",
+	"simulate.no_rules": "📈 Rule simulation\n\nNo rules are enabled",
+	"simulate.summary":  "Replayed %d draws, %d alerts would have been sent",
+	"simulate.by_rule":  "Triggers per rule:",
+	"simulate.rule":     "%s %s: %d alerts (%d dragons)",
+	"simulate.longest":  "Longest dragon per attribute:",
+	"simulate.hint":     "💡 Adjust your rules and tap simulate again to compare",
+	"simulate.rerun":    "🔄 Simulate again",
+
+	// 提醒统计
+	"stats.title":      "📨 <b>Alert statistics for this group</b> (last %d days)",
+	"stats.group_only": "⚠️ Please use this command in a group",
+	"stats.empty":      "No alerts have been sent yet",
+	"stats.day":        "<code>%s</code> sent %d / failed %d · %d dragons · avg latency %s",
+	"stats.total":      "Total: sent %d / failed %d · %d dragons\nAvg latency %s · max latency %s",
+	"stats.last_error": "Last failure: %s\n<code>%s</code>",
+	"stats.seconds":    "%.1fs",
+
+	// 定时汇总
+	"menu.digest":           "📰 Digest mode",
+	"digest.title":          "📰 Delivery\nMode: %s\nDigest interval: %s\n\n• Individual alerts: every draw, the dragons that meet your rules\n• Digest: a periodic summary of qualifying dragons, ended dragons and the longest run per attribute\n• Both: individual alerts plus the digest",
+	"digest.mode.immediate": "🔔 Individual alerts",
+	"digest.mode.digest":    "📰 Digest",
+	"digest.mode.both":      "🔔+📰 Both",
+	"digest.every":          "every %s",
+	"digest.minutes.one":    "%d minute",
+	"digest.minutes.other":  "%d minutes",
+	"digest.header":         "📰 <b>Dragon digest</b> (%s)",
+	"digest.active":         "🔥 <b>Running</b> (%d)",
+	"digest.ended":          "🏁 <b>Ended</b> (%d)",
+	"digest.ended.line":     "%s: <b>%s</b> %s~%s",
+	"digest.longest":        "📏 <b>Longest per attribute</b>",
+
+	// 路单
+	"menu.road.on":  "🛣 Attach road map to alerts",
+	"menu.road.off": "🛣 Stop attaching road maps",
+	"road.caption":  "🛣 %s road map, last %s\nTop: bead plate  Bottom: big road\n%s\nLatest: %s (yellow box)",
+	"road.usage":    "Usage: /road [size|parity|combo] [draws]\nDraws must be %d-%d",
+	"road.empty":    "No draw data yet",
+
+	// 走势
+	"trend.title":   "📉 <b>Draw trend</b> (%s per page, page %d)",
+	"trend.usage":   "Usage: /trend [draws]\nDraws must be %d-%d",
+	"trend.qihao":   "Draw",
+	"trend.numbers": "Numbers",
+	"trend.sum":     "Sum",
+	"trend.runs":    "🔥 <b>Current runs</b>",
+	"trend.run":     "%s: %s for %s",
+	"trend.older":   "◀️ Older",
+	"trend.newer":   "Newer ▶️",
+	"trend.latest":  "🔄 Latest",
+
+	// 当前长龙
+	"current.title":   "🔎 <b>Dragons running now</b>",
+	"current.reached": "🔥alert threshold reached",
+	"current.need":    "%s to go",
+	"current.rarity":  "rarity %d/%d",
+	"current.off":     "alerts off",
+
+	// 历史长龙
+	"history.title":       "📜 <b>Dragon history for this group</b> (%d total)",
+	"history.filter":      "Filter: %s",
+	"history.empty":       "No dragons match",
+	"history.usage":       "Usage: /history [attribute] [pattern] [min length] [from date] [to date]\nAttributes: size parity sum combo\nPatterns: a ab abb ab_ac ab_cd abab\nDates: 2024-05-01 or 05-01, or 7d for the last 7 days\nExample: /history size a 8 7d",
+	"history.line":        "%s %s <b>%s</b>\n   %s~%s · %s",
+	"history.older":       "Older ▶️",
+	"history.newer":       "◀️ Newer",
+	"history.detail":      "📜 <b>%s %s</b> %s\n\nDraws: %s ~ %s\nFinal length: <b>%s</b>\nTime: %s ~ %s (%s)\nFirst alert: %s, last alerted at %s\nAlert messages: %d",
+	"history.pattern":     "Sequence: %s",
+	"history.duration.m":  "%dm",
+	"history.duration.hm": "%dh %dm",
+
+	// 规则命令
+	"rules.cmd.usage.set":     "Usage: /set <attribute> <pattern> <trigger>\nAttributes: size parity sum combo, * for all\nPatterns: a ab abb ab_ac ab_cd abab, * for all\nTrigger: a number is a length (draws for a, groups otherwise), r60 triggers at rarity 60\nExample: /set size a 6",
+	"rules.cmd.usage.enable":  "Usage: /%s <attribute> <pattern>\nExamples: /enable parity abb, /disable combo *",
+	"rules.cmd.bad_attr":      "⚠️ Unknown attribute: %s\nAvailable: size parity sum combo",
+	"rules.cmd.bad_pattern":   "⚠️ Unknown pattern: %s\nAvailable: a ab abb ab_ac ab_cd abab",
+	"rules.cmd.bad_combo":     "⚠️ %s has no %s pattern",
+	"rules.cmd.bad_value":     "⚠️ The %s trigger must be %s-%s",
+	"rules.cmd.bad_rarity":    "⚠️ Rarity must be %d-%d",
+	"rules.cmd.updated.one":   "✅ Updated %d rule:",
+	"rules.cmd.updated.other": "✅ Updated %d rules:",
+	"rules.cmd.line":          "%s %s: %s %s",
+	"rules.cmd.block":         "📋 Current rules\nCopy the block below, edit it and send it after /rules to update in bulk\nEach line: attribute pattern trigger [off] (off disables the rule, r60 triggers by rarity)",
+	"rules.cmd.line_error":    "⚠️ Line %d: %s",
+	"rules.cmd.line_format":   "expected: attribute pattern trigger [off]",
+
+	// 阈值输入
+	"prompt.ask":     "✏️ %s reply with the new trigger for %s %s\nCurrent: %s\nRange: %s\nValid for %d minutes",
+	"prompt.rarity":  "rarity %d-%d",
+	"prompt.expired": "⌛ Input timed out, tap the trigger in the menu again",
+
+	// 命令菜单
+	"command.start":    "Show the welcome message and help",
+	"command.long":     "Configure dragon alerts (group admins only)",
+	"command.data":     "Show bot statistics",
+	"command.records":  "Show the dragon leaderboard",
+	"command.template": "Set the alert message template (group admins only)",
+	"command.stats":    "Show alert delivery statistics for this group",
+	"command.road":     "Show a road map (size/parity/combo)",
+	"command.trend":    "Show the recent draw trend",
+	"command.current":  "Show all dragons running now",
+	"command.history":  "Browse this group's dragon history",
+	"command.rules":    "View and bulk edit alert rules (group admins only)",
+	"command.set":      "Set a rule trigger, e.g. /set size a 6 (group admins only)",
+	"command.enable":   "Enable rules, e.g. /enable parity abb (group admins only)",
+	"command.disable":  "Disable rules, e.g. /disable combo * (group admins only)",
+
+	"list.separator": ", ",
+
+	// 提醒时段
+	"schedule.title":           "⏰ Alert hours\nTime zone: %s\nAlert hours: %s\nNow: %s\n\nDragons during quiet hours: %s",
+	"schedule.critical":        " (≥%d groups)",
+	"schedule.hint":            "An end time earlier than the start time spans midnight, e.g. 20:00-02:00",
+	"schedule.active":          "🔔 Alerting",
+	"schedule.quiet":           "🌙 Quiet",
+	"schedule.timezone":        "🌐 Time zone: %s",
+	"schedule.start":           "Start %02d:00",
+	"schedule.end":             "End %02d:00",
+	"schedule.critical_button": "Urgent ≥%d groups",
+	"schedule.policy.drop":     "Drop",
+	"schedule.policy.digest":   "Send as digest",
+	"schedule.policy.critical": "Urgent only",
+	"schedule.all_day":         "All day",
+	"schedule.every_day":       "Every day",
+	"schedule.never":           "Never",
+	"schedule.days":            "%s",
+	"weekday.0":                "Sun",
+	"weekday.1":                "Mon",
+	"weekday.2":                "Tue",
+	"weekday.3":                "Wed",
+	"weekday.4":                "Thu",
+	"weekday.5":                "Fri",
+	"weekday.6":                "Sat",
+
+	// 旧提醒清理
+	"cleanup.title":         "🧹 Old alert cleanup\nMode: %s\n\n• Delete previous alert: delete earlier alerts when a new one is sent\n• Delete after timeout: delete alerts %d minutes after they are sent\n• Reply thread: a continuing dragon replies to its first alert",
+	"cleanup.minutes":       "⏱ %d min",
+	"cleanup.mode.none":     "Keep",
+	"cleanup.mode.previous": "Delete previous alert",
+	"cleanup.mode.expire":   "Delete after timeout",
+	"cleanup.mode.thread":   "Reply thread",
+
+	// 重新加入群组
+	"rejoin.welcome":    "👋 Welcome back! Your dragon alert settings were kept",
+	"rejoin.resumed":    ", alerts have been resumed",
+	"rejoin.hint":       "Use /long to view or change the settings",
+	"rejoin.restored":   "✅ The bot has rejoined and dragon alerts have been resumed",
+	"rejoin.kicked":     "Alerts were paused because the bot was removed from the group.",
+	"rejoin.not_found":  "Alerts were paused because this group could not be found.",
+	"rejoin.permission": "Alerts were paused after %d failed sends without permission.",
+	"rejoin.last_error": "Last error: %s",
+	"rejoin.check":      "Admins, please make sure the bot is allowed to send messages. Use /long to view the settings.",
+
+	// 预览
+	"preview.no_rules": "👁 No rules are enabled, nothing to preview",
+	"preview.real":     "👁 <b>Preview</b>: the alert your rules produce for the dragons running now",
+	"preview.sample":   "👁 <b>Preview</b>: no dragon meets your rules right now, here is a sample built from them",
+	"preview.note":     "<i>This is only a preview, not a real alert</i>",
+
+	// 提醒模板
+	"template.usage":                 "📝 Alert template\nCurrent template: %s\n\nBuilt-in templates: %s\n\n/template <name> - use a built-in template\n/template set <template> - custom template (Go text/template syntax, may span lines)\n/template show - show the current template\n/template preview - preview an alert\n/template reset - restore the default template\n\nData: .Qihao .Draw .Total .Groups .Results\nEach dragon: .AttributeName .PatternName .Count .Groups .Length .Unit .Detail .StartQihao .CurrentQihao .Rarity .Stars .Odds .Percentile\nExample: 🔥 {{.Qihao}}{{range .Results}} | {{.AttributeName}} {{.PatternName}} {{.Length}} {{.Unit}}{{end}}",
+	"template.show":                  "📝 Current template: %s\n\n<pre>%s</pre>",
+	"template.invalid":               "❌ Invalid template: %s",
+	"template.switched":              "✅ Switched to the %s template, send /template preview to see it",
+	"template.error.blank":           "the template is empty",
+	"template.error.size":            "the template is too long (at most %d characters)",
+	"template.error.syntax":          "template syntax error: %v",
+	"template.error.exec":            "template execution error: %v",
+	"template.error.empty":           "the template produced no output",
+	"template.error.too_long":        "the output exceeds %d characters, Telegram cannot send it",
+	"template.error.sample_too_long": "the sample output exceeds %d characters, Telegram cannot send it",
+	"template.error.define":          "define/block is not supported",
+	"template.error.template":        "template is not supported",
+	"template.error.range_depth":     "range can be nested at most %d levels",
+	"template.error.range_field":     "range can only iterate data fields such as .Groups, .Results and .Items",
+	"template.error.tag":             "unsupported tag <%s>",
+	"template.error.tag_close":       "tag </%s> is not closed correctly",
+	"template.error.tag_open":        "tag <%s> is not closed",
+}
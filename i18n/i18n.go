@@ -0,0 +1,145 @@
+// Package i18n 机器人文字的多语言目录
+package i18n
+
+import (
+	"fmt"
+	"strings"
+)
+
+// 支持的语言
+const (
+	ZhCN = "zh-CN"
+	ZhTW = "zh-TW"
+	En   = "en"
+
+	Default = ZhCN // 未设置语言的群组使用简体中文
+)
+
+// Languages 支持的语言（按菜单切换顺序）
+var Languages = []string{ZhCN, ZhTW, En}
+
+// catalogs 各语言的消息目录，键在所有语言中必须一致
+var catalogs = map[string]map[string]string{
+	ZhCN: zhCN,
+	ZhTW: zhTW,
+	En:   en,
+}
+
+// Normalize 将未知或空的语言转换为默认语言
+func Normalize(lang string) string {
+	if _, ok := catalogs[lang]; ok {
+		return lang
+	}
+	return Default
+}
+
+// FromLanguageCode 根据 Telegram 用户的 language_code（IETF 语言标签）选择语言
+// zh-hant、zh-tw、zh-hk、zh-mo 使用繁体中文，其他中文使用简体中文，其余语言使用英文
+func FromLanguageCode(code string) string {
+	code = strings.ToLower(strings.ReplaceAll(code, "_", "-"))
+	switch {
+	case code == "":
+		return Default
+	case code == "zh-hant" || strings.HasPrefix(code, "zh-hant-") ||
+		code == "zh-tw" || code == "zh-hk" || code == "zh-mo":
+		return ZhTW
+	case code == "zh" || strings.HasPrefix(code, "zh-"):
+		return ZhCN
+	}
+	return En
+}
+
+// Next 返回切换顺序中的下一个语言
+func Next(lang string) string {
+	lang = Normalize(lang)
+	for i, l := range Languages {
+		if l == lang {
+			return Languages[(i+1)%len(Languages)]
+		}
+	}
+	return Default
+}
+
+// Name 语言自身的名称（如"English"）
+func Name(lang string) string {
+	return T(lang, "language.name")
+}
+
+// T 查找 key 对应的文字并用 args 格式化；当前语言缺少时退回默认语言，都没有时返回 key
+func T(lang, key string, args ...interface{}) string {
+	text, ok := catalogs[Normalize(lang)][key]
+	if !ok {
+		if text, ok = catalogs[Default][key]; !ok {
+			return key
+		}
+	}
+
+	if len(args) == 0 {
+		return text
+	}
+	return fmt.Sprintf(text, args...)
+}
+
+// pluralForm 返回 n 对应的复数形式：中文不区分单复数，英文 1 为 one
+func pluralForm(lang string, n int) string {
+	if Normalize(lang) == En && (n == 1 || n == -1) {
+		return "one"
+	}
+	return "other"
+}
+
+// Plural 按 n 选择 key.one 或 key.other 并格式化
+func Plural(lang, key string, n int, args ...interface{}) string {
+	return T(lang, key+"."+pluralForm(lang, n), args...)
+}
+
+// 长度单位
+const (
+	UnitDraw  = "draw"  // 期
+	UnitGroup = "group" // 组
+	UnitTime  = "time"  // 次
+)
+
+// Unit 返回数量 n 对应的单位文字（如"期"、"draws"）
+func Unit(lang, unit string, n int) string {
+	return Plural(lang, "unit."+unit, n)
+}
+
+// Count 返回带单位的数量（如"5期"、"5 draws"）
+func Count(lang, unit string, n int) string {
+	return T(lang, "unit.count", n, Unit(lang, unit, n))
+}
+
+// Value 翻译开奖属性值（大、小、单、双）
+func Value(lang, value string) string {
+	if key, ok := valueKeys[value]; ok {
+		return T(lang, key)
+	}
+	return value
+}
+
+// Values 翻译模式详情中的属性值（如"大单 小双"），使用简写
+func Values(lang, detail string) string {
+	lang = Normalize(lang)
+	if lang == Default {
+		return detail
+	}
+
+	var b strings.Builder
+	for _, r := range detail {
+		if key, ok := valueKeys[string(r)]; ok {
+			b.WriteString(T(lang, key+".short"))
+		} else {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// valueKeys 开奖属性值对应的目录键
+var valueKeys = map[string]string{
+	"大": "value.big",
+	"小": "value.small",
+	"单": "value.odd",
+	"双": "value.even",
+}
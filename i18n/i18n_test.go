@@ -0,0 +1,238 @@
+package i18n
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"unicode"
+)
+
+// TestCatalogsComplete 每个键都必须在所有语言中存在
+func TestCatalogsComplete(t *testing.T) {
+	for lang, catalog := range catalogs {
+		for key := range catalog {
+			for other, otherCatalog := range catalogs {
+				if _, ok := otherCatalog[key]; !ok {
+					t.Errorf("%s 缺少键 %q（%s 中存在）", other, key, lang)
+				}
+			}
+		}
+	}
+}
+
+var verbPattern = regexp.MustCompile(`%[-+# 0-9.]*[a-zA-Z%]`)
+
+// TestFormatVerbs 同一个键在各语言中的格式化占位符必须一致
+func TestFormatVerbs(t *testing.T) {
+	for key, text := range catalogs[Default] {
+		want := strings.Join(verbPattern.FindAllString(text, -1), " ")
+		for lang, catalog := range catalogs {
+			got := strings.Join(verbPattern.FindAllString(catalog[key], -1), " ")
+			if got != want {
+				t.Errorf("%s %q 的占位符为 [%s]，%s 为 [%s]", lang, key, got, Default, want)
+			}
+		}
+	}
+}
+
+var (
+	// i18n.T(lang, "key") 与 i18n.Plural(lang, "key", ...)，拼接的键（"pattern."+p）由 dynamicKeys 覆盖
+	callPattern = regexp.MustCompile(`i18n\.(T|Plural)\([^,()]+, "([^"]+)"\s*[,)]`)
+	// 内置模板中的 {{$.T "key"
+	templatePattern = regexp.MustCompile(`\$\.T "([^"]+)"`)
+)
+
+// dynamicKeys 代码中拼接生成的键
+func dynamicKeys() []string {
+	keys := []string{"language.name"}
+	for _, p := range []string{"a", "ab", "abb", "ab_ac", "ab_cd", "abab"} {
+		keys = append(keys, "pattern."+p, "pattern."+p+".full", "rules.pattern."+p)
+	}
+	for _, a := range []string{"size", "parity", "sum", "size_parity"} {
+		keys = append(keys, "attr."+a, "attr."+a+".title")
+	}
+	for _, w := range []string{"day", "week", "all"} {
+		keys = append(keys, "record.window."+w)
+	}
+	for _, u := range []string{UnitDraw, UnitGroup, UnitTime} {
+		keys = append(keys, "unit."+u+".one", "unit."+u+".other")
+	}
+	for _, key := range valueKeys {
+		keys = append(keys, key, key+".short")
+	}
+	for _, m := range []string{"none", "previous", "expire", "thread"} {
+		keys = append(keys, "cleanup.mode."+m)
+	}
+	for _, p := range []string{"drop", "digest", "critical"} {
+		keys = append(keys, "schedule.policy."+p)
+	}
+	for d := 0; d < 7; d++ {
+		keys = append(keys, "weekday."+strconv.Itoa(d))
+	}
+	for _, c := range commandNames {
+		keys = append(keys, "command."+c)
+	}
+	return append(keys, "unit.count")
+}
+
+// commandNames 命令菜单中的命令（与 bot.commandNames 一致）
+var commandNames = []string{
+	"start", "long", "data", "records", "template", "stats", "road", "trend",
+	"current", "history", "rules", "set", "enable", "disable",
+}
+
+// sourceFiles 使用消息目录的源文件（不含测试）
+func sourceFiles(t *testing.T) []string {
+	var files []string
+	for _, pattern := range []string{"../bot/*.go", "../schedule/*.go"} {
+		matches, err := filepath.Glob(pattern)
+		if err != nil || len(matches) == 0 {
+			t.Fatalf("找不到源文件 %s: %v", pattern, err)
+		}
+		for _, file := range matches {
+			if !strings.HasSuffix(file, "_test.go") {
+				files = append(files, file)
+			}
+		}
+	}
+	return files
+}
+
+// TestUsedKeysExist 代码中使用的键都必须在目录中，缺少时测试失败
+func TestUsedKeysExist(t *testing.T) {
+	keys := dynamicKeys()
+	for _, file := range sourceFiles(t) {
+		src, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, m := range callPattern.FindAllStringSubmatch(string(src), -1) {
+			if m[1] == "Plural" {
+				keys = append(keys, m[2]+".one", m[2]+".other")
+			} else {
+				keys = append(keys, m[2])
+			}
+		}
+		for _, m := range templatePattern.FindAllStringSubmatch(string(src), -1) {
+			keys = append(keys, m[1])
+		}
+	}
+
+	for _, key := range keys {
+		for lang, catalog := range catalogs {
+			if _, ok := catalog[key]; !ok {
+				t.Errorf("%s 缺少键 %q", lang, key)
+			}
+		}
+	}
+}
+
+// attributeValues 开奖属性值，作为数据而不是显示文字出现在代码中
+var attributeValues = map[string]bool{
+	"大": true, "小": true, "单": true, "双": true,
+	"大单": true, "大双": true, "小单": true, "小双": true,
+}
+
+// TestNoHardcodedChinese 显示给用户的文字必须放在消息目录中
+// 允许的中文字符串：日志、map 的键（命令别名等输入）、== 比较的输入、开奖属性值
+func TestNoHardcodedChinese(t *testing.T) {
+	fset := token.NewFileSet()
+	for _, file := range sourceFiles(t) {
+		f, err := parser.ParseFile(fset, file, nil, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		allowed := make(map[*ast.BasicLit]bool)
+		allow := func(node ast.Node) {
+			ast.Inspect(node, func(n ast.Node) bool {
+				if lit, ok := n.(*ast.BasicLit); ok {
+					allowed[lit] = true
+				}
+				return true
+			})
+		}
+
+		ast.Inspect(f, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.CallExpr:
+				if sel, ok := n.Fun.(*ast.SelectorExpr); ok {
+					if pkg, ok := sel.X.(*ast.Ident); ok && pkg.Name == "log" {
+						allow(n)
+					}
+				}
+			case *ast.KeyValueExpr:
+				allow(n.Key)
+			case *ast.BinaryExpr:
+				if n.Op == token.EQL || n.Op == token.NEQ {
+					allow(n)
+				}
+			case *ast.BasicLit:
+				if n.Kind != token.STRING || allowed[n] {
+					return true
+				}
+				value, err := strconv.Unquote(n.Value)
+				if err != nil || attributeValues[value] {
+					return true
+				}
+				for _, r := range value {
+					if unicode.Is(unicode.Han, r) {
+						t.Errorf("%s: 字符串 %s 含有中文，请放到消息目录中", fset.Position(n.Pos()), n.Value)
+						break
+					}
+				}
+			}
+			return true
+		})
+	}
+}
+
+func TestFromLanguageCode(t *testing.T) {
+	cases := map[string]string{
+		"":           Default,
+		"zh":         ZhCN,
+		"zh-hans":    ZhCN,
+		"zh-CN":      ZhCN,
+		"zh-hant":    ZhTW,
+		"zh-Hant-HK": ZhTW,
+		"zh-TW":      ZhTW,
+		"zh_HK":      ZhTW,
+		"en":         En,
+		"en-US":      En,
+		"ru":         En,
+	}
+
+	for code, want := range cases {
+		if got := FromLanguageCode(code); got != want {
+			t.Errorf("FromLanguageCode(%q) = %s, want %s", code, got, want)
+		}
+	}
+}
+
+func TestPlural(t *testing.T) {
+	cases := []struct {
+		lang, unit string
+		n          int
+		want       string
+	}{
+		{ZhCN, UnitDraw, 1, "1期"},
+		{ZhTW, UnitGroup, 3, "3組"},
+		{En, UnitDraw, 1, "1 draw"},
+		{En, UnitDraw, 5, "5 draws"},
+		{En, UnitGroup, 1, "1 group"},
+		{"", UnitGroup, 2, "2组"},
+	}
+
+	for _, c := range cases {
+		if got := Count(c.lang, c.unit, c.n); got != c.want {
+			t.Errorf("Count(%s, %s, %d) = %q, want %q", c.lang, c.unit, c.n, got, c.want)
+		}
+	}
+}
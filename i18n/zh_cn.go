@@ -0,0 +1,355 @@
+package i18n
+
+// zhCN 简体中文（默认语言）
+var zhCN = map[string]string{
+	"language.name": "简体中文",
+
+	// 单位
+	"unit.count":       "%d%s",
+	"unit.draw.one":    "期",
+	"unit.draw.other":  "期",
+	"unit.group.one":   "组",
+	"unit.group.other": "组",
+	"unit.time.one":    "次",
+	"unit.time.other":  "次",
+
+	// 开奖属性值
+	"value.big":         "大",
+	"value.big.short":   "大",
+	"value.small":       "小",
+	"value.small.short": "小",
+	"value.odd":         "单",
+	"value.odd.short":   "单",
+	"value.even":        "双",
+	"value.even.short":  "双",
+
+	// 格式与属性名称
+	"pattern.a":          "连续",
+	"pattern.ab":         "交替",
+	"pattern.abb":        "abb",
+	"pattern.ab_ac":      "固定交替",
+	"pattern.ab_cd":      "双交替",
+	"pattern.abab":       "组合重复",
+	"pattern.a.full":     "a连续",
+	"pattern.ab.full":    "ab交替",
+	"pattern.abb.full":   "abb",
+	"pattern.ab_ac.full": "ab,ac固定交替",
+	"pattern.ab_cd.full": "ab,cd双交替",
+	"pattern.abab.full":  "abab组合重复",
+
+	"attr.size":              "大小",
+	"attr.parity":            "单双",
+	"attr.sum":               "和值",
+	"attr.size_parity":       "组合",
+	"attr.size.title":        "📊大小",
+	"attr.parity.title":      "🎯单双",
+	"attr.sum.title":         "🔢和值",
+	"attr.size_parity.title": "🔄组合",
+
+	// 长龙提醒
+	"alert.title":         "长龙提醒",
+	"alert.draw":          "<code>%s</code>期 开奖号码: <b>%s=%d</b> %s%s",
+	"alert.qihao":         "当前期号: <code>%s</code>期",
+	"alert.group":         "【%s长龙】",
+	"alert.item":          "%s格式 连续<b>%d%s</b>",
+	"alert.start":         "起始: %s期",
+	"alert.rarity":        "稀有度: <b>%d</b> %s 理论概率约%s",
+	"alert.percentile":    "，超过历史%d%%的同类长龙",
+	"alert.compact.draw":  "<code>%s</code>期 <b>%s=%d</b> %s%s",
+	"alert.compact.qihao": "<code>%s</code>期",
+	"alert.compact.item":  "%s%s <b>%d%s</b> 起始%s",
+	"alert.oneline.item":  "%s%s<b>%d%s</b>",
+
+	// 纪录
+	"record.broken.title": "🏆 <b>打破纪录</b>",
+	"record.tied.title":   "🏅 <b>追平纪录</b>",
+	"record.broken":       "%s%s格式 已连续 <b>%s</b>，打破%s纪录（%s）",
+	"record.tied":         "%s%s格式 已连续 <b>%s</b>，追平%s纪录（%s）",
+	"record.window.day":   "24小时",
+	"record.window.week":  "7天",
+	"record.window.all":   "历史",
+	"leaderboard.title":   "🏆 <b>长龙纪录榜</b>\n24小时 / 7天 / 历史最长（期）",
+	"leaderboard.empty":   "暂无纪录",
+
+	// 实时榜单
+	"board.title":   "📌 <b>实时长龙榜</b>",
+	"board.line":    "%s: <b>%s</b> 起始%s期",
+	"board.none":    "暂无",
+	"board.updated": "🕒 更新于 %s",
+
+	// 静默时段汇总
+	"quiet.title.one":   "🌙 <b>静默时段长龙汇总</b>\n共 %d 条长龙",
+	"quiet.title.other": "🌙 <b>静默时段长龙汇总</b>\n共 %d 条长龙",
+	"quiet.line":        "%s: <b>%s</b> %s~%s期 %s",
+	"quiet.ended":       "已结束",
+	"quiet.active":      "🔥进行中",
+
+	// 命令
+	"welcome.group": `欢迎使用长龙提醒机器人！🎲
+
+功能：
+• 自动监测开奖数据
+• 识别各种长龙模式
+• 自定义提醒规则
+
+命令：
+/long - 配置长龙提醒（仅管理员）`,
+	"welcome.private": `欢迎使用长龙提醒机器人！🎲
+
+⚠️ 本机器人仅支持群组使用
+
+功能特点：
+• 自动监测开奖数据
+• 识别多种长龙模式
+• 灵活的自定义规则
+
+使用步骤：
+1. 点击下方按钮添加到群组
+2. 在群组中发送 /long 命令
+3. 管理员可配置提醒规则`,
+	"welcome.add_button": "➕ 添加机器人到群组",
+	"dragon.group_only":  "⚠️ 长龙提醒仅支持群组使用\n\n请点击下方按钮将机器人添加到群组",
+	"dragon.add_button":  "➕ 添加到群组",
+	"admin_only":         "⚠️ 仅限群组管理员操作",
+	"data.text": `📊 <b>机器人数据统计</b>
+
+👥 <b>群组数据</b>
+• 总群组数: <code>%d</code>
+• 启用提醒: <code>%d</code>
+• 禁用提醒: <code>%d</code>
+
+⚙️ <b>配置数据</b>
+• 启用规则: <code>%d</code> 条
+
+🔥 <b>长龙数据</b>
+• 活跃长龙: <code>%d</code> 个
+
+📨 <b>今日提醒</b>
+• 发送成功: <code>%d</code> 条
+• 发送失败: <code>%d</code> 条
+• 平均延迟: <code>%s</code>
+
+💡 使用 /long 配置长龙提醒`,
+
+	// 主菜单
+	"menu.title":       "🎲 长龙提醒配置\n当前状态: %s\n提醒方式: %s",
+	"menu.enabled":     "✅ 已启用",
+	"menu.disabled":    "❌ 已禁用",
+	"menu.enable":      "✅ 启用提醒",
+	"menu.disable":     "❌ 禁用提醒",
+	"menu.mode.alerts": "逐条提醒",
+	"menu.mode.board":  "📌 实时榜单（每期编辑置顶消息）",
+	"menu.to_board":    "📌 切换为实时榜单",
+	"menu.to_alerts":   "🔔 切换为逐条提醒",
+	"menu.schedule":    "⏰ 提醒时段",
+	"menu.cleanup":     "🧹 旧提醒清理",
+	"menu.size":        "📊 配置大小长龙",
+	"menu.parity":      "🎯 配置单双长龙",
+	"menu.sum":         "🔢 配置和值长龙",
+	"menu.combo":       "🔄 配置组合长龙",
+	"menu.status":      "📋 查看配置状态",
+	"menu.simulate":    "📈 模拟",
+	"menu.preview":     "👁 预览提醒",
+	"menu.back_main":   "◀️ 返回主菜单",
+	"menu.back":        "◀️ 返回",
+
+	// 规则菜单
+	"rules.attr.title":           "🎲 %s长龙配置\n[+][-]调整触发值 | 点击名称切换启用\n点击触发值可直接输入\n📏按长度触发 | 💎按稀有度触发",
+	"rules.combo.title":          "🔄 组合长龙配置\n大小+单双组合 | [+][-]调整触发值\n点击触发值可直接输入\n📏按长度触发 | 💎按稀有度触发",
+	"rules.pattern.a":            "a格式(连续)",
+	"rules.pattern.ab":           "ab格式(交替)",
+	"rules.pattern.abb":          "abb格式(A-B-B组)",
+	"rules.pattern.ab_ac":        "ab,ac格式(固定+交替)",
+	"rules.pattern.ab_cd":        "ab,cd格式(同时交替)",
+	"rules.pattern.abab":         "abab格式(组合重复)",
+	"rules.mode.rarity":          "💎稀有度",
+	"rules.mode.length":          "📏长度",
+	"rules.trigger.rarity":       "稀有度≥%d",
+	"rules.trigger.length":       "触发: %s",
+	"status.title":               "📋 配置状态",
+	"status.enabled_rules.one":   "已启用 %d 条规则",
+	"status.enabled_rules.other": "已启用 %d 条规则",
+	"status.refresh":             "🔄 刷新",
+	"status.preview":             "👁 预览",
+
+	// 规则模拟
+	"simulate.title":    "📈 规则模拟（最近24小时）",
+	"simulate.no_rules": "📈 规则模拟\n\n当前没有启用的规则",
+	"simulate.summary":  "回放 %d 期，共会发送 %d 条提醒",
+	"simulate.by_rule":  "按规则触发:",
+	"simulate.rule":     "%s %s: %d次 (%d条长龙)",
+	"simulate.longest":  "各属性最长长龙:",
+	"simulate.hint":     "💡 调整规则后可点击重新模拟对比",
+	"simulate.rerun":    "🔄 重新模拟",
+
+	// 提醒统计
+	"stats.title":      "📨 <b>本群提醒统计</b>（最近%d天）",
+	"stats.group_only": "⚠️ 请在群组中使用此命令",
+	"stats.empty":      "暂无提醒发送记录",
+	"stats.day":        "<code>%s</code> 成功 %d 条 / 失败 %d 条 · %d 条长龙 · 平均延迟 %s",
+	"stats.total":      "合计: 成功 %d 条 / 失败 %d 条 · %d 条长龙\n平均延迟 %s · 最大延迟 %s",
+	"stats.last_error": "最近一次失败: %s\n<code>%s</code>",
+	"stats.seconds":    "%.1f秒",
+
+	// 定时汇总
+	"menu.digest":           "📰 汇总模式",
+	"digest.title":          "📰 提醒方式\n当前方式: %s\n汇总间隔: %s\n\n• 逐条提醒：每期发送达到条件的长龙\n• 定时汇总：按间隔汇总达到条件的长龙、已结束的长龙和各属性最长的长龙\n• 两者都发送：逐条提醒并定时汇总",
+	"digest.mode.immediate": "🔔 逐条提醒",
+	"digest.mode.digest":    "📰 定时汇总",
+	"digest.mode.both":      "🔔+📰 两者都发送",
+	"digest.every":          "每%s",
+	"digest.minutes.one":    "%d分钟",
+	"digest.minutes.other":  "%d分钟",
+	"digest.header":         "📰 <b>长龙汇总</b>（%s）",
+	"digest.active":         "🔥 <b>进行中</b>（%d 条）",
+	"digest.ended":          "🏁 <b>已结束</b>（%d 条）",
+	"digest.ended.line":     "%s: <b>%s</b> %s~%s期",
+	"digest.longest":        "📏 <b>各属性最长</b>",
+
+	// 路单
+	"menu.road.on":  "🛣 提醒附带路单图",
+	"menu.road.off": "🛣 取消提醒附带路单图",
+	"road.caption":  "🛣 %s路单 最近%s\n上：珠盘路  下：大路\n%s\n最新: %s期（黄框）",
+	"road.usage":    "用法: /road [size|parity|combo] [期数]\n期数范围 %d-%d",
+	"road.empty":    "暂无开奖数据",
+
+	// 走势
+	"trend.title":   "📉 <b>开奖走势</b>（每页%s，第%d页）",
+	"trend.usage":   "用法: /trend [期数]\n期数范围 %d-%d",
+	"trend.qihao":   "期号",
+	"trend.numbers": "号码",
+	"trend.sum":     "和",
+	"trend.runs":    "🔥 <b>当前连续</b>",
+	"trend.run":     "%s: %s 连续%s",
+	"trend.older":   "◀️ 更早",
+	"trend.newer":   "较新 ▶️",
+	"trend.latest":  "🔄 最新",
+
+	// 当前长龙
+	"current.title":   "🔎 <b>当前进行中的长龙</b>",
+	"current.reached": "🔥已达到提醒条件",
+	"current.need":    "还差%s",
+	"current.rarity":  "稀有度 %d/%d",
+	"current.off":     "未启用提醒",
+
+	// 历史长龙
+	"history.title":       "📜 <b>本群历史长龙</b>（共 %d 条）",
+	"history.filter":      "筛选: %s",
+	"history.empty":       "没有符合条件的长龙",
+	"history.usage":       "用法: /history [属性] [格式] [最小期数] [开始日期] [结束日期]\n属性: 大小 单双 和值 组合\n格式: a ab abb ab_ac ab_cd abab\n日期: 2024-05-01 或 05-01，也可以用 7d 表示最近7天\n例如: /history 大小 a 8 7d",
+	"history.line":        "%s %s <b>%s</b>\n   %s~%s期 · %s",
+	"history.older":       "更早 ▶️",
+	"history.newer":       "◀️ 较新",
+	"history.detail":      "📜 <b>%s %s</b> %s\n\n期号: %s ~ %s期\n最终长度: <b>%s</b>\n时间: %s ~ %s（%s）\n首次提醒: %s，最后提醒时 %s\n提醒消息: %d 条",
+	"history.pattern":     "走势: %s",
+	"history.duration.m":  "%d分钟",
+	"history.duration.hm": "%d小时%d分钟",
+
+	// 规则命令
+	"rules.cmd.usage.set":     "用法: /set <属性> <格式> <触发值>\n属性: 大小(size) 单双(parity) 和值(sum) 组合(combo)，* 表示全部\n格式: a ab abb ab_ac ab_cd abab，* 表示全部\n触发值: 数字为长度（a格式为期数，其他为组数），r60 表示按稀有度60触发\n例如: /set size a 6",
+	"rules.cmd.usage.enable":  "用法: /%s <属性> <格式>\n例如: /enable parity abb、/disable combo *",
+	"rules.cmd.bad_attr":      "⚠️ 未知属性: %s\n可用: 大小(size) 单双(parity) 和值(sum) 组合(combo)",
+	"rules.cmd.bad_pattern":   "⚠️ 未知格式: %s\n可用: a ab abb ab_ac ab_cd abab",
+	"rules.cmd.bad_combo":     "⚠️ %s没有%s格式",
+	"rules.cmd.bad_value":     "⚠️ %s的触发值范围为 %s-%s",
+	"rules.cmd.bad_rarity":    "⚠️ 稀有度范围为 %d-%d",
+	"rules.cmd.updated.one":   "✅ 已更新 %d 条规则:",
+	"rules.cmd.updated.other": "✅ 已更新 %d 条规则:",
+	"rules.cmd.line":          "%s %s: %s %s",
+	"rules.cmd.block":         "📋 当前规则\n复制下面的内容修改后，以 /rules 开头发送即可批量更新\n每行: 属性 格式 触发值 [off]（off 表示停用，r60 表示按稀有度触发）",
+	"rules.cmd.line_error":    "⚠️ 第%d行: %s",
+	"rules.cmd.line_format":   "格式应为: 属性 格式 触发值 [off]",
+
+	// 阈值输入
+	"prompt.ask":     "✏️ %s 请回复 %s %s 的新触发值\n当前: %s\n范围: %s\n%d分钟内有效",
+	"prompt.rarity":  "稀有度%d-%d",
+	"prompt.expired": "⌛ 输入已超时，请重新点击菜单中的触发值",
+
+	// 命令菜单
+	"command.start":    "查看欢迎信息和使用说明",
+	"command.long":     "配置长龙提醒（仅群组管理员）",
+	"command.data":     "查看机器人数据统计",
+	"command.records":  "查看长龙纪录榜",
+	"command.template": "设置提醒消息模板（仅群组管理员）",
+	"command.stats":    "查看本群组的提醒发送统计",
+	"command.road":     "查看路单图（大小/单双/组合）",
+	"command.trend":    "查看最近开奖走势",
+	"command.current":  "查看当前所有进行中的长龙",
+	"command.history":  "浏览本群组的历史长龙",
+	"command.rules":    "查看和批量修改提醒规则（仅群组管理员）",
+	"command.set":      "设置规则触发值，如 /set size a 6（仅群组管理员）",
+	"command.enable":   "启用规则，如 /enable parity abb（仅群组管理员）",
+	"command.disable":  "停用规则，如 /disable combo *（仅群组管理员）",
+
+	"list.separator": "、",
+
+	// 提醒时段
+	"schedule.title":           "⏰ 提醒时段\n时区: %s\n提醒时段: %s\n当前: %s\n\n静默时段的长龙: %s",
+	"schedule.critical":        "（≥%d组）",
+	"schedule.hint":            "结束时间早于开始时间表示跨午夜，如 20:00-02:00",
+	"schedule.active":          "🔔 提醒中",
+	"schedule.quiet":           "🌙 静默中",
+	"schedule.timezone":        "🌐 切换时区: %s",
+	"schedule.start":           "开始 %02d:00",
+	"schedule.end":             "结束 %02d:00",
+	"schedule.critical_button": "紧急 ≥%d组",
+	"schedule.policy.drop":     "丢弃",
+	"schedule.policy.digest":   "汇总后发送",
+	"schedule.policy.critical": "仅紧急长龙",
+	"schedule.all_day":         "全天",
+	"schedule.every_day":       "每天",
+	"schedule.never":           "不提醒",
+	"schedule.days":            "周%s",
+	"weekday.0":                "日",
+	"weekday.1":                "一",
+	"weekday.2":                "二",
+	"weekday.3":                "三",
+	"weekday.4":                "四",
+	"weekday.5":                "五",
+	"weekday.6":                "六",
+
+	// 旧提醒清理
+	"cleanup.title":         "🧹 旧提醒清理\n当前方式: %s\n\n• 删除上一条提醒：发送新提醒后删除之前的提醒\n• 超时删除：提醒发送 %d 分钟后自动删除\n• 回复串联：延续的长龙回复到它的第一条提醒",
+	"cleanup.minutes":       "⏱ %d分钟",
+	"cleanup.mode.none":     "不清理",
+	"cleanup.mode.previous": "删除上一条提醒",
+	"cleanup.mode.expire":   "超时删除",
+	"cleanup.mode.thread":   "回复串联",
+
+	// 重新加入群组
+	"rejoin.welcome":    "👋 欢迎回来！之前的长龙提醒配置已保留",
+	"rejoin.resumed":    "，提醒已恢复",
+	"rejoin.hint":       "使用 /long 查看或修改配置",
+	"rejoin.restored":   "✅ 机器人已重新加入，长龙提醒已恢复",
+	"rejoin.kicked":     "此前机器人被移出群组，提醒已自动暂停。",
+	"rejoin.not_found":  "此前无法找到本群组，提醒已自动暂停。",
+	"rejoin.permission": "此前因没有发送权限连续失败 %d 次，提醒已自动暂停。",
+	"rejoin.last_error": "最后一次错误：%s",
+	"rejoin.check":      "请管理员确认机器人拥有发送消息的权限，可通过 /long 查看配置。",
+
+	// 预览
+	"preview.no_rules": "👁 当前没有启用的规则，无法预览",
+	"preview.real":     "👁 <b>预览</b>：以下为当前真实长龙按本群规则生成的提醒",
+	"preview.sample":   "👁 <b>预览</b>：当前没有达到阈值的长龙，以下为按本群规则生成的示例",
+	"preview.note":     "<i>此消息仅为预览，并非真实提醒</i>",
+
+	// 提醒模板
+	"template.usage":                 "📝 提醒模板\n当前模板: %s\n\n内置模板: %s\n\n/template <名称> - 使用内置模板\n/template set <模板内容> - 自定义模板（Go text/template 语法，可换行）\n/template show - 查看当前模板内容\n/template preview - 预览提醒效果\n/template reset - 恢复默认模板\n\n可用数据: .Qihao .Draw .Total .Groups .Results\n每条长龙: .AttributeName .PatternName .Count .Groups .Length .Unit .Detail .StartQihao .CurrentQihao .Rarity .Stars .Odds .Percentile\n示例: 🔥 {{.Qihao}}期{{range .Results}} | {{.AttributeName}}{{.PatternName}} {{.Length}}{{.Unit}}{{end}}",
+	"template.show":                  "📝 当前模板: %s\n\n<pre>%s</pre>",
+	"template.invalid":               "❌ 模板无效: %s",
+	"template.switched":              "✅ 已切换为 %s 模板，发送 /template preview 查看效果",
+	"template.error.blank":           "模板内容为空",
+	"template.error.size":            "模板过长（最多%d字）",
+	"template.error.syntax":          "模板语法错误: %v",
+	"template.error.exec":            "模板执行错误: %v",
+	"template.error.empty":           "模板输出为空",
+	"template.error.too_long":        "输出超过%d字，Telegram 无法发送",
+	"template.error.sample_too_long": "示例输出超过%d字，Telegram 无法发送",
+	"template.error.define":          "不支持 define/block",
+	"template.error.template":        "不支持 template",
+	"template.error.range_depth":     "range 最多嵌套%d层",
+	"template.error.range_field":     "range 只能遍历 .Groups、.Results、.Items 等数据字段",
+	"template.error.tag":             "不支持的标签 <%s>",
+	"template.error.tag_close":       "标签 </%s> 没有正确闭合",
+	"template.error.tag_open":        "标签 <%s> 没有闭合",
+}
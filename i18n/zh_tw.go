@@ -0,0 +1,355 @@
+package i18n
+
+// zhTW 繁体中文
+var zhTW = map[string]string{
+	"language.name": "繁體中文",
+
+	// 单位
+	"unit.count":       "%d%s",
+	"unit.draw.one":    "期",
+	"unit.draw.other":  "期",
+	"unit.group.one":   "組",
+	"unit.group.other": "組",
+	"unit.time.one":    "次",
+	"unit.time.other":  "次",
+
+	// 开奖属性值
+	"value.big":         "大",
+	"value.big.short":   "大",
+	"value.small":       "小",
+	"value.small.short": "小",
+	"value.odd":         "單",
+	"value.odd.short":   "單",
+	"value.even":        "雙",
+	"value.even.short":  "雙",
+
+	// 格式与属性名称
+	"pattern.a":          "連續",
+	"pattern.ab":         "交替",
+	"pattern.abb":        "abb",
+	"pattern.ab_ac":      "固定交替",
+	"pattern.ab_cd":      "雙交替",
+	"pattern.abab":       "組合重複",
+	"pattern.a.full":     "a連續",
+	"pattern.ab.full":    "ab交替",
+	"pattern.abb.full":   "abb",
+	"pattern.ab_ac.full": "ab,ac固定交替",
+	"pattern.ab_cd.full": "ab,cd雙交替",
+	"pattern.abab.full":  "abab組合重複",
+
+	"attr.size":              "大小",
+	"attr.parity":            "單雙",
+	"attr.sum":               "和值",
+	"attr.size_parity":       "組合",
+	"attr.size.title":        "📊大小",
+	"attr.parity.title":      "🎯單雙",
+	"attr.sum.title":         "🔢和值",
+	"attr.size_parity.title": "🔄組合",
+
+	// 长龙提醒
+	"alert.title":         "長龍提醒",
+	"alert.draw":          "<code>%s</code>期 開獎號碼: <b>%s=%d</b> %s%s",
+	"alert.qihao":         "當前期號: <code>%s</code>期",
+	"alert.group":         "【%s長龍】",
+	"alert.item":          "%s格式 連續<b>%d%s</b>",
+	"alert.start":         "起始: %s期",
+	"alert.rarity":        "稀有度: <b>%d</b> %s 理論機率約%s",
+	"alert.percentile":    "，超過歷史%d%%的同類長龍",
+	"alert.compact.draw":  "<code>%s</code>期 <b>%s=%d</b> %s%s",
+	"alert.compact.qihao": "<code>%s</code>期",
+	"alert.compact.item":  "%s%s <b>%d%s</b> 起始%s",
+	"alert.oneline.item":  "%s%s<b>%d%s</b>",
+
+	// 纪录
+	"record.broken.title": "🏆 <b>打破紀錄</b>",
+	"record.tied.title":   "🏅 <b>追平紀錄</b>",
+	"record.broken":       "%s%s格式 已連續 <b>%s</b>，打破%s紀錄（%s）",
+	"record.tied":         "%s%s格式 已連續 <b>%s</b>，追平%s紀錄（%s）",
+	"record.window.day":   "24小時",
+	"record.window.week":  "7天",
+	"record.window.all":   "歷史",
+	"leaderboard.title":   "🏆 <b>長龍紀錄榜</b>\n24小時 / 7天 / 歷史最長（期）",
+	"leaderboard.empty":   "暫無紀錄",
+
+	// 实时榜单
+	"board.title":   "📌 <b>即時長龍榜</b>",
+	"board.line":    "%s: <b>%s</b> 起始%s期",
+	"board.none":    "暫無",
+	"board.updated": "🕒 更新於 %s",
+
+	// 静默时段汇总
+	"quiet.title.one":   "🌙 <b>靜音時段長龍匯總</b>\n共 %d 條長龍",
+	"quiet.title.other": "🌙 <b>靜音時段長龍匯總</b>\n共 %d 條長龍",
+	"quiet.line":        "%s: <b>%s</b> %s~%s期 %s",
+	"quiet.ended":       "已結束",
+	"quiet.active":      "🔥進行中",
+
+	// 命令
+	"welcome.group": `歡迎使用長龍提醒機器人！🎲
+
+功能：
+• 自動監測開獎資料
+• 識別各種長龍模式
+• 自訂提醒規則
+
+指令：
+/long - 設定長龍提醒（僅管理員）`,
+	"welcome.private": `歡迎使用長龍提醒機器人！🎲
+
+⚠️ 本機器人僅支援群組使用
+
+功能特點：
+• 自動監測開獎資料
+• 識別多種長龍模式
+• 靈活的自訂規則
+
+使用步驟：
+1. 點擊下方按鈕加入群組
+2. 在群組中傳送 /long 指令
+3. 管理員可設定提醒規則`,
+	"welcome.add_button": "➕ 將機器人加入群組",
+	"dragon.group_only":  "⚠️ 長龍提醒僅支援群組使用\n\n請點擊下方按鈕將機器人加入群組",
+	"dragon.add_button":  "➕ 加入群組",
+	"admin_only":         "⚠️ 僅限群組管理員操作",
+	"data.text": `📊 <b>機器人資料統計</b>
+
+👥 <b>群組資料</b>
+• 總群組數: <code>%d</code>
+• 啟用提醒: <code>%d</code>
+• 停用提醒: <code>%d</code>
+
+⚙️ <b>設定資料</b>
+• 啟用規則: <code>%d</code> 條
+
+🔥 <b>長龍資料</b>
+• 活躍長龍: <code>%d</code> 個
+
+📨 <b>今日提醒</b>
+• 傳送成功: <code>%d</code> 條
+• 傳送失敗: <code>%d</code> 條
+• 平均延遲: <code>%s</code>
+
+💡 使用 /long 設定長龍提醒`,
+
+	// 主菜单
+	"menu.title":       "🎲 長龍提醒設定\n目前狀態: %s\n提醒方式: %s",
+	"menu.enabled":     "✅ 已啟用",
+	"menu.disabled":    "❌ 已停用",
+	"menu.enable":      "✅ 啟用提醒",
+	"menu.disable":     "❌ 停用提醒",
+	"menu.mode.alerts": "逐條提醒",
+	"menu.mode.board":  "📌 即時榜單（每期編輯置頂訊息）",
+	"menu.to_board":    "📌 切換為即時榜單",
+	"menu.to_alerts":   "🔔 切換為逐條提醒",
+	"menu.schedule":    "⏰ 提醒時段",
+	"menu.cleanup":     "🧹 舊提醒清理",
+	"menu.size":        "📊 設定大小長龍",
+	"menu.parity":      "🎯 設定單雙長龍",
+	"menu.sum":         "🔢 設定和值長龍",
+	"menu.combo":       "🔄 設定組合長龍",
+	"menu.status":      "📋 查看設定狀態",
+	"menu.simulate":    "📈 模擬",
+	"menu.preview":     "👁 預覽提醒",
+	"menu.back_main":   "◀️ 返回主選單",
+	"menu.back":        "◀️ 返回",
+
+	// 规则菜单
+	"rules.attr.title":           "🎲 %s長龍設定\n[+][-]調整觸發值 | 點擊名稱切換啟用\n點擊觸發值可直接輸入\n📏按長度觸發 | 💎按稀有度觸發",
+	"rules.combo.title":          "🔄 組合長龍設定\n大小+單雙組合 | [+][-]調整觸發值\n點擊觸發值可直接輸入\n📏按長度觸發 | 💎按稀有度觸發",
+	"rules.pattern.a":            "a格式(連續)",
+	"rules.pattern.ab":           "ab格式(交替)",
+	"rules.pattern.abb":          "abb格式(A-B-B組)",
+	"rules.pattern.ab_ac":        "ab,ac格式(固定+交替)",
+	"rules.pattern.ab_cd":        "ab,cd格式(同時交替)",
+	"rules.pattern.abab":         "abab格式(組合重複)",
+	"rules.mode.rarity":          "💎稀有度",
+	"rules.mode.length":          "📏長度",
+	"rules.trigger.rarity":       "稀有度≥%d",
+	"rules.trigger.length":       "觸發: %s",
+	"status.title":               "📋 設定狀態",
+	"status.enabled_rules.one":   "已啟用 %d 條規則",
+	"status.enabled_rules.other": "已啟用 %d 條規則",
+	"status.refresh":             "🔄 重新整理",
+	"status.preview":             "👁 預覽",
+
+	// 规则模拟
+	"simulate.title":    "📈 規則模擬（最近24小時）",
+	"simulate.no_rules": "📈 規則模擬\n\n目前沒有啟用的規則",
+	"simulate.summary":  "回放 %d 期，共會傳送 %d 條提醒",
+	"simulate.by_rule":  "按規則觸發:",
+	"simulate.rule":     "%s %s: %d次 (%d條長龍)",
+	"simulate.longest":  "各屬性最長長龍:",
+	"simulate.hint":     "💡 調整規則後可點擊重新模擬比較",
+	"simulate.rerun":    "🔄 重新模擬",
+
+	// 提醒统计
+	"stats.title":      "📨 <b>本群提醒統計</b>（最近%d天）",
+	"stats.group_only": "⚠️ 請在群組中使用此指令",
+	"stats.empty":      "暫無提醒傳送紀錄",
+	"stats.day":        "<code>%s</code> 成功 %d 條 / 失敗 %d 條 · %d 條長龍 · 平均延遲 %s",
+	"stats.total":      "合計: 成功 %d 條 / 失敗 %d 條 · %d 條長龍\n平均延遲 %s · 最大延遲 %s",
+	"stats.last_error": "最近一次失敗: %s\n<code>%s</code>",
+	"stats.seconds":    "%.1f秒",
+
+	// 定时汇总
+	"menu.digest":           "📰 匯總模式",
+	"digest.title":          "📰 提醒方式\n目前方式: %s\n匯總間隔: %s\n\n• 逐條提醒：每期傳送達到條件的長龍\n• 定時匯總：按間隔匯總達到條件的長龍、已結束的長龍和各屬性最長的長龍\n• 兩者都傳送：逐條提醒並定時匯總",
+	"digest.mode.immediate": "🔔 逐條提醒",
+	"digest.mode.digest":    "📰 定時匯總",
+	"digest.mode.both":      "🔔+📰 兩者都傳送",
+	"digest.every":          "每%s",
+	"digest.minutes.one":    "%d分鐘",
+	"digest.minutes.other":  "%d分鐘",
+	"digest.header":         "📰 <b>長龍匯總</b>（%s）",
+	"digest.active":         "🔥 <b>進行中</b>（%d 條）",
+	"digest.ended":          "🏁 <b>已結束</b>（%d 條）",
+	"digest.ended.line":     "%s: <b>%s</b> %s~%s期",
+	"digest.longest":        "📏 <b>各屬性最長</b>",
+
+	// 路单
+	"menu.road.on":  "🛣 提醒附帶路單圖",
+	"menu.road.off": "🛣 取消提醒附帶路單圖",
+	"road.caption":  "🛣 %s路單 最近%s\n上：珠盤路  下：大路\n%s\n最新: %s期（黃框）",
+	"road.usage":    "用法: /road [size|parity|combo] [期數]\n期數範圍 %d-%d",
+	"road.empty":    "暫無開獎資料",
+
+	// 走势
+	"trend.title":   "📉 <b>開獎走勢</b>（每頁%s，第%d頁）",
+	"trend.usage":   "用法: /trend [期數]\n期數範圍 %d-%d",
+	"trend.qihao":   "期號",
+	"trend.numbers": "號碼",
+	"trend.sum":     "和",
+	"trend.runs":    "🔥 <b>目前連續</b>",
+	"trend.run":     "%s: %s 連續%s",
+	"trend.older":   "◀️ 更早",
+	"trend.newer":   "較新 ▶️",
+	"trend.latest":  "🔄 最新",
+
+	// 当前长龙
+	"current.title":   "🔎 <b>目前進行中的長龍</b>",
+	"current.reached": "🔥已達到提醒條件",
+	"current.need":    "還差%s",
+	"current.rarity":  "稀有度 %d/%d",
+	"current.off":     "未啟用提醒",
+
+	// 历史长龙
+	"history.title":       "📜 <b>本群歷史長龍</b>（共 %d 條）",
+	"history.filter":      "篩選: %s",
+	"history.empty":       "沒有符合條件的長龍",
+	"history.usage":       "用法: /history [屬性] [格式] [最小期數] [開始日期] [結束日期]\n屬性: 大小 單雙 和值 組合\n格式: a ab abb ab_ac ab_cd abab\n日期: 2024-05-01 或 05-01，也可以用 7d 表示最近7天\n例如: /history 大小 a 8 7d",
+	"history.line":        "%s %s <b>%s</b>\n   %s~%s期 · %s",
+	"history.older":       "更早 ▶️",
+	"history.newer":       "◀️ 較新",
+	"history.detail":      "📜 <b>%s %s</b> %s\n\n期號: %s ~ %s期\n最終長度: <b>%s</b>\n時間: %s ~ %s（%s）\n首次提醒: %s，最後提醒時 %s\n提醒訊息: %d 條",
+	"history.pattern":     "走勢: %s",
+	"history.duration.m":  "%d分鐘",
+	"history.duration.hm": "%d小時%d分鐘",
+
+	// 规则命令
+	"rules.cmd.usage.set":     "用法: /set <屬性> <格式> <觸發值>\n屬性: 大小(size) 單雙(parity) 和值(sum) 組合(combo)，* 表示全部\n格式: a ab abb ab_ac ab_cd abab，* 表示全部\n觸發值: 數字為長度（a格式為期數，其他為組數），r60 表示按稀有度60觸發\n例如: /set size a 6",
+	"rules.cmd.usage.enable":  "用法: /%s <屬性> <格式>\n例如: /enable parity abb、/disable combo *",
+	"rules.cmd.bad_attr":      "⚠️ 未知屬性: %s\n可用: 大小(size) 單雙(parity) 和值(sum) 組合(combo)",
+	"rules.cmd.bad_pattern":   "⚠️ 未知格式: %s\n可用: a ab abb ab_ac ab_cd abab",
+	"rules.cmd.bad_combo":     "⚠️ %s沒有%s格式",
+	"rules.cmd.bad_value":     "⚠️ %s的觸發值範圍為 %s-%s",
+	"rules.cmd.bad_rarity":    "⚠️ 稀有度範圍為 %d-%d",
+	"rules.cmd.updated.one":   "✅ 已更新 %d 條規則:",
+	"rules.cmd.updated.other": "✅ 已更新 %d 條規則:",
+	"rules.cmd.line":          "%s %s: %s %s",
+	"rules.cmd.block":         "📋 目前規則\n複製下面的內容修改後，以 /rules 開頭傳送即可批次更新\n每行: 屬性 格式 觸發值 [off]（off 表示停用，r60 表示按稀有度觸發）",
+	"rules.cmd.line_error":    "⚠️ 第%d行: %s",
+	"rules.cmd.line_format":   "格式應為: 屬性 格式 觸發值 [off]",
+
+	// 阈值输入
+	"prompt.ask":     "✏️ %s 請回覆 %s %s 的新觸發值\n目前: %s\n範圍: %s\n%d分鐘內有效",
+	"prompt.rarity":  "稀有度%d-%d",
+	"prompt.expired": "⌛ 輸入已逾時，請重新點擊選單中的觸發值",
+
+	// 命令菜單
+	"command.start":    "查看歡迎資訊和使用說明",
+	"command.long":     "設定長龍提醒（僅群組管理員）",
+	"command.data":     "查看機器人資料統計",
+	"command.records":  "查看長龍紀錄榜",
+	"command.template": "設定提醒訊息範本（僅群組管理員）",
+	"command.stats":    "查看本群組的提醒發送統計",
+	"command.road":     "查看路單圖（大小/單雙/組合）",
+	"command.trend":    "查看最近開獎走勢",
+	"command.current":  "查看目前所有進行中的長龍",
+	"command.history":  "瀏覽本群組的歷史長龍",
+	"command.rules":    "查看和批次修改提醒規則（僅群組管理員）",
+	"command.set":      "設定規則觸發值，如 /set size a 6（僅群組管理員）",
+	"command.enable":   "啟用規則，如 /enable parity abb（僅群組管理員）",
+	"command.disable":  "停用規則，如 /disable combo *（僅群組管理員）",
+
+	"list.separator": "、",
+
+	// 提醒時段
+	"schedule.title":           "⏰ 提醒時段\n時區: %s\n提醒時段: %s\n目前: %s\n\n靜默時段的長龍: %s",
+	"schedule.critical":        "（≥%d組）",
+	"schedule.hint":            "結束時間早於開始時間表示跨午夜，如 20:00-02:00",
+	"schedule.active":          "🔔 提醒中",
+	"schedule.quiet":           "🌙 靜默中",
+	"schedule.timezone":        "🌐 切換時區: %s",
+	"schedule.start":           "開始 %02d:00",
+	"schedule.end":             "結束 %02d:00",
+	"schedule.critical_button": "緊急 ≥%d組",
+	"schedule.policy.drop":     "丟棄",
+	"schedule.policy.digest":   "彙總後發送",
+	"schedule.policy.critical": "僅緊急長龍",
+	"schedule.all_day":         "全天",
+	"schedule.every_day":       "每天",
+	"schedule.never":           "不提醒",
+	"schedule.days":            "週%s",
+	"weekday.0":                "日",
+	"weekday.1":                "一",
+	"weekday.2":                "二",
+	"weekday.3":                "三",
+	"weekday.4":                "四",
+	"weekday.5":                "五",
+	"weekday.6":                "六",
+
+	// 舊提醒清理
+	"cleanup.title":         "🧹 舊提醒清理\n目前方式: %s\n\n• 刪除上一則提醒：發送新提醒後刪除之前的提醒\n• 逾時刪除：提醒發送 %d 分鐘後自動刪除\n• 回覆串聯：延續的長龍回覆到它的第一則提醒",
+	"cleanup.minutes":       "⏱ %d分鐘",
+	"cleanup.mode.none":     "不清理",
+	"cleanup.mode.previous": "刪除上一則提醒",
+	"cleanup.mode.expire":   "逾時刪除",
+	"cleanup.mode.thread":   "回覆串聯",
+
+	// 重新加入群組
+	"rejoin.welcome":    "👋 歡迎回來！之前的長龍提醒設定已保留",
+	"rejoin.resumed":    "，提醒已恢復",
+	"rejoin.hint":       "使用 /long 查看或修改設定",
+	"rejoin.restored":   "✅ 機器人已重新加入，長龍提醒已恢復",
+	"rejoin.kicked":     "此前機器人被移出群組，提醒已自動暫停。",
+	"rejoin.not_found":  "此前無法找到本群組，提醒已自動暫停。",
+	"rejoin.permission": "此前因沒有發送權限連續失敗 %d 次，提醒已自動暫停。",
+	"rejoin.last_error": "最後一次錯誤：%s",
+	"rejoin.check":      "請管理員確認機器人擁有發送訊息的權限，可透過 /long 查看設定。",
+
+	// 預覽
+	"preview.no_rules": "👁 目前沒有啟用的規則，無法預覽",
+	"preview.real":     "👁 <b>預覽</b>：以下為目前真實長龍按本群規則產生的提醒",
+	"preview.sample":   "👁 <b>預覽</b>：目前沒有達到門檻的長龍，以下為按本群規則產生的範例",
+	"preview.note":     "<i>此訊息僅為預覽，並非真實提醒</i>",
+
+	// 提醒範本
+	"template.usage":                 "📝 提醒範本\n目前範本: %s\n\n內建範本: %s\n\n/template <名稱> - 使用內建範本\n/template set <範本內容> - 自訂範本（Go text/template 語法，可換行）\n/template show - 查看目前範本內容\n/template preview - 預覽提醒效果\n/template reset - 恢復預設範本\n\n可用資料: .Qihao .Draw .Total .Groups .Results\n每條長龍: .AttributeName .PatternName .Count .Groups .Length .Unit .Detail .StartQihao .CurrentQihao .Rarity .Stars .Odds .Percentile\n範例: 🔥 {{.Qihao}}期{{range .Results}} | {{.AttributeName}}{{.PatternName}} {{.Length}}{{.Unit}}{{end}}",
+	"template.show":                  "📝 目前範本: %s\n\n<pre>%s</pre>",
+	"template.invalid":               "❌ 範本無效: %s",
+	"template.switched":              "✅ 已切換為 %s 範本，發送 /template preview 查看效果",
+	"template.error.blank":           "範本內容為空",
+	"template.error.size":            "範本過長（最多%d字）",
+	"template.error.syntax":          "範本語法錯誤: %v",
+	"template.error.exec":            "範本執行錯誤: %v",
+	"template.error.empty":           "範本輸出為空",
+	"template.error.too_long":        "輸出超過%d字，Telegram 無法發送",
+	"template.error.sample_too_long": "範例輸出超過%d字，Telegram 無法發送",
+	"template.error.define":          "不支援 define/block",
+	"template.error.template":        "不支援 template",
+	"template.error.range_depth":     "range 最多巢狀%d層",
+	"template.error.range_field":     "range 只能走訪 .Groups、.Results、.Items 等資料欄位",
+	"template.error.tag":             "不支援的標籤 <%s>",
+	"template.error.tag_close":       "標籤 </%s> 沒有正確閉合",
+	"template.error.tag_open":        "標籤 <%s> 沒有閉合",
+}
@@ -0,0 +1,165 @@
+package leader
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"dragon-alert-bot/db"
+	"log"
+	"sync/atomic"
+	"time"
+)
+
+// checkInterval 抢锁和续约检查的间隔，决定故障切换速度
+const checkInterval = time.Second
+
+// Elector 基于 MySQL GET_LOCK 的主节点选举
+// 锁与数据库连接绑定：主节点进程退出或连接断开时 MySQL 自动释放锁，其他实例在下一次检查时接管
+type Elector struct {
+	lockName  string
+	onElected func()
+
+	conn   *sql.Conn
+	locked atomic.Bool // 持有锁（包括调用 onElected 的准备阶段）
+	leader atomic.Bool
+	stop   chan struct{}
+	done   chan struct{}
+}
+
+var current *Elector
+
+// Start 启用高可用模式并开始选举，onElected 在成为主节点后、开始处理开奖之前调用
+func Start(lockName string, onElected func()) {
+	current = &Elector{
+		lockName:  lockName,
+		onElected: onElected,
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+	go current.run()
+}
+
+// Stop 主动释放主节点身份（进程退出时调用，便于其他实例立即接管）
+func Stop() {
+	if current == nil {
+		return
+	}
+	close(current.stop)
+	<-current.done
+}
+
+// Enabled 是否启用了高可用模式
+func Enabled() bool {
+	return current != nil
+}
+
+// IsLeader 当前实例是否为主节点（未启用高可用模式时始终为主节点）
+func IsLeader() bool {
+	if current == nil {
+		return true
+	}
+	return current.leader.Load()
+}
+
+// HoldsLock 当前实例是否持有主节点锁：与 IsLeader 的区别是成为主节点的准备阶段（onElected）也为 true，
+// 用于判断本实例的写入是否仍然有效（未启用高可用模式时始终为 true）
+func HoldsLock() bool {
+	if current == nil {
+		return true
+	}
+	return current.locked.Load()
+}
+
+func (e *Elector) run() {
+	defer close(e.done)
+
+	ticker := time.NewTicker(checkInterval)
+	defer ticker.Stop()
+
+	for {
+		if e.leader.Load() {
+			e.renew()
+		} else {
+			e.tryAcquire()
+		}
+
+		select {
+		case <-e.stop:
+			e.release()
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// tryAcquire 尝试获取锁（不等待）
+func (e *Elector) tryAcquire() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if e.conn == nil {
+		conn, err := db.WriteDB.Conn(ctx)
+		if err != nil {
+			return
+		}
+		e.conn = conn
+	}
+
+	var got sql.NullInt64
+	if err := e.conn.QueryRowContext(ctx, "SELECT GET_LOCK(?, 0)", e.lockName).Scan(&got); err != nil {
+		log.Printf("[主节点选举] 抢锁失败: %v", err)
+		e.closeConn()
+		return
+	}
+
+	if !got.Valid || got.Int64 != 1 {
+		return
+	}
+
+	log.Println("[主节点选举] 已成为主节点")
+	e.locked.Store(true)
+	if e.onElected != nil {
+		e.onElected()
+	}
+	e.leader.Store(true)
+}
+
+// renew 确认锁仍由本连接持有，连接断开或锁丢失时立即降级
+func (e *Elector) renew() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var holding sql.NullBool
+	err := e.conn.QueryRowContext(ctx, "SELECT IS_USED_LOCK(?) = CONNECTION_ID()", e.lockName).Scan(&holding)
+	if err == nil && holding.Valid && holding.Bool {
+		return
+	}
+
+	e.locked.Store(false)
+	e.leader.Store(false)
+	log.Printf("[主节点选举] 失去主节点身份: %v", err)
+	e.closeConn()
+}
+
+func (e *Elector) release() {
+	if e.conn == nil {
+		return
+	}
+
+	if e.locked.Load() {
+		e.locked.Store(false)
+		e.leader.Store(false)
+		e.conn.ExecContext(context.Background(), "SELECT RELEASE_LOCK(?)", e.lockName)
+		log.Println("[主节点选举] 已释放主节点身份")
+	}
+	e.closeConn()
+}
+
+// closeConn 丢弃选举连接（不放回连接池，避免连接上残留的锁被其他查询"继承"）
+func (e *Elector) closeConn() {
+	if e.conn != nil {
+		e.conn.Raw(func(interface{}) error { return driver.ErrBadConn })
+		e.conn.Close()
+		e.conn = nil
+	}
+}
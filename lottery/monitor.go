@@ -1,7 +1,9 @@
 package lottery
 
 import (
+	"database/sql"
 	"dragon-alert-bot/db"
+	"dragon-alert-bot/leader"
 	"time"
 )
 
@@ -23,6 +25,11 @@ func (m *Monitor) Start() {
 }
 
 func (m *Monitor) checkNewData() {
+	// 高可用模式下只有主节点处理开奖
+	if !leader.IsLeader() {
+		return
+	}
+
 	// 获取当前最新期号
 	var latestQihao string
 	err := db.ReadDB.QueryRow("SELECT qihao FROM latest_lottery_data ORDER BY opentime DESC LIMIT 1").Scan(&latestQihao)
@@ -45,12 +52,15 @@ func (m *Monitor) checkNewData() {
 			return
 		}
 
-		// 更新检查状态
-		_, err = db.WriteDB.Exec("UPDATE lottery_check_state SET last_qihao = ?, last_check_time = ? WHERE id = 1",
-			latestQihao, time.Now())
+		// 更新检查状态（比较并交换：期号已被其他实例更新时不再处理，保证每期只处理一次）
+		res, err := db.WriteDB.Exec("UPDATE lottery_check_state SET last_qihao = ?, last_check_time = ? WHERE id = 1 AND last_qihao = ?",
+			latestQihao, time.Now(), lastQihao)
 		if err != nil {
 			return
 		}
+		if affected, err := res.RowsAffected(); err != nil || affected == 0 {
+			return
+		}
 
 		// 触发回调
 		if m.OnNewData != nil {
@@ -74,8 +84,8 @@ func (m *Monitor) getLatestData() (*LotteryData, error) {
 		return nil, err
 	}
 
-	// 解析时间字符串
-	data.OpenTime, _ = time.Parse("2006-01-02 15:04:05", openTimeStr)
+	// 解析时间字符串（开奖时间为本地时间，与数据库连接的 loc=Local 一致）
+	data.OpenTime, _ = time.ParseInLocation("2006-01-02 15:04:05", openTimeStr, time.Local)
 	data.CreatedAt, _ = time.Parse("2006-01-02 15:04:05", createdAtStr)
 	data.UpdatedAt, _ = time.Parse("2006-01-02 15:04:05", updatedAtStr)
 
@@ -95,23 +105,126 @@ func (m *Monitor) GetHistoryData(limit int) ([]LotteryData, error) {
 	}
 	defer rows.Close()
 
-	var dataList []LotteryData
+	return scanLotteryRows(rows)
+}
+
+// GetHistoryBeforeQihao 获取期号早于 qihao 的历史数据（从新到旧），qihao 为空时从最新一期开始
+func (m *Monitor) GetHistoryBeforeQihao(qihao string, limit int) ([]LotteryData, error) {
+	query := `
+		SELECT qihao, opentime, opennum, sum_value, source, created_at, updated_at
+		FROM latest_lottery_data`
+	var args []interface{}
+	if qihao != "" {
+		query += " WHERE qihao < ?"
+		args = append(args, qihao)
+	}
+	query += " ORDER BY qihao DESC LIMIT ?"
+	args = append(args, limit)
+
+	rows, err := db.ReadDB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanLotteryRows(rows)
+}
+
+// GetHistoryAfterQihao 获取期号晚于 qihao 的最早 limit 期（从新到旧）
+func (m *Monitor) GetHistoryAfterQihao(qihao string, limit int) ([]LotteryData, error) {
+	rows, err := db.ReadDB.Query(`
+		SELECT qihao, opentime, opennum, sum_value, source, created_at, updated_at
+		FROM latest_lottery_data
+		WHERE qihao > ?
+		ORDER BY qihao ASC
+		LIMIT ?
+	`, qihao, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	data, err := scanLotteryRows(rows)
+	if err != nil {
+		return nil, err
+	}
+	for i, j := 0, len(data)-1; i < j; i, j = i+1, j-1 {
+		data[i], data[j] = data[j], data[i]
+	}
+	return data, nil
+}
+
+// GetHistoryBefore 获取指定时间之前的历史数据（从新到旧）
+func (m *Monitor) GetHistoryBefore(before time.Time, limit int) ([]LotteryData, error) {
+	rows, err := db.ReadDB.Query(`
+		SELECT qihao, opentime, opennum, sum_value, source, created_at, updated_at 
+		FROM latest_lottery_data 
+		WHERE opentime < ?
+		ORDER BY opentime DESC 
+		LIMIT ?
+	`, before.Format("2006-01-02 15:04:05"), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanLotteryRows(rows)
+}
+
+// StreamRange 按开奖时间从旧到新逐条读取 [from, to) 范围内的数据，避免一次性载入内存
+func (m *Monitor) StreamRange(from, to time.Time, fn func(data *LotteryData) error) error {
+	rows, err := db.ReadDB.Query(`
+		SELECT qihao, opentime, opennum, sum_value, source, created_at, updated_at 
+		FROM latest_lottery_data 
+		WHERE opentime >= ? AND opentime < ?
+		ORDER BY opentime ASC
+	`, from.Format("2006-01-02 15:04:05"), to.Format("2006-01-02 15:04:05"))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
 	for rows.Next() {
-		var data LotteryData
-		var openTimeStr, createdAtStr, updatedAtStr string
+		data, err := scanLotteryData(rows)
+		if err != nil {
+			return err
+		}
+		if err := fn(data); err != nil {
+			return err
+		}
+	}
 
-		err := rows.Scan(&data.Qihao, &openTimeStr, &data.OpenNum, &data.SumValue, &data.Source, &createdAtStr, &updatedAtStr)
+	return rows.Err()
+}
+
+// scanLotteryRows 读取全部开奖数据行
+func scanLotteryRows(rows *sql.Rows) ([]LotteryData, error) {
+	var dataList []LotteryData
+	for rows.Next() {
+		data, err := scanLotteryData(rows)
 		if err != nil {
 			return nil, err
 		}
+		dataList = append(dataList, *data)
+	}
 
-		// 解析时间字符串
-		data.OpenTime, _ = time.Parse("2006-01-02 15:04:05", openTimeStr)
-		data.CreatedAt, _ = time.Parse("2006-01-02 15:04:05", createdAtStr)
-		data.UpdatedAt, _ = time.Parse("2006-01-02 15:04:05", updatedAtStr)
+	return dataList, nil
+}
 
-		dataList = append(dataList, data)
+// scanLotteryData 读取一行开奖数据
+func scanLotteryData(rows *sql.Rows) (*LotteryData, error) {
+	var data LotteryData
+	var openTimeStr, createdAtStr, updatedAtStr string
+
+	err := rows.Scan(&data.Qihao, &openTimeStr, &data.OpenNum, &data.SumValue, &data.Source, &createdAtStr, &updatedAtStr)
+	if err != nil {
+		return nil, err
 	}
 
-	return dataList, nil
+	// 解析时间字符串（开奖时间为本地时间，与数据库连接的 loc=Local 一致）
+	data.OpenTime, _ = time.ParseInLocation("2006-01-02 15:04:05", openTimeStr, time.Local)
+	data.CreatedAt, _ = time.Parse("2006-01-02 15:04:05", createdAtStr)
+	data.UpdatedAt, _ = time.Parse("2006-01-02 15:04:05", updatedAtStr)
+
+	return &data, nil
 }
@@ -2,10 +2,12 @@ package main
 
 import (
 	"dragon-alert-bot/alert"
+	"dragon-alert-bot/backtest"
 	"dragon-alert-bot/bot"
 	"dragon-alert-bot/config"
 	"dragon-alert-bot/db"
 	"dragon-alert-bot/dragon"
+	"dragon-alert-bot/leader"
 	"dragon-alert-bot/lottery"
 	"log"
 	"os"
@@ -15,6 +17,11 @@ import (
 )
 
 func main() {
+	// 子命令：历史回测
+	if len(os.Args) > 1 && os.Args[1] == "backtest" {
+		os.Exit(backtest.RunCLI(os.Args[2:]))
+	}
+
 	log.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
 	log.Println("长龙提醒机器人启动中...")
 	log.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
@@ -39,9 +46,45 @@ func main() {
 	// 创建模块
 	monitor := lottery.NewMonitor()
 	analyzer := dragon.NewAnalyzer(monitor)
-	tracker := dragon.NewTracker()
+	tracker := dragon.NewTracker(cfg.TrackerJournal)
+	if err := tracker.Restore(); err != nil {
+		log.Fatalf("长龙跟踪状态恢复失败: %v", err)
+	}
+
+	// 按开奖历史校正停机期间的长龙状态
+	reconcile := func() {
+		if attrs, err := analyzer.LoadAttrs(2000); err != nil {
+			log.Printf("启动校正失败，读取开奖历史出错: %v", err)
+		} else {
+			tracker.Reconcile(attrs)
+		}
+	}
+
+	if cfg.HAMode {
+		// 高可用模式：成为主节点时接管数据库中的最新状态再开始处理
+		leader.Start(cfg.LeaderLockName, func() {
+			if err := tracker.Reload(); err != nil {
+				log.Printf("长龙跟踪状态加载失败: %v", err)
+			}
+			reconcile()
+		})
+		defer leader.Stop()
+		log.Println("✓ 高可用模式已启用，等待主节点选举")
+	} else {
+		reconcile()
+	}
+	// 在释放主节点锁之前落库剩余变更（defer 按相反顺序执行）
+	defer tracker.Close()
+
+	records := dragon.NewRecordKeeper()
 	dispatcher := alert.NewDispatcher(analyzer, tracker)
 
+	bot.SetModules(bot.Modules{
+		Monitor:  monitor,
+		Analyzer: analyzer,
+		Records:  records,
+	})
+
 	// 设置新数据回调
 	monitor.OnNewData = func(data *lottery.LotteryData) {
 		attrs := data.CalculateAttributes()
@@ -54,11 +97,19 @@ func main() {
 			SumValue: data.SumValue,
 			Size:     attrs.Size,
 			Parity:   attrs.Parity,
+			OpenTime: data.OpenTime,
 		}
 
 		// 分析长龙
 		results := analyzer.Analyze(data)
 
+		// 全局长龙登记（每期一次，与群组数量无关）
+		ended := tracker.Track(results)
+
+		// 更新长龙纪录（全局，每期一次）
+		runs := analyzer.CurrentRuns()
+		recordEvents := records.Update(runs)
+
 		// 获取所有启用的群组
 		chatIDs, err := analyzer.GetActiveChats()
 		if err != nil {
@@ -90,31 +141,47 @@ func main() {
 					return
 				}
 
-				if len(rules) == 0 {
+				chatConfig, err := analyzer.GetChatConfig(cid)
+				if err != nil {
 					return
 				}
 
+				// 平/破纪录提醒（仅发送给启用了对应规则的群组）
+				for _, event := range recordEvents {
+					if hasRule(rules, event.Result) {
+						dispatcher.SendRecordAlert(chatConfig, event, currentInfo)
+					}
+				}
+
 				// 根据规则过滤结果
 				filteredResults := analyzer.FilterResultsByRules(results, rules)
 
 				if len(filteredResults) > 0 {
 					log.Printf("[长龙提醒] 群组:%d 匹配:%d个长龙", cid, len(filteredResults))
-					dispatcher.ProcessNewData(cid, filteredResults, currentInfo)
 
 					mu.Lock()
 					alertCount++
 					mu.Unlock()
 				}
+
+				// 实时榜单模式的群组即使没有达到阈值的长龙也每期更新榜单
+				dispatcher.ProcessNewData(chatConfig, filteredResults, runs, rules, currentInfo)
 			}(chatID)
 		}
 
 		wg.Wait()
 
+		// 定时汇总：标记结束的长龙并发送到期的汇总（在各群组暂存本期长龙之后）
+		dispatcher.AdvanceDigests(ended)
+
 		if alertCount == 0 && len(results) > 0 {
 			log.Printf("[长龙检测] 发现%d个长龙但未达到任何群组阈值", len(results))
 		}
 	}
 
+	// 定时任务（清理超时提醒、静默时段汇总、定时汇总）
+	go dispatcher.RunScheduler()
+
 	// 启动监测（在 goroutine 中）
 	go monitor.Start()
 	log.Println("✓ 开奖监测启动")
@@ -135,3 +202,13 @@ func main() {
 	log.Println("\n收到退出信号，正在关闭...")
 	log.Println("再见！")
 }
+
+// hasRule 判断规则列表中是否包含该长龙对应的规则
+func hasRule(rules []db.DragonRule, result *dragon.PatternResult) bool {
+	for _, rule := range rules {
+		if rule.PatternType == result.PatternType && rule.AttributeType == result.AttributeType {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,228 @@
+package road
+
+import (
+	"bytes"
+	"dragon-alert-bot/lottery"
+	"image"
+	"image/color"
+	"image/png"
+)
+
+// 路单支持的属性（与长龙的属性类型一致）
+const (
+	AttrSize   = "size"
+	AttrParity = "parity"
+	AttrCombo  = "size_parity"
+)
+
+// 路单期数
+const (
+	DefaultDraws = 60
+	MinDraws     = 12
+	MaxDraws     = 120
+)
+
+// Rows 珠盘路和大路的行数
+const Rows = 6
+
+// 图片尺寸（像素）
+const (
+	cellSize = 20
+	padding  = 10
+	gap      = 14 // 珠盘路与大路之间的间隔
+)
+
+var (
+	background = color.RGBA{0xff, 0xff, 0xff, 0xff}
+	gridLine   = color.RGBA{0xdd, 0xdd, 0xdd, 0xff}
+	highlight  = color.RGBA{0xff, 0xc1, 0x07, 0xff} // 最新一期
+)
+
+// Colors 各属性值的颜色，组合路单按大小单双区分四种颜色
+var Colors = map[string]color.RGBA{
+	"大":  {0xd3, 0x2f, 0x2f, 0xff},
+	"小":  {0x19, 0x76, 0xd2, 0xff},
+	"单":  {0xd3, 0x2f, 0x2f, 0xff},
+	"双":  {0x19, 0x76, 0xd2, 0xff},
+	"大单": {0xd3, 0x2f, 0x2f, 0xff},
+	"大双": {0xf5, 0x7c, 0x00, 0xff},
+	"小单": {0x38, 0x8e, 0x3c, 0xff},
+	"小双": {0x19, 0x76, 0xd2, 0xff},
+}
+
+// Legend 各属性路单的值（图例顺序）
+var Legend = map[string][]string{
+	AttrSize:   {"大", "小"},
+	AttrParity: {"单", "双"},
+	AttrCombo:  {"大单", "大双", "小单", "小双"},
+}
+
+// Cell 路单中的一格
+type Cell struct {
+	Col   int
+	Row   int
+	Value string
+}
+
+// Values 从属性列表（从旧到新）提取最近 n 期的路单值
+func Values(attrs []lottery.Attributes, attr string, n int) []string {
+	if len(attrs) > n {
+		attrs = attrs[len(attrs)-n:]
+	}
+
+	values := make([]string, 0, len(attrs))
+	for _, a := range attrs {
+		switch attr {
+		case AttrSize:
+			values = append(values, a.Size)
+		case AttrParity:
+			values = append(values, a.Parity)
+		case AttrCombo:
+			values = append(values, a.Size+a.Parity)
+		}
+	}
+	return values
+}
+
+// BeadPlate 珠盘路：按顺序从上到下、从左到右逐格排列
+func BeadPlate(values []string) []Cell {
+	cells := make([]Cell, 0, len(values))
+	for i, v := range values {
+		cells = append(cells, Cell{Col: i / Rows, Row: i % Rows, Value: v})
+	}
+	return cells
+}
+
+// BigRoad 大路：相同的值向下排列，变化时换到新的一列；
+// 向下到底或被占用时向右转弯（长龙拖尾），新的一列从上一列起点的右边开始
+func BigRoad(values []string) []Cell {
+	cells := make([]Cell, 0, len(values))
+	occupied := make(map[[2]int]bool)
+
+	col, row, startCol := 0, 0, -1
+	for i, v := range values {
+		switch {
+		case i == 0 || v != values[i-1]:
+			startCol++
+			for occupied[[2]int{startCol, 0}] {
+				startCol++
+			}
+			col, row = startCol, 0
+		case row+1 < Rows && !occupied[[2]int{col, row + 1}]:
+			row++
+		default:
+			col++
+		}
+
+		occupied[[2]int{col, row}] = true
+		cells = append(cells, Cell{Col: col, Row: row, Value: v})
+	}
+	return cells
+}
+
+// Render 绘制路单图片（PNG）：上方为珠盘路（实心圆），下方为大路（空心圆），最新一期加框标出
+func Render(values []string) ([]byte, error) {
+	bead := BeadPlate(values)
+	big := BigRoad(values)
+
+	cols := columns(bead)
+	if c := columns(big); c > cols {
+		cols = c
+	}
+	if cols == 0 {
+		cols = 1
+	}
+
+	gridHeight := Rows * cellSize
+	width := padding*2 + cols*cellSize
+	height := padding*2 + gridHeight*2 + gap
+
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	fillRect(img, img.Bounds(), background)
+
+	beadOrigin := image.Pt(padding, padding)
+	bigOrigin := image.Pt(padding, padding+gridHeight+gap)
+	drawGrid(img, beadOrigin, cols)
+	drawGrid(img, bigOrigin, cols)
+
+	for i, c := range bead {
+		drawCell(img, beadOrigin, c, true, i == len(bead)-1)
+	}
+	for i, c := range big {
+		drawCell(img, bigOrigin, c, false, i == len(big)-1)
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// columns 路单占用的列数
+func columns(cells []Cell) int {
+	n := 0
+	for _, c := range cells {
+		if c.Col+1 > n {
+			n = c.Col + 1
+		}
+	}
+	return n
+}
+
+func drawGrid(img *image.RGBA, origin image.Point, cols int) {
+	right := origin.X + cols*cellSize
+	bottom := origin.Y + Rows*cellSize
+	for r := 0; r <= Rows; r++ {
+		y := origin.Y + r*cellSize
+		fillRect(img, image.Rect(origin.X, y, right+1, y+1), gridLine)
+	}
+	for c := 0; c <= cols; c++ {
+		x := origin.X + c*cellSize
+		fillRect(img, image.Rect(x, origin.Y, x+1, bottom+1), gridLine)
+	}
+}
+
+func drawCell(img *image.RGBA, origin image.Point, c Cell, filled, latest bool) {
+	x := origin.X + c.Col*cellSize
+	y := origin.Y + c.Row*cellSize
+
+	if latest {
+		box := image.Rect(x, y, x+cellSize+1, y+cellSize+1)
+		fillRect(img, image.Rect(box.Min.X, box.Min.Y, box.Max.X, box.Min.Y+2), highlight)
+		fillRect(img, image.Rect(box.Min.X, box.Max.Y-2, box.Max.X, box.Max.Y), highlight)
+		fillRect(img, image.Rect(box.Min.X, box.Min.Y, box.Min.X+2, box.Max.Y), highlight)
+		fillRect(img, image.Rect(box.Max.X-2, box.Min.Y, box.Max.X, box.Max.Y), highlight)
+	}
+
+	col, ok := Colors[c.Value]
+	if !ok {
+		return
+	}
+
+	// 以格子中心为圆心，实心圆填满半径，空心圆只画外圈
+	cx := float64(x) + float64(cellSize)/2 + 0.5
+	cy := float64(y) + float64(cellSize)/2 + 0.5
+	outer := float64(cellSize)/2 - 3
+	inner := outer - 3
+	for py := y + 1; py < y+cellSize; py++ {
+		for px := x + 1; px < x+cellSize; px++ {
+			dx := float64(px) + 0.5 - cx
+			dy := float64(py) + 0.5 - cy
+			d := dx*dx + dy*dy
+			if d > outer*outer || (!filled && d < inner*inner) {
+				continue
+			}
+			img.SetRGBA(px, py, col)
+		}
+	}
+}
+
+func fillRect(img *image.RGBA, r image.Rectangle, c color.RGBA) {
+	r = r.Intersect(img.Bounds())
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			img.SetRGBA(x, y, c)
+		}
+	}
+}
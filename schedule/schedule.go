@@ -0,0 +1,142 @@
+package schedule
+
+import (
+	"dragon-alert-bot/db"
+	"dragon-alert-bot/i18n"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	// 内置时区数据，部署环境没有 zoneinfo 时也能加载群组时区
+	_ "time/tzdata"
+)
+
+// 静默时段的处理方式（chat_configs.quiet_policy）
+const (
+	PolicyDrop     = "drop"     // 直接丢弃
+	PolicyDigest   = "digest"   // 汇总后在提醒时段开始时发送
+	PolicyCritical = "critical" // 只发送达到紧急长度的长龙
+)
+
+// AllDays 每天都提醒的星期掩码
+const AllDays = 1<<7 - 1
+
+// DefaultTimezone 默认时区
+const DefaultTimezone = "Asia/Shanghai"
+
+// Timezones 菜单中可选的时区
+var Timezones = []string{
+	"Asia/Shanghai",
+	"Asia/Taipei",
+	"Asia/Hong_Kong",
+	"Asia/Singapore",
+	"Asia/Tokyo",
+	"Europe/London",
+	"America/New_York",
+	"UTC",
+}
+
+// Schedule 群组的提醒时段
+// Days 为星期掩码（第 n 位对应 time.Weekday(n)），每天在 [Start, End) 小时内提醒；
+// Start > End 表示跨午夜（如 20-2），午夜之后的部分属于前一天的时段
+type Schedule struct {
+	Location *time.Location
+	Days     int
+	Start    int // 0-23
+	End      int // 1-24
+	Policy   string
+	Critical int // PolicyCritical 下静默时段仍然提醒的最小组数
+}
+
+// FromConfig 由群组配置构建提醒时段，时区无效时使用默认时区
+func FromConfig(cfg *db.ChatConfig) *Schedule {
+	loc, err := time.LoadLocation(cfg.Timezone)
+	if err != nil {
+		loc, _ = time.LoadLocation(DefaultTimezone)
+	}
+
+	return &Schedule{
+		Location: loc,
+		Days:     cfg.ActiveDays,
+		Start:    cfg.WindowStart,
+		End:      cfg.WindowEnd,
+		Policy:   cfg.QuietPolicy,
+		Critical: cfg.QuietCritical,
+	}
+}
+
+// AlwaysOn 是否全天提醒（没有静默时段）
+func (s *Schedule) AlwaysOn() bool {
+	return s.Days&AllDays == AllDays && s.Start == 0 && s.End >= 24
+}
+
+// Active 判断某一时刻是否处于提醒时段，Start == End 的时段为空
+func (s *Schedule) Active(now time.Time) bool {
+	if s.AlwaysOn() {
+		return true
+	}
+	if s.Start == s.End {
+		return false
+	}
+
+	t := now.In(s.Location)
+	hour := t.Hour()
+
+	if s.Start < s.End {
+		return s.dayEnabled(t.Weekday()) && hour >= s.Start && hour < s.End
+	}
+
+	// 跨午夜
+	if hour >= s.Start {
+		return s.dayEnabled(t.Weekday())
+	}
+	if hour < s.End {
+		return s.dayEnabled((t.Weekday() + 6) % 7)
+	}
+	return false
+}
+
+func (s *Schedule) dayEnabled(day time.Weekday) bool {
+	return s.Days&(1<<uint(day)) != 0
+}
+
+// 按周一到周日的顺序显示
+var weekdayOrder = []time.Weekday{
+	time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday, time.Saturday, time.Sunday,
+}
+
+// WeekdayOrder 返回周一到周日的顺序
+func WeekdayOrder() []time.Weekday {
+	return weekdayOrder
+}
+
+// WeekdayName 星期的简称
+func WeekdayName(lang string, day time.Weekday) string {
+	return i18n.T(lang, "weekday."+strconv.Itoa(int(day)))
+}
+
+// Describe 提醒时段的文字描述，如 "周一、二 09:00-23:00"
+func (s *Schedule) Describe(lang string) string {
+	if s.AlwaysOn() {
+		return i18n.T(lang, "schedule.all_day")
+	}
+
+	var days string
+	switch s.Days & AllDays {
+	case AllDays:
+		days = i18n.T(lang, "schedule.every_day")
+	case 0:
+		return i18n.T(lang, "schedule.never")
+	default:
+		var names []string
+		for _, day := range weekdayOrder {
+			if s.dayEnabled(day) {
+				names = append(names, WeekdayName(lang, day))
+			}
+		}
+		days = i18n.T(lang, "schedule.days", strings.Join(names, i18n.T(lang, "list.separator")))
+	}
+
+	return fmt.Sprintf("%s %02d:00-%02d:00", days, s.Start, s.End)
+}
@@ -0,0 +1,49 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+)
+
+func TestActive(t *testing.T) {
+	weekdays := 1<<time.Monday | 1<<time.Tuesday | 1<<time.Wednesday | 1<<time.Thursday | 1<<time.Friday
+
+	// 2026-10-19 为周一
+	at := func(day, hour int) time.Time {
+		return time.Date(2026, 10, day, hour, 30, 0, 0, time.UTC)
+	}
+
+	cases := []struct {
+		name       string
+		days       int
+		start, end int
+		now        time.Time
+		want       bool
+	}{
+		{"全天", AllDays, 0, 24, at(25, 3), true},
+
+		{"同一天 时段内", weekdays, 9, 17, at(19, 10), true},
+		{"同一天 开始前", weekdays, 9, 17, at(19, 8), false},
+		{"同一天 结束时", weekdays, 9, 17, at(19, 17), false},
+		{"同一天 未启用的星期", weekdays, 9, 17, at(24, 10), false},
+
+		{"跨午夜 午夜前", 1 << time.Friday, 20, 2, at(23, 21), true},
+		{"跨午夜 午夜后属于前一天", 1 << time.Friday, 20, 2, at(24, 1), true},
+		{"跨午夜 前一天未启用", 1 << time.Friday, 20, 2, at(23, 1), false},
+		{"跨午夜 结束后", 1 << time.Friday, 20, 2, at(24, 3), false},
+		{"跨午夜 当天未启用", 1 << time.Friday, 20, 2, at(24, 21), false},
+
+		{"跨周 周日到周一", 1 << time.Sunday, 22, 3, at(26, 2), true},
+		{"跨周 周六未启用", 1 << time.Sunday, 22, 3, at(25, 2), false},
+
+		{"开始等于结束 时段为空", AllDays, 9, 9, at(19, 9), false},
+		{"开始等于结束 其他时间", AllDays, 9, 9, at(19, 3), false},
+	}
+
+	for _, c := range cases {
+		s := &Schedule{Location: time.UTC, Days: c.days, Start: c.start, End: c.end}
+		if got := s.Active(c.now); got != c.want {
+			t.Errorf("%s: %02d-%02d %s Active = %v, want %v", c.name, c.start, c.end, c.now.Format("Mon 15:04"), got, c.want)
+		}
+	}
+}